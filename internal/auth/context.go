@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"context"
+	"go-tasker/schemas"
+)
+
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user.
+func WithUser(ctx context.Context, user *schemas.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user, or nil for anonymous requests.
+func UserFromContext(ctx context.Context) *schemas.User {
+	user, _ := ctx.Value(contextKey{}).(*schemas.User)
+	return user
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when a login names no user, so unknown
+// usernames take as long to reject as wrong passwords.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("go-tasker"), bcrypt.DefaultCost)
+	return hash
+})
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func CheckPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// RejectPassword spends as long on password as CheckPassword would, for
+// logins whose user does not exist.
+func RejectPassword(password string) {
+	bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+}
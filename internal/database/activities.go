@@ -0,0 +1,249 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"go-tasker/internal/events"
+	"go-tasker/internal/webhook"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"log"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/iancoleman/strcase"
+	"gorm.io/gorm"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var activitySortColumns = map[string]sortColumn[schemas.Activity]{
+	"id": {"activities.id", func(a schemas.Activity) any { return a.ID }},
+}
+
+// As returns a Service that records userID as the actor of the activities
+// its mutations log.
+func (s *service) As(userID uint) Service {
+	scoped := *s
+	scoped.actorID = &userID
+	return &scoped
+}
+
+// Events returns the hub the activities of committed changes are published
+// to.
+func (s *service) Events() *events.Hub {
+	return s.hub
+}
+
+// recordedKey is the context key of the activities recorded by the current
+// transaction.
+type recordedKey struct{}
+
+// transaction runs fn in a database transaction and, once it has committed,
+// publishes the activities it recorded.
+func (s *service) transaction(fn func(tx *gorm.DB) error) error {
+	var recorded []schemas.Activity
+	ctx := context.WithValue(context.Background(), recordedKey{}, &recorded)
+	if err := s.db.WithContext(ctx).Transaction(fn); err != nil {
+		return err
+	}
+
+	for _, activity := range recorded {
+		payload, err := activityPayload(&activity)
+		if err != nil {
+			log.Printf("publishing activity %d: %v", activity.ID, err)
+			continue
+		}
+		s.hub.Publish(activity.ProjectID, webhook.Event(activity.EntityType, activity.Action), payload)
+	}
+	return nil
+}
+
+// savepoint runs fn in a nested transaction of tx, so that when it fails
+// only its changes are rolled back, along with the activities it recorded.
+func (s *service) savepoint(tx *gorm.DB, fn func(tx *gorm.DB) error) error {
+	recorded, _ := tx.Statement.Context.Value(recordedKey{}).(*[]schemas.Activity)
+	var count int
+	if recorded != nil {
+		count = len(*recorded)
+	}
+
+	err := tx.Transaction(fn)
+	if err != nil && recorded != nil {
+		*recorded = (*recorded)[:count]
+	}
+	return err
+}
+
+// GetProjectActivities returns the activity feed of a project, newest first.
+func (s *service) GetProjectActivities(projectID string, query types.ActivityQuery) ([]schemas.Activity, string, error) {
+	tx := s.db.Model(&schemas.Activity{}).Where("activities.project_id = ?", projectID)
+	return s.activityPage(tx, query)
+}
+
+// GetTaskActivities returns the activity feed of a task, newest first. The
+// history of deleted tasks stays readable.
+func (s *service) GetTaskActivities(projectID string, listID string, taskID string, query types.ActivityQuery) ([]schemas.Activity, string, error) {
+	task, err := s.findTask(s.db.Unscoped(), projectID, listID, taskID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tx := s.db.Model(&schemas.Activity{}).Where("activities.task_id = ?", task.ID)
+	return s.activityPage(tx, query)
+}
+
+func (s *service) activityPage(tx *gorm.DB, query types.ActivityQuery) ([]schemas.Activity, string, error) {
+	if query.EntityType != "" {
+		tx = tx.Where("activities.entity_type = ?", query.EntityType)
+	}
+	if query.Action != "" {
+		tx = tx.Where("activities.action = ?", query.Action)
+	}
+
+	return paginate(tx, "activities", "-id", activitySortColumns, query.PageQuery,
+		func(a schemas.Activity) uint { return a.ID })
+}
+
+// record appends an activity by the current actor, queues it for the
+// webhooks subscribed to it and publishes it once the transaction commits.
+// before and after are the entity as it was and as it is now, either of
+// which may be nil; when both are set only the fields that changed are kept.
+func (s *service) record(tx *gorm.DB, activity schemas.Activity, before any, after any) error {
+	activity.ActorID = s.actorID
+
+	b, a := snapshot(before), snapshot(after)
+	if b != nil && a != nil {
+		for field, value := range a {
+			if field == "updated_at" || field == "version" || jsonEqual(value, b[field]) {
+				delete(a, field)
+				delete(b, field)
+			}
+		}
+	}
+
+	var err error
+	if activity.Before, err = json.Marshal(b); err != nil {
+		return err
+	}
+	if activity.After, err = json.Marshal(a); err != nil {
+		return err
+	}
+
+	if err := tx.Create(&activity).Error; err != nil {
+		return err
+	}
+	if recorded, ok := tx.Statement.Context.Value(recordedKey{}).(*[]schemas.Activity); ok {
+		*recorded = append(*recorded, activity)
+	}
+	return s.enqueueWebhooks(tx, &activity)
+}
+
+// activityPayload renders an activity as the JSON sent to webhooks and event
+// streams.
+func activityPayload(activity *schemas.Activity) ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":          activity.ID,
+		"event":       webhook.Event(activity.EntityType, activity.Action),
+		"created_at":  activity.CreatedAt,
+		"project_id":  activity.ProjectID,
+		"actor_id":    activity.ActorID,
+		"task_id":     activity.TaskID,
+		"entity_type": activity.EntityType,
+		"entity_id":   activity.EntityID,
+		"before":      activity.Before,
+		"after":       activity.After,
+	})
+}
+
+// recordTask appends an activity about a task of the project.
+func (s *service) recordTask(tx *gorm.DB, projectID string, task *schemas.Task, action string, before any, after any) error {
+	return s.record(tx, schemas.Activity{
+		ProjectID:  parseID(projectID),
+		TaskID:     &task.ID,
+		EntityType: schemas.EntityTask,
+		EntityID:   task.ID,
+		Action:     action,
+	}, before, after)
+}
+
+// recordList appends an activity about a list.
+func (s *service) recordList(tx *gorm.DB, list *schemas.List, action string, before any, after any) error {
+	return s.record(tx, schemas.Activity{
+		ProjectID:  list.ProjectID,
+		EntityType: schemas.EntityList,
+		EntityID:   list.ID,
+		Action:     action,
+	}, before, after)
+}
+
+// recordProject appends an activity about a project.
+func (s *service) recordProject(tx *gorm.DB, project *schemas.Project, action string, before any, after any) error {
+	return s.record(tx, schemas.Activity{
+		ProjectID:  project.ID,
+		EntityType: schemas.EntityProject,
+		EntityID:   project.ID,
+		Action:     action,
+	}, before, after)
+}
+
+// snapshot flattens an entity into its snake_case scalar fields, the way it
+// is rendered in responses. Associations are left out, and maps are kept as
+// they are for activities that are not about a whole entity.
+func snapshot(entity any) map[string]any {
+	if entity == nil {
+		return nil
+	}
+	if fields, ok := entity.(map[string]any); ok {
+		return fields
+	}
+
+	val := reflect.ValueOf(entity)
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+
+	fields := make(map[string]any)
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		value := val.Field(i)
+		if model, ok := value.Interface().(gorm.Model); ok {
+			fields["id"] = model.ID
+			fields["created_at"] = model.CreatedAt
+			fields["updated_at"] = model.UpdatedAt
+			continue
+		}
+
+		typ := field.Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if (typ.Kind() == reflect.Struct && typ != timeType) || typ.Kind() == reflect.Slice {
+			continue
+		}
+
+		fields[strcase.ToSnake(field.Name)] = value.Interface()
+	}
+	return fields
+}
+
+func jsonEqual(a any, b any) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(x) == string(y)
+}
+
+// parseID converts an ID taken from a request path, which has already been
+// matched against the database, back into its numeric form.
+func parseID(id string) uint {
+	parsed, _ := strconv.ParseUint(id, 10, 64)
+	return uint(parsed)
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/schemas"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotProjectMember = errors.New("user is not a member of this project")
+
+// AssignTask adds the user to the task's assignees. Only members of the
+// task's project can be assigned.
+func (s *service) AssignTask(projectID string, listID string, taskID string, userID uint) (*schemas.Task, error) {
+	task, err := s.findTask(s.db, projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetProjectRole(projectID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotProjectMember
+		}
+		return nil, err
+	}
+
+	var user schemas.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	err = s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(task).Association("Assignees").Append(&user); err != nil {
+			return err
+		}
+		return s.recordTask(tx, projectID, task, schemas.ActionAssigned, nil, map[string]any{"user_id": user.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findTaskWithAssignees(projectID, listID, taskID)
+}
+
+func (s *service) UnassignTask(projectID string, listID string, taskID string, userID string) (*schemas.Task, error) {
+	task, err := s.findTask(s.db, projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("DELETE FROM task_assignees WHERE task_id = ? AND user_id = ?", task.ID, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return s.recordTask(tx, projectID, task, schemas.ActionUnassigned, map[string]any{"user_id": parseID(userID)}, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findTaskWithAssignees(projectID, listID, taskID)
+}
+
+// GetAssignedTasks returns the open tasks assigned to the user across every
+// project they belong to, with their list and project loaded and ordered by
+// project, list and task.
+func (s *service) GetAssignedTasks(userID uint) ([]schemas.Task, error) {
+	var tasks []schemas.Task
+	if err := s.db.
+		Preload("List.Project").
+		Preload("Assignees").
+		Joins("JOIN task_assignees ON task_assignees.task_id = tasks.id").
+		Joins("JOIN lists ON lists.id = tasks.list_id AND lists.deleted_at IS NULL").
+		Joins("JOIN projects ON projects.id = lists.project_id AND projects.deleted_at IS NULL").
+		Where("task_assignees.user_id = ? AND tasks.done = ?", userID, false).
+		Order("projects.id").Order("lists.id").Order("tasks.id").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (s *service) findTaskWithAssignees(projectID string, listID string, taskID string) (*schemas.Task, error) {
+	return s.findTask(s.db.Preload("Assignees"), projectID, listID, taskID)
+}
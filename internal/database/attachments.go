@@ -0,0 +1,167 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/schemas"
+
+	"gorm.io/gorm"
+)
+
+// GetAttachments returns the attachments of a task, oldest first.
+func (s *service) GetAttachments(projectID string, listID string, taskID string) ([]schemas.Attachment, error) {
+	task, err := s.findTask(s.db, projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachments []schemas.Attachment
+	if err := s.db.Where("task_id = ?", task.ID).Order("id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (s *service) GetAttachment(projectID string, listID string, taskID string, attachmentID string) (*schemas.Attachment, error) {
+	return s.findAttachment(s.db, projectID, listID, taskID, attachmentID)
+}
+
+// errBlobRemoved is returned inside CreateAttachment when the blob it meant
+// to reuse was removed by DeleteUnusedBlobs before the attachment was saved.
+var errBlobRemoved = errors.New("blob was removed while attaching it")
+
+// CreateAttachment attaches a file to a task. When no blob with the same hash
+// exists yet, storeBlob is called to write the content to the blob store
+// before the write transaction starts, so the upload does not hold the
+// database lock; if it fails nothing is recorded.
+func (s *service) CreateAttachment(projectID string, listID string, taskID string, attachment *schemas.Attachment, storeBlob func() error) error {
+	if _, err := s.findTask(s.db, projectID, listID, taskID); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.db.Model(&schemas.Blob{}).Where("hash = ?", attachment.Hash).Count(&count).Error; err != nil {
+		return err
+	}
+	stored := false
+	if count == 0 {
+		if err := storeBlob(); err != nil {
+			return err
+		}
+		stored = true
+	}
+
+	err := s.createAttachment(projectID, listID, taskID, attachment, stored)
+	if errors.Is(err, errBlobRemoved) {
+		// The blob was unused and deleted after it was counted, so its
+		// content has to be stored again
+		if err := storeBlob(); err != nil {
+			return err
+		}
+		err = s.createAttachment(projectID, listID, taskID, attachment, true)
+	}
+	return err
+}
+
+// createAttachment records an attachment and, when stored is set, the blob
+// whose content was just written to the blob store.
+func (s *service) createAttachment(projectID string, listID string, taskID string, attachment *schemas.Attachment, stored bool) error {
+	return s.transaction(func(tx *gorm.DB) error {
+		task, err := s.findTask(tx, projectID, listID, taskID)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&schemas.Blob{}).Where("hash = ?", attachment.Hash).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if !stored {
+				return errBlobRemoved
+			}
+			if err := tx.Create(&schemas.Blob{Hash: attachment.Hash, Size: attachment.Size}).Error; err != nil {
+				return err
+			}
+		}
+
+		attachment.TaskID = task.ID
+		if err := tx.Omit("Task", "Uploader").Create(attachment).Error; err != nil {
+			return err
+		}
+		return s.recordAttachment(tx, projectID, attachment, schemas.ActionCreated, nil, attachment)
+	})
+}
+
+// DeleteAttachment removes an attachment from a task. Its content stays in
+// the blob store until DeleteUnusedBlobs runs.
+func (s *service) DeleteAttachment(projectID string, listID string, taskID string, attachmentID string) error {
+	return s.transaction(func(tx *gorm.DB) error {
+		attachment, err := s.findAttachment(tx, projectID, listID, taskID, attachmentID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(attachment).Error; err != nil {
+			return err
+		}
+		return s.recordAttachment(tx, projectID, attachment, schemas.ActionDeleted, attachment, nil)
+	})
+}
+
+// DeleteUnusedBlobs forgets the blobs no attachment refers to any more,
+// calling deleteBlob to remove each one from the blob store, and returns how
+// many were removed. A blob whose removal fails is kept for the next run.
+func (s *service) DeleteUnusedBlobs(deleteBlob func(hash string) error) (int64, error) {
+	var hashes []string
+	if err := s.db.Model(&schemas.Blob{}).
+		Where("NOT EXISTS (SELECT 1 FROM attachments WHERE attachments.hash = blobs.hash)").
+		Pluck("hash", &hashes).Error; err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, hash := range hashes {
+		// The blob is removed while the row is locked, so an upload of the
+		// same content waits and then stores it again
+		err := s.transaction(func(tx *gorm.DB) error {
+			result := tx.Where("hash = ? AND NOT EXISTS (SELECT 1 FROM attachments WHERE attachments.hash = blobs.hash)", hash).
+				Delete(&schemas.Blob{})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			if err := deleteBlob(hash); err != nil {
+				return err
+			}
+			deleted++
+			return nil
+		})
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// findAttachment loads an attachment, checking that it belongs to the task.
+func (s *service) findAttachment(tx *gorm.DB, projectID string, listID string, taskID string, attachmentID string) (*schemas.Attachment, error) {
+	task, err := s.findTask(tx, projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachment schemas.Attachment
+	if err := tx.Where("id = ? AND task_id = ?", attachmentID, task.ID).First(&attachment).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (s *service) recordAttachment(tx *gorm.DB, projectID string, attachment *schemas.Attachment, action string, before any, after any) error {
+	return s.record(tx, schemas.Activity{
+		ProjectID:  parseID(projectID),
+		TaskID:     &attachment.TaskID,
+		EntityType: schemas.EntityAttachment,
+		EntityID:   attachment.ID,
+		Action:     action,
+	}, before, after)
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// maxBulkTasks is how many tasks a bulk operation can change at once.
+const maxBulkTasks = 1000
+
+var (
+	ErrTooManyTasks = fmt.Errorf("a bulk operation can change at most %d tasks", maxBulkTasks)
+	ErrBulkFailed   = errors.New("the operation failed for some tasks, so no task was changed")
+	ErrEmptyTitle   = errors.New("the new title would be empty")
+)
+
+// BulkResult is the outcome of a bulk operation for one task. Task is the
+// task as changed, nil when it was deleted or the operation failed with Err.
+type BulkResult struct {
+	TaskID uint
+	Task   *schemas.Task
+	Err    error
+}
+
+// BulkUpdateTasks applies an operation to tasks of a project, or of one of
+// its lists when listID is set, in a single transaction. Each task succeeds
+// or fails on its own unless payload.Atomic is set; then one failure rolls
+// everything back and ErrBulkFailed is returned along with the results.
+func (s *service) BulkUpdateTasks(projectID string, listID string, payload types.BulkTaskPayload) ([]BulkResult, error) {
+	var pattern *regexp.Regexp
+	if payload.Operation == "rename" {
+		var err error
+		if pattern, err = regexp.Compile(payload.Pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []BulkResult
+	err := s.transaction(func(tx *gorm.DB) error {
+		ids, found, err := s.bulkTaskIDs(tx, projectID, listID, payload)
+		if err != nil {
+			return err
+		}
+
+		if payload.Operation == "move" {
+			var target schemas.List
+			if err := tx.Where("id = ? AND project_id = ?", *payload.ListID, projectID).First(&target).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrTargetListNotFound
+				}
+				return err
+			}
+		}
+
+		deleted := make(map[uint]bool)
+		failed := false
+		for _, id := range ids {
+			result := BulkResult{TaskID: id}
+			switch {
+			case !found[id]:
+				result.Err = gorm.ErrRecordNotFound
+			case deleted[id]:
+				// Already deleted along with a parent task
+			default:
+				var task schemas.Task
+				result.Err = s.savepoint(tx, func(tx *gorm.DB) error {
+					if err := tx.First(&task, id).Error; err != nil {
+						return err
+					}
+					return s.applyBulkOperation(tx, projectID, &task, payload, pattern, deleted)
+				})
+				if result.Err == nil && payload.Operation != "delete" {
+					result.Task = &task
+				}
+			}
+
+			failed = failed || result.Err != nil
+			results = append(results, result)
+		}
+
+		if failed && payload.Atomic {
+			return ErrBulkFailed
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrBulkFailed) {
+		return nil, err
+	}
+
+	return results, err
+}
+
+// bulkTaskIDs returns the IDs of the tasks a bulk operation applies to, in
+// the order given or in list order for a filter, and which of them are live
+// tasks in scope.
+func (s *service) bulkTaskIDs(tx *gorm.DB, projectID string, listID string, payload types.BulkTaskPayload) ([]uint, map[uint]bool, error) {
+	scope := tx.Model(&schemas.Task{}).
+		Joins("JOIN lists ON lists.id = tasks.list_id AND lists.deleted_at IS NULL").
+		Where("lists.project_id = ?", projectID)
+	if listID != "" {
+		var list schemas.List
+		if err := tx.Where("id = ? AND project_id = ?", listID, projectID).First(&list).Error; err != nil {
+			return nil, nil, err
+		}
+		scope = scope.Where("tasks.list_id = ?", list.ID)
+	}
+
+	var ids []uint
+	if payload.Filter == nil {
+		for _, id := range payload.TaskIDs {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		scope = scope.Where("tasks.id IN ?", ids)
+	} else {
+		scope = filterBulkTasks(scope, projectID, payload.Filter).
+			Order("lists.position, tasks.position").
+			Limit(maxBulkTasks + 1)
+	}
+
+	var matched []uint
+	if err := scope.Pluck("tasks.id", &matched).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(matched) > maxBulkTasks {
+		return nil, nil, ErrTooManyTasks
+	}
+	if payload.Filter != nil {
+		ids = matched
+	}
+
+	found := make(map[uint]bool, len(matched))
+	for _, id := range matched {
+		found[id] = true
+	}
+	return ids, found, nil
+}
+
+func filterBulkTasks(tx *gorm.DB, projectID string, filter *types.BulkTaskFilter) *gorm.DB {
+	if filter.Done != nil {
+		tx = tx.Where("tasks.done = ?", *filter.Done)
+	}
+	if len(filter.Priorities) > 0 {
+		tx = tx.Where("tasks.priority IN ?", filter.Priorities)
+	}
+	if filter.DueBefore != nil {
+		tx = tx.Where("tasks.due_at < ?", filter.DueBefore.UTC())
+	}
+	if filter.Title != "" {
+		tx = tx.Where(`tasks.title LIKE ? ESCAPE '\'`, likePattern(filter.Title))
+	}
+	if len(filter.Labels) > 0 {
+		tx = tx.Where("tasks.id IN (?)", tx.Session(&gorm.Session{NewDB: true}).
+			Table("task_labels").
+			Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Where("labels.project_id = ? AND labels.name IN ?", projectID, filter.Labels))
+	}
+	return tx
+}
+
+// applyBulkOperation applies the operation of a bulk payload to one task,
+// adding the IDs of deleted tasks to deleted.
+func (s *service) applyBulkOperation(tx *gorm.DB, projectID string, task *schemas.Task, payload types.BulkTaskPayload, pattern *regexp.Regexp, deleted map[uint]bool) error {
+	switch payload.Operation {
+	case "done", "undone":
+		return s.setTaskDone(tx, projectID, task, payload.Operation == "done", payload.Force)
+	case "rename":
+		title := pattern.ReplaceAllString(task.Title, payload.Replacement)
+		if title == task.Title {
+			return nil
+		}
+		if strings.TrimSpace(title) == "" {
+			return ErrEmptyTitle
+		}
+		before := *task
+		task.Title = title
+		return s.saveTask(tx, projectID, &before, task, schemas.ActionUpdated, false)
+	case "move":
+		return s.moveTask(tx, task, types.MoveTaskPayload{ListID: payload.ListID})
+	case "delete":
+		ids, err := s.deleteTask(tx, projectID, task)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			deleted[id] = true
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown operation %q", payload.Operation)
+}
@@ -0,0 +1,236 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotCommentAuthor = errors.New("only the author of a comment can change it")
+
+var (
+	// mentionPattern matches @username where the @ does not follow a word
+	// character, so e-mail addresses are not taken for mentions
+	mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.@])@([A-Za-z0-9]+)`)
+
+	// codePattern matches fenced code blocks and inline code spans, whose
+	// contents are not scanned for mentions
+	codePattern = regexp.MustCompile("(?s)```.*?(```|$)|`[^`\n]*`")
+)
+
+var commentSortColumns = map[string]sortColumn[schemas.Comment]{
+	"id": {"comments.id", func(c schemas.Comment) any { return c.ID }},
+}
+
+var mentionSortColumns = map[string]sortColumn[schemas.Mention]{
+	"id": {"mentions.id", func(m schemas.Mention) any { return m.ID }},
+}
+
+// GetComments returns the comments of a task, oldest first.
+func (s *service) GetComments(projectID string, listID string, taskID string, page types.PageQuery) ([]schemas.Comment, string, error) {
+	task, err := s.findTask(s.db, projectID, listID, taskID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tx := s.db.Model(&schemas.Comment{}).
+		Preload("Author").
+		Preload("Mentions.User").
+		Where("comments.task_id = ?", task.ID)
+
+	return paginate(tx, "comments", "id", commentSortColumns, page,
+		func(c schemas.Comment) uint { return c.ID })
+}
+
+// GetComment returns a comment of the task with its previous revisions.
+func (s *service) GetComment(projectID string, listID string, taskID string, commentID string) (*schemas.Comment, error) {
+	return s.findComment(s.db.Preload("Author").Preload("Mentions.User").
+		Preload("Revisions", func(db *gorm.DB) *gorm.DB { return db.Order("id") }),
+		projectID, listID, taskID, commentID)
+}
+
+func (s *service) CreateComment(projectID string, listID string, taskID string, authorID uint, payload types.CreateCommentPayload) (*schemas.Comment, error) {
+	var comment schemas.Comment
+	err := s.transaction(func(tx *gorm.DB) error {
+		task, err := s.findTask(tx, projectID, listID, taskID)
+		if err != nil {
+			return err
+		}
+
+		comment = schemas.Comment{
+			Body:     payload.Body,
+			TaskID:   task.ID,
+			AuthorID: authorID,
+		}
+		if err := tx.Omit("Author", "Task").Create(&comment).Error; err != nil {
+			return err
+		}
+		if err := s.syncMentions(tx, projectID, &comment); err != nil {
+			return err
+		}
+		return s.recordComment(tx, projectID, &comment, schemas.ActionCreated, nil, &comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetComment(projectID, listID, taskID, strconv.FormatUint(uint64(comment.ID), 10))
+}
+
+// UpdateComment replaces the body of a comment written by the user, keeping
+// the previous body as a revision.
+func (s *service) UpdateComment(projectID string, listID string, taskID string, commentID string, userID uint, payload types.UpdateCommentPayload) (*schemas.Comment, error) {
+	err := s.transaction(func(tx *gorm.DB) error {
+		comment, err := s.findComment(tx, projectID, listID, taskID, commentID)
+		if err != nil {
+			return err
+		}
+		if comment.AuthorID != userID {
+			return ErrNotCommentAuthor
+		}
+		if comment.Body == payload.Body {
+			return nil
+		}
+
+		revision := schemas.CommentRevision{CommentID: comment.ID, Body: comment.Body}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		before := *comment
+		now := time.Now().UTC()
+		comment.Body = payload.Body
+		comment.EditedAt = &now
+		if err := tx.Model(comment).Updates(map[string]any{"body": comment.Body, "edited_at": comment.EditedAt}).Error; err != nil {
+			return err
+		}
+		if err := s.syncMentions(tx, projectID, comment); err != nil {
+			return err
+		}
+		return s.recordComment(tx, projectID, comment, schemas.ActionUpdated, &before, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetComment(projectID, listID, taskID, commentID)
+}
+
+// DeleteComment soft-deletes a comment. Authors can delete their own
+// comments and project owners anyone's.
+func (s *service) DeleteComment(projectID string, listID string, taskID string, commentID string, userID uint) error {
+	return s.transaction(func(tx *gorm.DB) error {
+		comment, err := s.findComment(tx, projectID, listID, taskID, commentID)
+		if err != nil {
+			return err
+		}
+
+		if comment.AuthorID != userID {
+			role, err := s.GetProjectRole(projectID, userID)
+			if err != nil {
+				return err
+			}
+			if role != schemas.RoleOwner {
+				return ErrNotCommentAuthor
+			}
+		}
+
+		if err := tx.Delete(comment).Error; err != nil {
+			return err
+		}
+		return s.recordComment(tx, projectID, comment, schemas.ActionDeleted, comment, nil)
+	})
+}
+
+// GetMentions returns the mentions of the user in comments that still
+// exist, newest first, with the comments and their authors loaded. Mentions
+// in projects the user is no longer a member of are left out.
+func (s *service) GetMentions(userID uint, page types.PageQuery) ([]schemas.Mention, string, error) {
+	tx := s.db.Model(&schemas.Mention{}).
+		Preload("Comment.Author").
+		Joins("JOIN comments ON comments.id = mentions.comment_id AND comments.deleted_at IS NULL").
+		Joins("JOIN tasks ON tasks.id = mentions.task_id AND tasks.deleted_at IS NULL").
+		Joins("JOIN lists ON lists.id = tasks.list_id AND lists.deleted_at IS NULL").
+		Joins("JOIN projects ON projects.id = lists.project_id AND projects.deleted_at IS NULL").
+		Joins("JOIN project_members ON project_members.project_id = projects.id AND project_members.user_id = mentions.user_id AND project_members.deleted_at IS NULL").
+		Where("mentions.user_id = ?", userID)
+
+	return paginate(tx, "mentions", "-id", mentionSortColumns, page,
+		func(m schemas.Mention) uint { return m.ID })
+}
+
+// findComment loads a live comment, checking that it belongs to the task.
+func (s *service) findComment(tx *gorm.DB, projectID string, listID string, taskID string, commentID string) (*schemas.Comment, error) {
+	task, err := s.findTask(tx.Session(&gorm.Session{NewDB: true}), projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var comment schemas.Comment
+	if err := tx.Where("id = ? AND task_id = ?", commentID, task.ID).First(&comment).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// syncMentions records the project members the comment mentions. Existing
+// mentions are kept so users are only notified once per comment.
+func (s *service) syncMentions(tx *gorm.DB, projectID string, comment *schemas.Comment) error {
+	var users []uint
+	if names := parseMentions(comment.Body); len(names) > 0 {
+		if err := tx.Table("users").
+			Joins("JOIN project_members ON project_members.user_id = users.id AND project_members.deleted_at IS NULL").
+			Where("project_members.project_id = ? AND users.username IN ? AND users.deleted_at IS NULL", projectID, names).
+			Pluck("users.id", &users).Error; err != nil {
+			return err
+		}
+	}
+
+	removed := tx.Where("comment_id = ?", comment.ID)
+	if len(users) > 0 {
+		removed = removed.Where("user_id NOT IN ?", users)
+	}
+	if err := removed.Delete(&schemas.Mention{}).Error; err != nil {
+		return err
+	}
+
+	for _, userID := range users {
+		mention := schemas.Mention{CommentID: comment.ID, UserID: userID, TaskID: comment.TaskID}
+		if err := tx.Omit("Comment", "User").
+			Where(schemas.Mention{CommentID: comment.ID, UserID: userID}).
+			FirstOrCreate(&mention).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) recordComment(tx *gorm.DB, projectID string, comment *schemas.Comment, action string, before any, after any) error {
+	return s.record(tx, schemas.Activity{
+		ProjectID:  parseID(projectID),
+		TaskID:     &comment.TaskID,
+		EntityType: schemas.EntityComment,
+		EntityID:   comment.ID,
+		Action:     action,
+	}, before, after)
+}
+
+// parseMentions returns the distinct usernames mentioned in a Markdown body,
+// ignoring code.
+func parseMentions(body string) []string {
+	body = codePattern.ReplaceAllString(body, " ")
+
+	var names []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		if !slices.Contains(names, match[1]) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
@@ -1,10 +1,13 @@
 package database
 
 import (
+	"go-tasker/internal/events"
+	"go-tasker/internal/workflow"
 	"go-tasker/schemas"
 	"go-tasker/types"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"gorm.io/driver/sqlite"
@@ -15,28 +18,134 @@ import (
 )
 
 type Service interface {
-	GetLists(projectID string) ([]schemas.List, error)
+	As(userID uint) Service
+	IfMatch(version uint) Service
+	Events() *events.Hub
+	Workflow() *workflow.Workflow
+	GetProjectActivities(projectID string, query types.ActivityQuery) ([]schemas.Activity, string, error)
+	GetTaskActivities(projectID string, listID string, taskID string, query types.ActivityQuery) ([]schemas.Activity, string, error)
+
+	GetLists(projectID string, query types.ListQuery) ([]schemas.List, string, error)
 	GetList(projectID string, listID string) (*schemas.List, error)
 	CreateList(projectID string, payload types.CreateListPayload) (*schemas.List, error)
 	UpdateList(projectID string, listID string, payload types.UpdateListPayload) (*schemas.List, error)
 	DeleteList(projectID string, listID string) error
+	MoveList(projectID string, listID string, siblingID uint, after bool) (*schemas.List, error)
 
-	GetTasks(projectID string, listID string) ([]schemas.Task, error)
+	GetTasks(projectID string, listID string, query types.TaskQuery) ([]schemas.Task, string, error)
+	GetTask(projectID string, listID string, taskID string) (*schemas.Task, error)
 	CreateTask(projectID string, listID string, payload types.CreateTaskPayload) (*schemas.Task, error)
 	UpdateTask(projectID string, listID string, taskID string, payload types.UpdateTaskPayload) (*schemas.Task, error)
 	UpdateTaskDone(projectID string, listID string, taskID string, payload types.UpdateTaskDonePayload) (*schemas.Task, error)
 	DeleteTask(projectID string, listID string, taskID string) error
+	MoveTask(projectID string, listID string, taskID string, payload types.MoveTaskPayload) (*schemas.Task, error)
+	CopyTask(projectID string, listID string, taskID string, payload types.CopyTaskPayload) (*schemas.Task, error)
+	BulkUpdateTasks(projectID string, listID string, payload types.BulkTaskPayload) ([]BulkResult, error)
+	GetTaskTree(projectID string, listID string, taskID string) (*TaskTree, error)
+	SetTaskParent(projectID string, listID string, taskID string, parentID *uint) (*schemas.Task, error)
+	GetTaskDependencies(projectID string, listID string, taskID string) ([]schemas.Task, error)
+	AddTaskDependency(projectID string, listID string, taskID string, blockerID uint) ([]schemas.Task, error)
+	RemoveTaskDependency(projectID string, listID string, taskID string, blockerID string) ([]schemas.Task, error)
+	GetTasksInDependencyOrder(projectID string) ([]schemas.Task, error)
+	GetTaskOccurrences(projectID string, listID string, taskID string, count int) ([]time.Time, error)
+	GetOverdueTasks(projectID string, now time.Time) ([]schemas.Task, error)
+	GetTasksDueBetween(projectID string, from time.Time, to time.Time) ([]schemas.Task, error)
+	AssignTask(projectID string, listID string, taskID string, userID uint) (*schemas.Task, error)
+	UnassignTask(projectID string, listID string, taskID string, userID string) (*schemas.Task, error)
+	GetAssignedTasks(userID uint) ([]schemas.Task, error)
+
+	GetLabels(projectID string) ([]schemas.Label, error)
+	CreateLabel(projectID string, payload types.CreateLabelPayload) (*schemas.Label, error)
+	UpdateLabel(projectID string, labelID string, payload types.UpdateLabelPayload) (*schemas.Label, error)
+	DeleteLabel(projectID string, labelID string) error
+	AttachLabel(projectID string, listID string, taskID string, labelID uint) (*schemas.Task, error)
+	DetachLabel(projectID string, listID string, taskID string, labelID string) (*schemas.Task, error)
 
-	GetProjects() ([]schemas.Project, error)
-	CreateProject(payload types.CreateProjectPayload) (*schemas.Project, error)
+	Search(userID uint, query types.SearchQuery) ([]SearchResult, error)
+
+	GetProjects(userID uint, query types.ProjectQuery) ([]schemas.Project, string, error)
+	GetProject(projectID string) (*schemas.Project, error)
+	CreateProject(userID uint, payload types.CreateProjectPayload) (*schemas.Project, error)
 	UpdateProject(projectID string, payload types.UpdateProjectPayload) (*schemas.Project, error)
+	TransitionProject(projectID string, payload types.ProjectTransitionPayload) (*schemas.Project, error)
+	GetProjectStatusHistory(projectID string, page types.PageQuery) ([]schemas.ProjectStatusChange, string, error)
 	DeleteProject(projectID string) error
+
+	GetProjectRole(projectID string, userID uint) (string, error)
+	GetProjectMembers(projectID string) ([]schemas.ProjectMember, error)
+	AddProjectMember(projectID string, payload types.AddProjectMemberPayload) (*schemas.ProjectMember, error)
+	UpdateProjectMember(projectID string, userID string, payload types.UpdateProjectMemberPayload) (*schemas.ProjectMember, error)
+	RemoveProjectMember(projectID string, userID string) error
+
+	CreateUser(payload types.RegisterPayload) (*schemas.User, error)
+	GetUser(userID uint) (*schemas.User, error)
+	GetUserByUsername(username string) (*schemas.User, error)
+	GetUserByAPIKey(key string) (*schemas.User, error)
+	GetAPIKeys(userID uint) ([]schemas.APIKey, error)
+	CreateAPIKey(userID uint, payload types.CreateAPIKeyPayload) (*schemas.APIKey, string, error)
+	DeleteAPIKey(userID uint, apiKeyID string) error
+
+	GetComments(projectID string, listID string, taskID string, page types.PageQuery) ([]schemas.Comment, string, error)
+	GetComment(projectID string, listID string, taskID string, commentID string) (*schemas.Comment, error)
+	CreateComment(projectID string, listID string, taskID string, authorID uint, payload types.CreateCommentPayload) (*schemas.Comment, error)
+	UpdateComment(projectID string, listID string, taskID string, commentID string, userID uint, payload types.UpdateCommentPayload) (*schemas.Comment, error)
+	DeleteComment(projectID string, listID string, taskID string, commentID string, userID uint) error
+	GetMentions(userID uint, page types.PageQuery) ([]schemas.Mention, string, error)
+	GetAttachments(projectID string, listID string, taskID string) ([]schemas.Attachment, error)
+	GetAttachment(projectID string, listID string, taskID string, attachmentID string) (*schemas.Attachment, error)
+	CreateAttachment(projectID string, listID string, taskID string, attachment *schemas.Attachment, storeBlob func() error) error
+	DeleteAttachment(projectID string, listID string, taskID string, attachmentID string) error
+	DeleteUnusedBlobs(deleteBlob func(hash string) error) (int64, error)
+	GetWebhooks(projectID string) ([]schemas.Webhook, error)
+	GetWebhook(projectID string, webhookID string) (*schemas.Webhook, error)
+	CreateWebhook(projectID string, payload types.CreateWebhookPayload) (*schemas.Webhook, error)
+	UpdateWebhook(projectID string, webhookID string, payload types.UpdateWebhookPayload) (*schemas.Webhook, error)
+	DeleteWebhook(projectID string, webhookID string) error
+	GetWebhookDeliveries(projectID string, webhookID string, page types.PageQuery) ([]schemas.WebhookDelivery, string, error)
+	RedeliverWebhookDelivery(projectID string, webhookID string, deliveryID string) (*schemas.WebhookDelivery, error)
+	ClaimWebhookDeliveries(limit int, lease time.Duration) ([]schemas.WebhookDelivery, error)
+	SaveWebhookDeliveryAttempt(delivery *schemas.WebhookDelivery) error
+
+	ClaimIdempotentRequest(request *schemas.IdempotentRequest, abandonedBefore time.Time) (*schemas.IdempotentRequest, error)
+	SaveIdempotentResponse(request *schemas.IdempotentRequest) error
+	ReleaseIdempotentRequest(request *schemas.IdempotentRequest) error
+	DeleteExpiredIdempotentRequests(now time.Time) (int64, error)
+
+	GetTrash(projectID string) ([]schemas.List, []schemas.Task, error)
+	GetDeletedProjects(userID uint) ([]schemas.Project, error)
+	RestoreProject(projectID string, userID uint) (*schemas.Project, error)
+	RestoreList(projectID string, listID string) (*schemas.List, error)
+	RestoreTask(projectID string, taskID string) (*schemas.Task, error)
+	PurgeProject(projectID string, userID uint) error
+	PurgeList(projectID string, listID string) error
+	PurgeTask(projectID string, taskID string) error
+	EmptyTrash(projectID string) error
+	PurgeDeleted(before time.Time) (int64, error)
 }
 
 type service struct {
 	db *gorm.DB
+
+	// fts reports whether SQLite supports FTS5 for full-text search
+	fts bool
+
+	// actorID is the user recorded in the activity log, set by As
+	actorID *uint
+
+	// hub receives the activities of committed transactions
+	hub *events.Hub
+
+	// version is the version updates and deletes expect, set by IfMatch
+	version *uint
+
+	// workflow holds the project statuses and the transitions between them
+	workflow *workflow.Workflow
 }
 
+// eventBufferSize is how many recent events are kept for clients that
+// reconnect to a project's event stream.
+const eventBufferSize = 1000
+
 var (
 	dbUrl      = os.Getenv("DB_URL")
 	dbInstance *service
@@ -68,8 +177,11 @@ func New() Service {
 	)
 
 	// Create DB and connect
-	db, err := gorm.Open(sqlite.Open(dbUrl), &gorm.Config{
-		Logger: newLogger,
+	// Timestamps are stored in UTC, as SQLite compares them as text and
+	// filters are normalised with toUTC
+	db, err := gorm.Open(sqlite.Open(withImmediateTransactions(dbUrl)), &gorm.Config{
+		Logger:  newLogger,
+		NowFunc: func() time.Time { return time.Now().UTC() },
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -84,12 +196,81 @@ func New() Service {
 		log.Fatal(err)
 	}
 
-	if err := db.AutoMigrate(&schemas.Project{}); err != nil {
+	if err := db.AutoMigrate(&schemas.Project{}, &schemas.ProjectStatusChange{}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&schemas.User{}, &schemas.APIKey{}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&schemas.ProjectMember{}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&schemas.Label{}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&schemas.Comment{}, &schemas.CommentRevision{}, &schemas.Mention{}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&schemas.Attachment{}, &schemas.Blob{}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&schemas.Webhook{}, &schemas.WebhookDelivery{}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&schemas.Activity{}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&schemas.IdempotentRequest{}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := backfillPositions(db); err != nil {
+		log.Fatal(err)
+	}
+
+	fts, err := migrateSearch(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !fts && os.Getenv("APP_ENV") != "test" {
+		log.Println("SQLite was built without FTS5 (build tag sqlite_fts5), search falls back to substring matching")
+	}
+
+	projectWorkflow, err := workflow.Load(os.Getenv("PROJECT_WORKFLOW"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := migrateLegacyStatuses(db, projectWorkflow); err != nil {
 		log.Fatal(err)
 	}
 
 	dbInstance = &service{
-		db: db,
+		db:       db,
+		fts:      fts,
+		hub:      events.NewHub(eventBufferSize),
+		workflow: projectWorkflow,
 	}
 	return dbInstance
 }
+
+// withImmediateTransactions makes transactions take the write lock when they
+// begin. A transaction that reads before it writes would otherwise fail with
+// "database is locked" rather than wait when another connection is writing,
+// such as the webhook worker saving its deliveries.
+func withImmediateTransactions(dsn string) string {
+	if strings.Contains(dsn, "_txlock=") {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&_txlock=immediate"
+	}
+	return dsn + "?_txlock=immediate"
+}
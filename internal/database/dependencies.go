@@ -0,0 +1,234 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/schemas"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTaskBlocked      = errors.New("task is blocked by open tasks")
+	ErrDependencyCycle  = errors.New("dependency would create a cycle")
+	ErrDependencyExists = errors.New("task already depends on this task")
+)
+
+// GetTaskDependencies returns the tasks blocking the given task.
+func (s *service) GetTaskDependencies(projectID string, listID string, taskID string) ([]schemas.Task, error) {
+	task, err := s.findTask(s.db, projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockers []schemas.Task
+	if err := s.db.Model(task).Order("tasks.id").Association("BlockedBy").Find(&blockers); err != nil {
+		return nil, err
+	}
+	return blockers, nil
+}
+
+// AddTaskDependency records that the task cannot be completed before
+// blockerID. The blocker may live in any list of the same project.
+func (s *service) AddTaskDependency(projectID string, listID string, taskID string, blockerID uint) ([]schemas.Task, error) {
+	err := s.transaction(func(tx *gorm.DB) error {
+		task, err := s.findTask(tx, projectID, listID, taskID)
+		if err != nil {
+			return err
+		}
+
+		var blocker schemas.Task
+		if err := tx.Joins("JOIN lists ON lists.id = tasks.list_id AND lists.deleted_at IS NULL").
+			Where("tasks.id = ? AND lists.project_id = ?", blockerID, projectID).
+			First(&blocker).Error; err != nil {
+			return err
+		}
+
+		// Adding task -> blocker closes a cycle when the blocker already
+		// depends on the task, directly or transitively.
+		upstream, err := s.blockerClosure(tx, blocker.ID)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(upstream, task.ID) {
+			return ErrDependencyCycle
+		}
+
+		var count int64
+		if err := tx.Table("task_dependencies").
+			Where("task_id = ? AND blocker_id = ?", task.ID, blocker.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDependencyExists
+		}
+
+		if err := tx.Exec("INSERT INTO task_dependencies (task_id, blocker_id) VALUES (?, ?)", task.ID, blocker.ID).Error; err != nil {
+			return err
+		}
+		return s.recordTask(tx, projectID, task, schemas.ActionBlocked, nil, map[string]any{"blocker_id": blocker.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTaskDependencies(projectID, listID, taskID)
+}
+
+func (s *service) RemoveTaskDependency(projectID string, listID string, taskID string, blockerID string) ([]schemas.Task, error) {
+	task, err := s.findTask(s.db, projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("DELETE FROM task_dependencies WHERE task_id = ? AND blocker_id = ?", task.ID, blockerID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return s.recordTask(tx, projectID, task, schemas.ActionUnblocked, map[string]any{"blocker_id": parseID(blockerID)}, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTaskDependencies(projectID, listID, taskID)
+}
+
+// GetTasksInDependencyOrder returns every task of the project ordered so that
+// each task comes after the tasks blocking it. Ties are broken by ID, which
+// keeps the order stable between calls.
+func (s *service) GetTasksInDependencyOrder(projectID string) ([]schemas.Task, error) {
+	var project schemas.Project
+	if err := s.db.First(&project, projectID).Error; err != nil {
+		return nil, err
+	}
+
+	var tasks []schemas.Task
+	if err := s.db.Joins("JOIN lists ON lists.id = tasks.list_id AND lists.deleted_at IS NULL").
+		Where("lists.project_id = ?", projectID).
+		Preload("BlockedBy").
+		Order("tasks.id").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	// Kahn's algorithm, always taking the lowest ready ID next
+	byID := make(map[uint]int, len(tasks))
+	for i, task := range tasks {
+		byID[task.ID] = i
+	}
+	pending := make([]int, len(tasks))
+	dependents := make([][]int, len(tasks))
+	for i, task := range tasks {
+		for _, blocker := range task.BlockedBy {
+			if j, ok := byID[blocker.ID]; ok {
+				pending[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+
+	var ready []int
+	for i := range tasks {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	sorted := make([]schemas.Task, 0, len(tasks))
+	for len(ready) > 0 {
+		slices.Sort(ready)
+		next := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, tasks[next])
+
+		for _, dependent := range dependents[next] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(sorted) != len(tasks) {
+		return nil, ErrDependencyCycle
+	}
+	return sorted, nil
+}
+
+// blockerClosure returns the task and every live task it transitively
+// depends on.
+func (s *service) blockerClosure(tx *gorm.DB, taskID uint) ([]uint, error) {
+	var ids []uint
+	if err := tx.Raw(`
+		WITH RECURSIVE upstream(id) AS (
+			SELECT ?
+			UNION
+			SELECT task_dependencies.blocker_id FROM task_dependencies
+			JOIN upstream ON task_dependencies.task_id = upstream.id
+			JOIN tasks ON tasks.id = task_dependencies.blocker_id
+			WHERE tasks.deleted_at IS NULL
+		)
+		SELECT id FROM upstream`, taskID).
+		Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// dropCyclicDependencies removes the dependencies of restored tasks that
+// now close a cycle, which happens when a dependency was added while a task
+// on its path was in the trash. Each removal is recorded on the blocked
+// task.
+func (s *service) dropCyclicDependencies(tx *gorm.DB, projectID string, taskIDs []uint) error {
+	var dependencies []struct {
+		TaskID    uint
+		BlockerID uint
+	}
+	if err := tx.Table("task_dependencies").
+		Select("task_id, blocker_id").
+		Where("task_id IN ? OR blocker_id IN ?", taskIDs, taskIDs).
+		Order("rowid").
+		Scan(&dependencies).Error; err != nil {
+		return err
+	}
+
+	for _, dependency := range dependencies {
+		upstream, err := s.blockerClosure(tx, dependency.BlockerID)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(upstream, dependency.TaskID) {
+			continue
+		}
+
+		var task schemas.Task
+		if err := tx.First(&task, dependency.TaskID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM task_dependencies WHERE task_id = ? AND blocker_id = ?",
+			dependency.TaskID, dependency.BlockerID).Error; err != nil {
+			return err
+		}
+		if err := s.recordTask(tx, projectID, &task, schemas.ActionUnblocked,
+			map[string]any{"blocker_id": dependency.BlockerID}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openBlockerCount counts the live, unfinished tasks blocking the task.
+func (s *service) openBlockerCount(tx *gorm.DB, taskID uint) (int64, error) {
+	var count int64
+	err := tx.Table("task_dependencies").
+		Joins("JOIN tasks ON tasks.id = task_dependencies.blocker_id").
+		Where("task_dependencies.task_id = ? AND tasks.done = ? AND tasks.deleted_at IS NULL", taskID, false).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"go-tasker/schemas"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClaimIdempotentRequest stores request unless its user has already used the
+// key, in which case the earlier request is returned instead. Expired keys
+// are free to use again, as are keys of requests still unanswered since
+// abandonedBefore, whose handling was interrupted.
+func (s *service) ClaimIdempotentRequest(request *schemas.IdempotentRequest, abandonedBefore time.Time) (*schemas.IdempotentRequest, error) {
+	var existing *schemas.IdempotentRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(&schemas.IdempotentRequest{UserID: request.UserID, Key: request.Key}).
+			Where("expires_at <= ? OR (status = 0 AND created_at <= ?)", time.Now().UTC(), abandonedBefore.UTC()).
+			Delete(&schemas.IdempotentRequest{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(request)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		existing = &schemas.IdempotentRequest{}
+		return tx.Where(&schemas.IdempotentRequest{UserID: request.UserID, Key: request.Key}).First(existing).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// SaveIdempotentResponse stores the response to a claimed request.
+func (s *service) SaveIdempotentResponse(request *schemas.IdempotentRequest) error {
+	return s.db.Model(request).Select("status", "header", "body").Updates(request).Error
+}
+
+// ReleaseIdempotentRequest frees the key of a claimed request so it can be
+// retried.
+func (s *service) ReleaseIdempotentRequest(request *schemas.IdempotentRequest) error {
+	return s.db.Delete(request).Error
+}
+
+// DeleteExpiredIdempotentRequests deletes the requests whose keys expired
+// before now.
+func (s *service) DeleteExpiredIdempotentRequests(now time.Time) (int64, error) {
+	result := s.db.Where("expires_at <= ?", now).Delete(&schemas.IdempotentRequest{})
+	return result.RowsAffected, result.Error
+}
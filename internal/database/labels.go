@@ -0,0 +1,174 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+var ErrLabelExists = errors.New("a label with this name already exists in the project")
+
+func (s *service) GetLabels(projectID string) ([]schemas.Label, error) {
+	var labels []schemas.Label
+	if err := s.db.Where("project_id = ?", projectID).Order("name").Find(&labels).Error; err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (s *service) CreateLabel(projectID string, payload types.CreateLabelPayload) (*schemas.Label, error) {
+	projectIDUint, err := strconv.ParseUint(projectID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureLabelNameFree(projectID, payload.Name, 0); err != nil {
+		return nil, err
+	}
+
+	label := schemas.Label{
+		Name:      payload.Name,
+		Color:     labelColorOrDefault(payload.Color),
+		ProjectID: uint(projectIDUint),
+	}
+
+	err = s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&label).Error; err != nil {
+			return err
+		}
+		return s.recordLabel(tx, &label, schemas.ActionCreated, nil, &label)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &label, nil
+}
+
+func (s *service) UpdateLabel(projectID string, labelID string, payload types.UpdateLabelPayload) (*schemas.Label, error) {
+	var label schemas.Label
+	if err := s.db.Where("id = ? AND project_id = ?", labelID, projectID).First(&label).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureLabelNameFree(projectID, payload.Name, label.ID); err != nil {
+		return nil, err
+	}
+
+	before := label
+	label.Name = payload.Name
+	label.Color = labelColorOrDefault(payload.Color)
+
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&label).Error; err != nil {
+			return err
+		}
+		return s.recordLabel(tx, &label, schemas.ActionUpdated, &before, &label)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &label, nil
+}
+
+// DeleteLabel removes the label for good and detaches it from every task, so
+// its name can be reused.
+func (s *service) DeleteLabel(projectID string, labelID string) error {
+	var label schemas.Label
+	if err := s.db.Where("id = ? AND project_id = ?", labelID, projectID).First(&label).Error; err != nil {
+		return err
+	}
+
+	return s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM task_labels WHERE label_id = ?", label.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&label).Error; err != nil {
+			return err
+		}
+		return s.recordLabel(tx, &label, schemas.ActionDeleted, &label, nil)
+	})
+}
+
+// AttachLabel adds a label of the task's project to the task.
+func (s *service) AttachLabel(projectID string, listID string, taskID string, labelID uint) (*schemas.Task, error) {
+	task, err := s.findTask(s.db, projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var label schemas.Label
+	if err := s.db.Where("id = ? AND project_id = ?", labelID, projectID).First(&label).Error; err != nil {
+		return nil, err
+	}
+
+	err = s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(task).Association("Labels").Append(&label); err != nil {
+			return err
+		}
+		return s.recordTask(tx, projectID, task, schemas.ActionLabeled, nil, map[string]any{"label_id": label.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findTask(s.db.Preload("Labels"), projectID, listID, taskID)
+}
+
+func (s *service) DetachLabel(projectID string, listID string, taskID string, labelID string) (*schemas.Task, error) {
+	task, err := s.findTask(s.db, projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("DELETE FROM task_labels WHERE task_id = ? AND label_id = ?", task.ID, labelID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return s.recordTask(tx, projectID, task, schemas.ActionUnlabeled, map[string]any{"label_id": parseID(labelID)}, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findTask(s.db.Preload("Labels"), projectID, listID, taskID)
+}
+
+func (s *service) recordLabel(tx *gorm.DB, label *schemas.Label, action string, before any, after any) error {
+	return s.record(tx, schemas.Activity{
+		ProjectID:  label.ProjectID,
+		EntityType: schemas.EntityLabel,
+		EntityID:   label.ID,
+		Action:     action,
+	}, before, after)
+}
+
+// ensureLabelNameFree returns ErrLabelExists when another label of the
+// project, other than exceptID, already uses name.
+func (s *service) ensureLabelNameFree(projectID string, name string, exceptID uint) error {
+	var count int64
+	if err := s.db.Model(&schemas.Label{}).
+		Where("project_id = ? AND name = ? AND id <> ?", projectID, name, exceptID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrLabelExists
+	}
+	return nil
+}
+
+func labelColorOrDefault(color string) string {
+	if color == "" {
+		return schemas.DefaultLabelColor
+	}
+	return color
+}
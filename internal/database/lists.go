@@ -4,14 +4,36 @@ import (
 	"go-tasker/schemas"
 	"go-tasker/types"
 	"strconv"
+	"time"
+
+	"gorm.io/gorm"
 )
 
-func (s *service) GetLists(projectID string) ([]schemas.List, error) {
-	var lists []schemas.List
-	if err := s.db.Where("project_id = ?", projectID).Find(&lists).Error; err != nil {
-		return nil, err
+var listSortColumns = map[string]sortColumn[schemas.List]{
+	"position":   {"lists.position", func(l schemas.List) any { return l.Position }},
+	"id":         {"lists.id", func(l schemas.List) any { return l.ID }},
+	"title":      {"lists.title", func(l schemas.List) any { return l.Title }},
+	"created_at": {"lists.created_at", func(l schemas.List) any { return l.CreatedAt }},
+	"updated_at": {"lists.updated_at", func(l schemas.List) any { return l.UpdatedAt }},
+}
+
+func (s *service) GetLists(projectID string, query types.ListQuery) ([]schemas.List, string, error) {
+	tx := s.db.Model(&schemas.List{}).Where("lists.project_id = ?", projectID)
+
+	if query.CreatedAfter != nil {
+		tx = tx.Where("lists.created_at > ?", toUTC(query.CreatedAfter))
+	}
+	if query.CreatedBefore != nil {
+		tx = tx.Where("lists.created_at < ?", toUTC(query.CreatedBefore))
+	}
+
+	sort := query.Sort
+	if sort == "" {
+		sort = "position"
 	}
-	return lists, nil
+
+	return paginate(tx, "lists", sort, listSortColumns, query.PageQuery,
+		func(l schemas.List) uint { return l.ID })
 }
 
 func (s *service) GetList(projectID string, listID string) (*schemas.List, error) {
@@ -30,10 +52,23 @@ func (s *service) CreateList(projectID string, payload types.CreateListPayload)
 
 	list := schemas.List{
 		Title:     payload.Title,
+		Version:   1,
 		ProjectID: uint(projectIDUint),
 	}
 
-	if err := s.db.Create(&list).Error; err != nil {
+	err = s.transaction(func(tx *gorm.DB) error {
+		// The position is read in the transaction so that concurrent creates
+		// in the same project do not take the same one
+		var err error
+		if list.Position, err = nextPosition(tx, "lists", "project_id", list.ProjectID); err != nil {
+			return err
+		}
+		if err := tx.Create(&list).Error; err != nil {
+			return err
+		}
+		return s.recordList(tx, &list, schemas.ActionCreated, nil, &list)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -46,9 +81,20 @@ func (s *service) UpdateList(projectID string, listID string, payload types.Upda
 		return nil, err
 	}
 
+	before := list
 	list.Title = payload.Title
 
-	if err := s.db.Save(&list).Error; err != nil {
+	err := s.transaction(func(tx *gorm.DB) error {
+		var err error
+		if list.Version, err = s.bumpVersion(tx, "lists", list.ID); err != nil {
+			return err
+		}
+		if err := tx.Save(&list).Error; err != nil {
+			return err
+		}
+		return s.recordList(tx, &list, schemas.ActionUpdated, &before, &list)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -61,9 +107,17 @@ func (s *service) DeleteList(projectID string, listID string) error {
 		return err
 	}
 
-	if err := s.db.Delete(&list).Error; err != nil {
-		return err
-	}
-
-	return nil
+	now := time.Now().UTC()
+	return s.transaction(func(tx *gorm.DB) error {
+		if _, err := s.bumpVersion(tx, "lists", list.ID); err != nil {
+			return err
+		}
+		if err := softDeleteAt(tx, "tasks", now, "list_id = ?", list.ID); err != nil {
+			return err
+		}
+		if err := softDeleteAt(tx, "lists", now, "id = ?", list.ID); err != nil {
+			return err
+		}
+		return s.recordList(tx, &list, schemas.ActionDeleted, &list, nil)
+	})
 }
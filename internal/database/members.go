@@ -0,0 +1,161 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/schemas"
+	"go-tasker/types"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlreadyMember = errors.New("user is already a member of this project")
+	ErrLastOwner     = errors.New("a project must keep at least one owner")
+)
+
+// GetProjectRole returns the user's role in the project, or
+// gorm.ErrRecordNotFound when the user is not a member.
+func (s *service) GetProjectRole(projectID string, userID uint) (string, error) {
+	var member schemas.ProjectMember
+	if err := s.db.Joins("JOIN projects ON projects.id = project_members.project_id AND projects.deleted_at IS NULL").
+		Where("project_members.project_id = ? AND project_members.user_id = ?", projectID, userID).
+		First(&member).Error; err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+func (s *service) GetProjectMembers(projectID string) ([]schemas.ProjectMember, error) {
+	var members []schemas.ProjectMember
+	if err := s.db.Preload("User").Where("project_id = ?", projectID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (s *service) AddProjectMember(projectID string, payload types.AddProjectMemberPayload) (*schemas.ProjectMember, error) {
+	var project schemas.Project
+	if err := s.db.First(&project, projectID).Error; err != nil {
+		return nil, err
+	}
+
+	var user schemas.User
+	if err := s.db.Where("username = ?", payload.Username).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&schemas.ProjectMember{}).
+		Where("project_id = ? AND user_id = ?", project.ID, user.ID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrAlreadyMember
+	}
+
+	member := schemas.ProjectMember{
+		ProjectID: project.ID,
+		UserID:    user.ID,
+		User:      user,
+		Role:      payload.Role,
+	}
+
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Create(&member).Error; err != nil {
+			return err
+		}
+		return s.recordMember(tx, &member, schemas.ActionCreated, nil, &member)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (s *service) UpdateProjectMember(projectID string, userID string, payload types.UpdateProjectMemberPayload) (*schemas.ProjectMember, error) {
+	var member schemas.ProjectMember
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("User").Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error; err != nil {
+			return err
+		}
+
+		if member.Role == schemas.RoleOwner && payload.Role != schemas.RoleOwner {
+			if err := ensureAnotherOwner(tx, projectID, member.ID); err != nil {
+				return err
+			}
+		}
+
+		before := member
+		member.Role = payload.Role
+		if err := tx.Omit("User").Save(&member).Error; err != nil {
+			return err
+		}
+		return s.recordMember(tx, &member, schemas.ActionUpdated, &before, &member)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (s *service) RemoveProjectMember(projectID string, userID string) error {
+	var member schemas.ProjectMember
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error; err != nil {
+			return err
+		}
+
+		if member.Role == schemas.RoleOwner {
+			if err := ensureAnotherOwner(tx, projectID, member.ID); err != nil {
+				return err
+			}
+		}
+
+		// Former members keep no assignments in the project
+		if err := tx.Exec(`DELETE FROM task_assignees WHERE user_id = ? AND task_id IN (
+			SELECT tasks.id FROM tasks JOIN lists ON lists.id = tasks.list_id WHERE lists.project_id = ?)`,
+			member.UserID, projectID).Error; err != nil {
+			return err
+		}
+
+		// Memberships are removed for good so the user can be invited again
+		if err := tx.Unscoped().Delete(&member).Error; err != nil {
+			return err
+		}
+		return s.recordMember(tx, &member, schemas.ActionDeleted, &member, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The former member stops receiving the events of the project
+	s.hub.Revoke(member.ProjectID, member.UserID)
+	return nil
+}
+
+func (s *service) recordMember(tx *gorm.DB, member *schemas.ProjectMember, action string, before any, after any) error {
+	return s.record(tx, schemas.Activity{
+		ProjectID:  member.ProjectID,
+		EntityType: schemas.EntityMember,
+		EntityID:   member.ID,
+		Action:     action,
+	}, before, after)
+}
+
+// ensureAnotherOwner returns ErrLastOwner unless the project has an owner
+// other than the membership being demoted or removed.
+func ensureAnotherOwner(tx *gorm.DB, projectID string, memberID uint) error {
+	var owners int64
+	if err := tx.Model(&schemas.ProjectMember{}).
+		Where("project_id = ? AND role = ? AND id <> ?", projectID, schemas.RoleOwner, memberID).
+		Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-tasker/types"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// was issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor identifies the last row of a page. It is serialised as base64 JSON
+// and handed to clients as next_cursor.
+type cursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+// sortColumn describes a field a collection can be ordered by: the SQL
+// expression to order on and how to read the same value from a loaded row.
+type sortColumn[T any] struct {
+	expr  string
+	value func(T) any
+}
+
+// paginate applies keyset pagination to tx. Rows are ordered by the requested
+// sort column with the primary key as a tie-breaker, and the page after the
+// cursor is fetched with a LIMIT so only the requested rows are read. It
+// returns the rows and the cursor of the next page, or "" on the last page.
+func paginate[T any](tx *gorm.DB, table string, sort string, columns map[string]sortColumn[T], page types.PageQuery, id func(T) uint) ([]T, string, error) {
+	if sort == "" {
+		sort = "id"
+	}
+
+	field, desc := strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+	column, ok := columns[field]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported sort %q", sort)
+	}
+
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if page.Cursor != "" {
+		var zero T
+		last, lastID, err := decodeCursor(page.Cursor, sort, reflect.TypeOf(column.value(zero)))
+		if err != nil {
+			return nil, "", err
+		}
+
+		tx = tx.Where(
+			fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s.id %[2]s ?))", column.expr, comparison, table),
+			last, last, lastID,
+		)
+	}
+
+	limit := page.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	var rows []T
+	err := tx.
+		Order(column.expr + " " + direction).
+		Order(table + ".id " + direction).
+		Limit(limit + 1).
+		Find(&rows).Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(rows) <= limit {
+		return rows, "", nil
+	}
+
+	rows = rows[:limit]
+	last := rows[len(rows)-1]
+	next, err := encodeCursor(sort, column.value(last), id(last))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return rows, next, nil
+}
+
+func encodeCursor(sort string, value any, id uint) (string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	encoded, err := json.Marshal(cursor{Sort: sort, Value: raw, ID: id})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// decodeCursor returns the sort value and ID stored in the cursor, decoding
+// the value into valueType so it binds exactly like the column it came from.
+func decodeCursor(encoded string, sort string, valueType reflect.Type) (any, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sort {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	value := reflect.New(valueType)
+	if err := json.Unmarshal(c.Value, value.Interface()); err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	return value.Elem().Interface(), c.ID, nil
+}
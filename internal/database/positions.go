@@ -0,0 +1,262 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/schemas"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidSibling = errors.New("an item can only be moved next to another item of the same parent")
+
+// rankDigits are the characters of a position, in ascending byte order so
+// positions sort correctly as plain strings.
+const rankDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// maxRankLength is the length past which the positions of a scope are spread
+// out again, so they stay short however often items are inserted at the same
+// spot.
+const maxRankLength = 8
+
+// rankBetween returns a position sorting strictly between a and b, where an
+// empty a or b leaves that side unbounded. Positions never end in the lowest
+// digit, so there is always room for another one in between, and moving an
+// item only rewrites that one row until its scope has to be rebalanced.
+func rankBetween(a string, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) && rankDigitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + rankBetween(rankSuffix(a, n), b[n:])
+		}
+	}
+
+	lo := strings.IndexByte(rankDigits, rankDigitAt(a, 0))
+	hi := len(rankDigits)
+	if b != "" {
+		hi = strings.IndexByte(rankDigits, b[0])
+	}
+
+	switch {
+	case a != "" && b == "" && lo+1 < hi:
+		// Appending steps by one digit, which keeps positions short when
+		// items are only ever added at the end
+		return string(rankDigits[lo+1])
+	case hi-lo > 1:
+		// Without a lower bound this halves the room left before b, so
+		// inserting at the front only lengthens positions every few items
+		return string(rankDigits[(lo+hi)/2])
+	case len(b) > 1:
+		return b[:1]
+	default:
+		rest := rankSuffix(a, 1)
+		if rest == "" && b == "" {
+			// Appending past the highest digit carries on stepping
+			rest = rankDigits[:1]
+		}
+		return string(rankDigits[lo]) + rankBetween(rest, "")
+	}
+}
+
+func rankDigitAt(rank string, i int) byte {
+	if i < len(rank) {
+		return rank[i]
+	}
+	return rankDigits[0]
+}
+
+func rankSuffix(rank string, i int) string {
+	if i < len(rank) {
+		return rank[i:]
+	}
+	return ""
+}
+
+// nextPosition returns a position after every row of table whose scope
+// column equals scopeID, to append a new row at the end.
+func nextPosition(tx *gorm.DB, table string, scope string, scopeID uint) (string, error) {
+	return balancedPosition(tx, table, scope, scopeID, 0, func() (string, error) {
+		var last string
+		if err := tx.Table(table).
+			Select("COALESCE(MAX(position), '')").
+			Where(scope+" = ? AND deleted_at IS NULL", scopeID).
+			Scan(&last).Error; err != nil {
+			return "", err
+		}
+		return rankBetween(last, ""), nil
+	})
+}
+
+// positionNextTo returns a position right before or right after the sibling
+// row, among the rows of table sharing its scope. The moving row is ignored
+// so moving an item next to its current neighbour is a no-op.
+func positionNextTo(tx *gorm.DB, table string, scope string, scopeID uint, movingID uint, siblingID uint, after bool) (string, error) {
+	return balancedPosition(tx, table, scope, scopeID, movingID, func() (string, error) {
+		var sibling struct {
+			Position string
+			ScopeID  uint
+		}
+		if err := tx.Table(table).
+			Select("position, "+scope+" AS scope_id").
+			Where("id = ? AND deleted_at IS NULL", siblingID).
+			Scan(&sibling).Error; err != nil {
+			return "", err
+		}
+		if sibling.Position == "" || sibling.ScopeID != scopeID || siblingID == movingID {
+			return "", ErrInvalidSibling
+		}
+
+		neighbour := tx.Table(table).
+			Where(scope+" = ? AND deleted_at IS NULL AND id <> ?", scopeID, movingID)
+		if after {
+			neighbour = neighbour.Select("COALESCE(MIN(position), '')").Where("position > ?", sibling.Position)
+		} else {
+			neighbour = neighbour.Select("COALESCE(MAX(position), '')").Where("position < ?", sibling.Position)
+		}
+
+		var other string
+		if err := neighbour.Scan(&other).Error; err != nil {
+			return "", err
+		}
+
+		if after {
+			return rankBetween(sibling.Position, other), nil
+		}
+		return rankBetween(other, sibling.Position), nil
+	})
+}
+
+// balancedPosition returns the position computed by rank. When it is longer
+// than maxRankLength, the scope is rebalanced and the position computed
+// again from the new positions of its rows.
+func balancedPosition(tx *gorm.DB, table string, scope string, scopeID uint, movingID uint, rank func() (string, error)) (string, error) {
+	position, err := rank()
+	if err != nil || len(position) <= maxRankLength {
+		return position, err
+	}
+
+	if err := rebalancePositions(tx, table, scope, scopeID, movingID); err != nil {
+		return "", err
+	}
+	return rank()
+}
+
+// rebalancePositions spreads the rows of table whose scope column equals
+// scopeID evenly over positions of the same length, keeping their order.
+// Deleted rows are included so they come back in place when restored, and
+// the moving row is left out as its caller gives it a new position anyway.
+func rebalancePositions(tx *gorm.DB, table string, scope string, scopeID uint, movingID uint) error {
+	var ids []uint
+	if err := tx.Table(table).
+		Where(scope+" = ? AND id <> ?", scopeID, movingID).
+		Order("position, id").
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	for i, position := range spreadRanks(len(ids)) {
+		if err := tx.Table(table).Where("id = ?", ids[i]).Updates(map[string]any{
+			"position": position,
+			"version":  gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return fmt.Errorf("rebalancing %s positions: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// spreadRanks returns n ascending positions of the same length, spaced so
+// that at least a full digit of room is left around each of them.
+func spreadRanks(n int) []string {
+	base := len(rankDigits)
+	width, room := 1, base
+	for room/(n+1) < base {
+		width++
+		room *= base
+	}
+	step := room / (n + 1)
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		value := (i + 1) * step
+		if value%base == 0 {
+			value++
+		}
+
+		digits := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			digits[j] = rankDigits[value%base]
+			value /= base
+		}
+		ranks[i] = string(digits)
+	}
+	return ranks
+}
+
+// backfillPositions gives rows created before positions existed one at the
+// end of their scope, in ID order.
+func backfillPositions(db *gorm.DB) error {
+	for _, t := range []struct{ table, scope string }{{"lists", "project_id"}, {"tasks", "list_id"}} {
+		var rows []struct {
+			ID      uint
+			ScopeID uint
+		}
+		if err := db.Table(t.table).
+			Select("id, " + t.scope + " AS scope_id").
+			Where("position IS NULL OR position = ''").
+			Order("id").
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			var last string
+			if err := db.Table(t.table).
+				Select("COALESCE(MAX(position), '')").
+				Where(t.scope+" = ?", row.ScopeID).
+				Scan(&last).Error; err != nil {
+				return err
+			}
+			if err := db.Table(t.table).Where("id = ?", row.ID).
+				Update("position", rankBetween(last, "")).Error; err != nil {
+				return fmt.Errorf("backfilling %s positions: %w", t.table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MoveList puts the list right before or after a sibling list of the same
+// project.
+func (s *service) MoveList(projectID string, listID string, siblingID uint, after bool) (*schemas.List, error) {
+	var list schemas.List
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND project_id = ?", listID, projectID).First(&list).Error; err != nil {
+			return err
+		}
+
+		position, err := positionNextTo(tx, "lists", "project_id", list.ProjectID, list.ID, siblingID, after)
+		if err != nil {
+			return err
+		}
+
+		before := list
+		list.Position = position
+		if list.Version, err = s.bumpVersion(tx, "lists", list.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&list).Update("position", position).Error; err != nil {
+			return err
+		}
+		return s.recordList(tx, &list, schemas.ActionMoved, &before, &list)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &list, nil
+}
@@ -3,23 +3,76 @@ package database
 import (
 	"go-tasker/schemas"
 	"go-tasker/types"
+	"time"
+
+	"gorm.io/gorm"
 )
 
-func (s *service) GetProjects() ([]schemas.Project, error) {
-	var projects []schemas.Project
-	if err := s.db.Find(&projects).Error; err != nil {
+var projectSortColumns = map[string]sortColumn[schemas.Project]{
+	"id":         {"projects.id", func(p schemas.Project) any { return p.ID }},
+	"title":      {"projects.title", func(p schemas.Project) any { return p.Title }},
+	"created_at": {"projects.created_at", func(p schemas.Project) any { return p.CreatedAt }},
+	"updated_at": {"projects.updated_at", func(p schemas.Project) any { return p.UpdatedAt }},
+}
+
+// GetProjects returns the projects the user is a member of.
+func (s *service) GetProjects(userID uint, query types.ProjectQuery) ([]schemas.Project, string, error) {
+	tx := s.db.Model(&schemas.Project{}).
+		Joins("JOIN project_members ON project_members.project_id = projects.id AND project_members.deleted_at IS NULL").
+		Where("project_members.user_id = ?", userID)
+
+	if query.Status != "" {
+		tx = tx.Where("projects.status = ?", query.Status)
+	}
+	if query.CreatedAfter != nil {
+		tx = tx.Where("projects.created_at > ?", toUTC(query.CreatedAfter))
+	}
+	if query.CreatedBefore != nil {
+		tx = tx.Where("projects.created_at < ?", toUTC(query.CreatedBefore))
+	}
+
+	return paginate(tx, "projects", query.Sort, projectSortColumns, query.PageQuery,
+		func(p schemas.Project) uint { return p.ID })
+}
+
+func (s *service) GetProject(projectID string) (*schemas.Project, error) {
+	var project schemas.Project
+	if err := s.db.First(&project, projectID).Error; err != nil {
 		return nil, err
 	}
-	return projects, nil
+	return &project, nil
 }
 
-func (s *service) CreateProject(payload types.CreateProjectPayload) (*schemas.Project, error) {
+// CreateProject creates the project with the user as its owner.
+func (s *service) CreateProject(userID uint, payload types.CreateProjectPayload) (*schemas.Project, error) {
 	project := schemas.Project{
-		Title:  payload.Title,
-		Status: payload.Status,
+		Title:   payload.Title,
+		Status:  payload.Status,
+		Version: 1,
 	}
+	if project.Status == "" {
+		project.Status = s.workflow.Initial
+	}
+
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+		if err := s.changeStatus(tx, &project, "", ""); err != nil {
+			return err
+		}
 
-	if err := s.db.Create(&project).Error; err != nil {
+		owner := schemas.ProjectMember{
+			ProjectID: project.ID,
+			UserID:    userID,
+			Role:      schemas.RoleOwner,
+		}
+		if err := tx.Create(&owner).Error; err != nil {
+			return err
+		}
+		return s.recordProject(tx, &project, schemas.ActionCreated, nil, &project)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -27,15 +80,40 @@ func (s *service) CreateProject(payload types.CreateProjectPayload) (*schemas.Pr
 }
 
 func (s *service) UpdateProject(projectID string, payload types.UpdateProjectPayload) (*schemas.Project, error) {
+	return s.updateProject(projectID, "", func(project *schemas.Project) error {
+		project.Title = payload.Title
+		project.Status = payload.Status
+		return nil
+	})
+}
+
+// updateProject applies change to a project and saves it, moving it through
+// the workflow when its status changed.
+func (s *service) updateProject(projectID string, comment string, change func(project *schemas.Project) error) (*schemas.Project, error) {
 	var project schemas.Project
 	if err := s.db.First(&project, projectID).Error; err != nil {
 		return nil, err
 	}
 
-	project.Title = payload.Title
-	project.Status = payload.Status
+	before := project
+	if err := change(&project); err != nil {
+		return nil, err
+	}
 
-	if err := s.db.Save(&project).Error; err != nil {
+	err := s.transaction(func(tx *gorm.DB) error {
+		var err error
+		if project.Version, err = s.bumpVersion(tx, "projects", project.ID); err != nil {
+			return err
+		}
+		if err := s.changeStatus(tx, &project, before.Status, comment); err != nil {
+			return err
+		}
+		if err := tx.Save(&project).Error; err != nil {
+			return err
+		}
+		return s.recordProject(tx, &project, schemas.ActionUpdated, &before, &project)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -48,9 +126,31 @@ func (s *service) DeleteProject(projectID string) error {
 		return err
 	}
 
-	if err := s.db.Delete(&project).Error; err != nil {
+	// Lists and tasks are deleted with the same timestamp so restoring the
+	// project brings them back too
+	now := time.Now().UTC()
+	err := s.transaction(func(tx *gorm.DB) error {
+		if _, err := s.bumpVersion(tx, "projects", project.ID); err != nil {
+			return err
+		}
+
+		lists := tx.Table("lists").Select("id").Where("project_id = ?", project.ID)
+		if err := softDeleteAt(tx, "tasks", now, "list_id IN (?)", lists); err != nil {
+			return err
+		}
+		if err := softDeleteAt(tx, "lists", now, "project_id = ?", project.ID); err != nil {
+			return err
+		}
+		if err := softDeleteAt(tx, "projects", now, "id = ?", project.ID); err != nil {
+			return err
+		}
+		return s.recordProject(tx, &project, schemas.ActionDeleted, &project, nil)
+	})
+	if err != nil {
 		return err
 	}
 
+	// Nobody follows a deleted project any more
+	s.hub.Revoke(project.ID, 0)
 	return nil
 }
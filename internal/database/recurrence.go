@@ -0,0 +1,123 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/internal/recurrence"
+	"go-tasker/schemas"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrTaskNotRecurring = errors.New("task does not recur")
+
+// GetTaskOccurrences previews the next count occurrences of a recurring task
+// after its current one.
+func (s *service) GetTaskOccurrences(projectID string, listID string, taskID string, count int) ([]time.Time, error) {
+	task, err := s.findTask(s.db, projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Recurrence == "" {
+		return nil, ErrTaskNotRecurring
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	return rule.After(task.AnchorAt.UTC(), currentOccurrence(task), count), nil
+}
+
+// createNextOccurrence creates the instance following a completed recurring
+// task. Nothing is created when the series has ended, or when an instance
+// was already generated because the task was completed before.
+func (s *service) createNextOccurrence(tx *gorm.DB, projectID string, task *schemas.Task) error {
+	var existing int64
+	if err := tx.Model(&schemas.Task{}).Where("recurrence_from_id = ?", task.ID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return err
+	}
+
+	current := currentOccurrence(task)
+	next := rule.After(task.AnchorAt.UTC(), current, 1)
+	if len(next) == 0 {
+		return nil
+	}
+
+	position, err := nextPosition(tx, "tasks", "list_id", task.ListID)
+	if err != nil {
+		return err
+	}
+
+	nextTask := schemas.Task{
+		Title:            task.Title,
+		Position:         position,
+		Priority:         task.Priority,
+		ListID:           task.ListID,
+		ParentID:         task.ParentID,
+		Recurrence:       task.Recurrence,
+		AnchorAt:         task.AnchorAt,
+		RecurrenceFromID: &task.ID,
+		DueAt:            &next[0],
+		Version:          1,
+	}
+	// Keep the same lead time between start and due date
+	if task.StartAt != nil && task.DueAt != nil {
+		startAt := next[0].Add(task.StartAt.Sub(*task.DueAt))
+		nextTask.StartAt = &startAt
+	}
+
+	if err := tx.Create(&nextTask).Error; err != nil {
+		return err
+	}
+	if err := s.recordTask(tx, projectID, &nextTask, schemas.ActionCreated, nil, &nextTask); err != nil {
+		return err
+	}
+
+	// Assignees and labels carry over to the next instance
+	if err := tx.Exec("INSERT INTO task_assignees (task_id, user_id) SELECT ?, user_id FROM task_assignees WHERE task_id = ?",
+		nextTask.ID, task.ID).Error; err != nil {
+		return err
+	}
+	return tx.Exec("INSERT INTO task_labels (task_id, label_id) SELECT ?, label_id FROM task_labels WHERE task_id = ?",
+		nextTask.ID, task.ID).Error
+}
+
+// currentOccurrence is the date of the instance a task represents: its due
+// date, or the anchor for a task without one.
+func currentOccurrence(task *schemas.Task) time.Time {
+	if task.DueAt != nil {
+		return task.DueAt.UTC()
+	}
+	return task.AnchorAt.UTC()
+}
+
+// recurrenceOrNone returns the rule and anchor to store for a task. The
+// anchor defaults to the one of the stored task when its rule is unchanged,
+// so an update does not restart the series, then to the due date, then to
+// now. It is dropped when the task does not recur.
+func recurrenceOrNone(rule string, anchorAt *time.Time, dueAt *time.Time, stored *schemas.Task) (string, *time.Time) {
+	if rule == "" {
+		return "", nil
+	}
+	if anchorAt == nil && stored != nil && stored.Recurrence == rule {
+		anchorAt = stored.AnchorAt
+	}
+	if anchorAt == nil {
+		anchorAt = dueAt
+	}
+	if anchorAt == nil {
+		now := time.Now().Truncate(time.Second)
+		anchorAt = &now
+	}
+	return rule, toUTC(anchorAt)
+}
@@ -0,0 +1,246 @@
+package database
+
+import (
+	"fmt"
+	"go-tasker/types"
+	"html"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SearchResult is a project, list or task matching a search query, linked
+// to its parent project and list.
+type SearchResult struct {
+	Type         string
+	ID           uint
+	Title        string
+	Snippet      string
+	ProjectID    uint
+	ProjectTitle string
+	ListID       *uint
+	ListTitle    *string
+	Rank         float64
+}
+
+// searchTables maps each indexed table to its FTS5 shadow table.
+var searchTables = map[string]string{
+	"projects": "projects_fts",
+	"lists":    "lists_fts",
+	"tasks":    "tasks_fts",
+}
+
+// migrateSearch creates the FTS5 indexes and the triggers that keep them in
+// sync with their tables, and fills indexes that were missing or not kept in
+// sync. It returns false when SQLite was built without FTS5 (the sqlite_fts5
+// build tag), in which case search falls back to LIKE matching.
+func migrateSearch(db *gorm.DB) (bool, error) {
+	for table, fts := range searchTables {
+		var tables, triggers int64
+		if err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", fts).
+			Scan(&tables).Error; err != nil {
+			return false, err
+		}
+		if err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND tbl_name = ? AND name LIKE ?", table, fts+"_%").
+			Scan(&triggers).Error; err != nil {
+			return false, err
+		}
+
+		var err error
+		if tables == 0 {
+			err = db.Exec(fmt.Sprintf("CREATE VIRTUAL TABLE %s USING fts5(title, tokenize = 'unicode61 remove_diacritics 2')", fts)).Error
+		} else {
+			err = db.Exec(fmt.Sprintf("SELECT rowid FROM %s LIMIT 0", fts)).Error
+		}
+		if err != nil {
+			if strings.Contains(err.Error(), "no such module") {
+				// Triggers left by an FTS5 build would make every write fail
+				return false, dropSearchTriggers(db)
+			}
+			return false, err
+		}
+
+		if tables == 0 || triggers < 3 {
+			if err := db.Exec(fmt.Sprintf("DELETE FROM %s", fts)).Error; err != nil {
+				return false, err
+			}
+			if err := db.Exec(fmt.Sprintf(
+				"INSERT INTO %s(rowid, title) SELECT id, title FROM %s WHERE deleted_at IS NULL", fts, table,
+			)).Error; err != nil {
+				return false, err
+			}
+		}
+
+		// Soft deletes are updates, so the update trigger re-indexes a row
+		// only while it is not deleted
+		statements := []string{
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[2]s_insert AFTER INSERT ON %[1]s WHEN new.deleted_at IS NULL BEGIN
+				INSERT INTO %[2]s(rowid, title) VALUES (new.id, new.title);
+			END`, table, fts),
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[2]s_update AFTER UPDATE ON %[1]s BEGIN
+				DELETE FROM %[2]s WHERE rowid = old.id;
+				INSERT INTO %[2]s(rowid, title) SELECT new.id, new.title WHERE new.deleted_at IS NULL;
+			END`, table, fts),
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[2]s_delete AFTER DELETE ON %[1]s BEGIN
+				DELETE FROM %[2]s WHERE rowid = old.id;
+			END`, table, fts),
+		}
+		for _, statement := range statements {
+			if err := db.Exec(statement).Error; err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func dropSearchTriggers(db *gorm.DB) error {
+	for _, fts := range searchTables {
+		for _, event := range []string{"insert", "update", "delete"} {
+			if err := db.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %s_%s", fts, event)).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Search returns the best matches for the query among the projects, lists
+// and tasks the user can see, most relevant first.
+func (s *service) Search(userID uint, query types.SearchQuery) ([]SearchResult, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	if s.fts {
+		return s.searchFTS(userID, query.Q, limit)
+	}
+	return s.searchLike(userID, query.Q, limit)
+}
+
+func (s *service) searchFTS(userID uint, q string, limit int) ([]SearchResult, error) {
+	match := ftsQuery(q)
+	if match == "" {
+		return []SearchResult{}, nil
+	}
+
+	var results []SearchResult
+	err := s.db.Raw(`
+		SELECT 'project' AS type, projects.id AS id, projects.title AS title,
+			snippet(projects_fts, 0, @open, @close, '…', 12) AS snippet,
+			projects.id AS project_id, projects.title AS project_title,
+			NULL AS list_id, NULL AS list_title, bm25(projects_fts) AS rank
+		FROM projects_fts
+		JOIN projects ON projects.id = projects_fts.rowid
+		WHERE projects_fts MATCH @match AND `+visibleProject+`
+		UNION ALL
+		SELECT 'list', lists.id, lists.title,
+			snippet(lists_fts, 0, @open, @close, '…', 12),
+			projects.id, projects.title, lists.id, lists.title, bm25(lists_fts)
+		FROM lists_fts
+		JOIN lists ON lists.id = lists_fts.rowid
+		JOIN projects ON projects.id = lists.project_id
+		WHERE lists_fts MATCH @match AND lists.deleted_at IS NULL AND `+visibleProject+`
+		UNION ALL
+		SELECT 'task', tasks.id, tasks.title,
+			snippet(tasks_fts, 0, @open, @close, '…', 12),
+			projects.id, projects.title, lists.id, lists.title, bm25(tasks_fts)
+		FROM tasks_fts
+		JOIN tasks ON tasks.id = tasks_fts.rowid
+		JOIN lists ON lists.id = tasks.list_id
+		JOIN projects ON projects.id = lists.project_id
+		WHERE tasks_fts MATCH @match AND lists.deleted_at IS NULL AND `+visibleProject+`
+		ORDER BY rank
+		LIMIT @limit`,
+		map[string]interface{}{"match": match, "user": userID, "limit": limit, "open": snippetOpen, "close": snippetClose},
+	).Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Snippet = highlightSnippet(results[i].Snippet)
+	}
+	return results, nil
+}
+
+// Titles are user input, so snippet() marks matches with control characters
+// that highlightSnippet turns into <mark> tags once the text is escaped.
+const (
+	snippetOpen  = "\x01"
+	snippetClose = "\x02"
+)
+
+// highlightSnippet returns a snippet as HTML, with the matches marked by
+// snippet() in <mark> tags and everything else escaped.
+func highlightSnippet(snippet string) string {
+	return strings.NewReplacer(snippetOpen, "<mark>", snippetClose, "</mark>").Replace(html.EscapeString(snippet))
+}
+
+// likePattern is a LIKE pattern, with ESCAPE '\', matching text containing q.
+func likePattern(q string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q) + "%"
+}
+
+// searchLike is the fallback used without FTS5: a case-insensitive
+// substring match on titles with no ranking or highlighting. Snippets are
+// the escaped titles.
+func (s *service) searchLike(userID uint, q string, limit int) ([]SearchResult, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return []SearchResult{}, nil
+	}
+	pattern := likePattern(q)
+
+	var results []SearchResult
+	err := s.db.Raw(`
+		SELECT 'project' AS type, projects.id AS id, projects.title AS title, projects.title AS snippet,
+			projects.id AS project_id, projects.title AS project_title,
+			NULL AS list_id, NULL AS list_title, 0 AS rank
+		FROM projects
+		WHERE projects.title LIKE @pattern ESCAPE '\' AND `+visibleProject+`
+		UNION ALL
+		SELECT 'list', lists.id, lists.title, lists.title, projects.id, projects.title, lists.id, lists.title, 0
+		FROM lists
+		JOIN projects ON projects.id = lists.project_id
+		WHERE lists.title LIKE @pattern ESCAPE '\' AND lists.deleted_at IS NULL AND `+visibleProject+`
+		UNION ALL
+		SELECT 'task', tasks.id, tasks.title, tasks.title, projects.id, projects.title, lists.id, lists.title, 0
+		FROM tasks
+		JOIN lists ON lists.id = tasks.list_id
+		JOIN projects ON projects.id = lists.project_id
+		WHERE tasks.title LIKE @pattern ESCAPE '\' AND tasks.deleted_at IS NULL
+			AND lists.deleted_at IS NULL AND `+visibleProject+`
+		LIMIT @limit`,
+		map[string]interface{}{"pattern": pattern, "user": userID, "limit": limit},
+	).Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Snippet = html.EscapeString(results[i].Snippet)
+	}
+	return results, nil
+}
+
+// visibleProject restricts search results to live projects the @user
+// parameter is a member of.
+const visibleProject = `projects.deleted_at IS NULL AND projects.id IN (
+	SELECT project_id FROM project_members WHERE user_id = @user AND deleted_at IS NULL)`
+
+// ftsQuery turns free text into an FTS5 query in which every word must
+// match as a prefix. Words are quoted so user input cannot inject FTS5
+// operators or cause syntax errors.
+func ftsQuery(q string) string {
+	var terms []string
+	for _, word := range strings.Fields(q) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word != "" {
+			terms = append(terms, `"`+word+`"*`)
+		}
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/workflow"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownStatus    = errors.New("unknown project status")
+	ErrStatusTransition = errors.New("invalid status transition")
+	ErrOpenTasks        = errors.New("the project still has open tasks")
+)
+
+var projectStatusChangeSortColumns = map[string]sortColumn[schemas.ProjectStatusChange]{
+	"id": {"project_status_changes.id", func(c schemas.ProjectStatusChange) any { return c.ID }},
+}
+
+// statusHook runs in the transaction moving a project into a status and
+// refuses the transition by returning an error.
+type statusHook func(tx *gorm.DB, project *schemas.Project) error
+
+// statusHooks are the hooks run when a project enters a status, whichever
+// endpoint changes it.
+var statusHooks = map[string][]statusHook{
+	schemas.ProjectCompleted: {requireTasksDone},
+}
+
+// requireTasksDone refuses to complete a project while any of its tasks is
+// still open.
+func requireTasksDone(tx *gorm.DB, project *schemas.Project) error {
+	var open int64
+	if err := tx.Model(&schemas.Task{}).
+		Joins("JOIN lists ON lists.id = tasks.list_id AND lists.deleted_at IS NULL").
+		Where("lists.project_id = ? AND tasks.done = ?", project.ID, false).
+		Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return fmt.Errorf("%w: %d tasks are not done", ErrOpenTasks, open)
+	}
+	return nil
+}
+
+func (s *service) Workflow() *workflow.Workflow {
+	return s.workflow
+}
+
+// TransitionProject moves a project to another status of the workflow.
+func (s *service) TransitionProject(projectID string, payload types.ProjectTransitionPayload) (*schemas.Project, error) {
+	return s.updateProject(projectID, payload.Comment, func(project *schemas.Project) error {
+		if project.Status == payload.Status {
+			return fmt.Errorf("%w: the project is already %q", ErrStatusTransition, payload.Status)
+		}
+		project.Status = payload.Status
+		return nil
+	})
+}
+
+// GetProjectStatusHistory returns a page of the status changes of a
+// project, newest first.
+func (s *service) GetProjectStatusHistory(projectID string, page types.PageQuery) ([]schemas.ProjectStatusChange, string, error) {
+	project, err := s.GetProject(projectID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tx := s.db.Model(&schemas.ProjectStatusChange{}).Where("project_status_changes.project_id = ?", project.ID)
+	return paginate(tx, "project_status_changes", "-id", projectStatusChangeSortColumns, page,
+		func(c schemas.ProjectStatusChange) uint { return c.ID })
+}
+
+// changeStatus checks that a project can move from the status from to its
+// current one, runs the hooks of the new status and records the change in
+// the status history. An empty from is a project being created, which must
+// start in the initial status of the workflow. Nothing happens when the
+// status did not change.
+func (s *service) changeStatus(tx *gorm.DB, project *schemas.Project, from string, comment string) error {
+	if project.Status == from {
+		return nil
+	}
+	if !s.workflow.Has(project.Status) {
+		return fmt.Errorf("%w %q: must be one of %s", ErrUnknownStatus, project.Status,
+			strings.Join(s.workflow.Statuses(), ", "))
+	}
+	if from == "" && project.Status != s.workflow.Initial {
+		return fmt.Errorf("%w: projects start as %q", ErrStatusTransition, s.workflow.Initial)
+	}
+	if from != "" && !s.workflow.Allows(from, project.Status) {
+		return fmt.Errorf("%w: a project cannot move from %q to %q", ErrStatusTransition, from, project.Status)
+	}
+
+	for _, hook := range statusHooks[project.Status] {
+		if err := hook(tx, project); err != nil {
+			return err
+		}
+	}
+
+	return tx.Create(&schemas.ProjectStatusChange{
+		ProjectID:  project.ID,
+		ActorID:    s.actorID,
+		FromStatus: from,
+		ToStatus:   project.Status,
+		Comment:    comment,
+	}).Error
+}
+
+// legacyStatuses maps the free-text statuses projects were created with
+// before the workflow existed to the statuses of the default workflow.
+var legacyStatuses = map[string]string{
+	"not started": schemas.ProjectPlanning,
+	"in progress": schemas.ProjectActive,
+	"on hold":     schemas.ProjectOnHold,
+	"done":        schemas.ProjectCompleted,
+}
+
+// migrateLegacyStatuses moves the projects still in one of legacyStatuses to
+// the matching status, when the workflow has it, and records the change in
+// their status history.
+func migrateLegacyStatuses(db *gorm.DB, projectWorkflow *workflow.Workflow) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for legacy, status := range legacyStatuses {
+			if !projectWorkflow.Has(status) {
+				continue
+			}
+
+			var projects []schemas.Project
+			if err := tx.Unscoped().Where("LOWER(TRIM(status)) = ?", legacy).Find(&projects).Error; err != nil {
+				return err
+			}
+			for _, project := range projects {
+				if err := tx.Model(&schemas.Project{}).Unscoped().Where("id = ?", project.ID).Updates(map[string]any{
+					"status":  status,
+					"version": gorm.Expr("version + 1"),
+				}).Error; err != nil {
+					return fmt.Errorf("migrating the status of project %d: %w", project.ID, err)
+				}
+				if err := tx.Create(&schemas.ProjectStatusChange{
+					ProjectID:  project.ID,
+					FromStatus: project.Status,
+					ToStatus:   status,
+					Comment:    "Moved to the project workflow",
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
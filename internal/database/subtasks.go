@@ -0,0 +1,134 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/schemas"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTaskCycle       = errors.New("a task cannot be nested under itself or one of its subtasks")
+	ErrParentNotInList = errors.New("parent task must belong to the same list")
+)
+
+// TaskTree is a task together with its nested subtasks.
+type TaskTree struct {
+	Task     schemas.Task
+	Subtasks []*TaskTree
+}
+
+// GetTaskTree loads a task and every task nested below it.
+func (s *service) GetTaskTree(projectID string, listID string, taskID string) (*TaskTree, error) {
+	root, err := s.findTask(s.db, projectID, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.subtreeIDs(s.db, root.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []schemas.Task
+	if err := s.db.Preload("Assignees").Preload("Labels").
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	// A re-parented task can have a lower ID than its new parent, so every
+	// node is indexed before any are linked.
+	nodes := make(map[uint]*TaskTree, len(tasks))
+	for _, task := range tasks {
+		nodes[task.ID] = &TaskTree{Task: task, Subtasks: []*TaskTree{}}
+	}
+	for _, task := range tasks {
+		if task.ID == root.ID || task.ParentID == nil {
+			continue
+		}
+		if parent, ok := nodes[*task.ParentID]; ok {
+			parent.Subtasks = append(parent.Subtasks, nodes[task.ID])
+		}
+	}
+
+	return nodes[root.ID], nil
+}
+
+// SetTaskParent nests the task under parentID, or makes it a top-level task
+// when parentID is nil.
+func (s *service) SetTaskParent(projectID string, listID string, taskID string, parentID *uint) (*schemas.Task, error) {
+	var task *schemas.Task
+	err := s.transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = s.findTask(tx, projectID, listID, taskID)
+		if err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			if err := s.checkParent(tx, task.ListID, *parentID); err != nil {
+				return err
+			}
+
+			subtree, err := s.subtreeIDs(tx, task.ID)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(subtree, *parentID) {
+				return ErrTaskCycle
+			}
+		}
+
+		before := *task
+		task.ParentID = parentID
+		if task.Version, err = s.bumpVersion(tx, "tasks", task.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(task).Update("parent_id", parentID).Error; err != nil {
+			return err
+		}
+		return s.recordTask(tx, projectID, task, schemas.ActionUpdated, &before, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// checkParent verifies that parentID is a live task in the given list.
+func (s *service) checkParent(tx *gorm.DB, listID uint, parentID uint) error {
+	var parent schemas.Task
+	if err := tx.First(&parent, parentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrParentNotInList
+		}
+		return err
+	}
+	if parent.ListID != listID {
+		return ErrParentNotInList
+	}
+	return nil
+}
+
+// subtreeIDs returns the ID of the task and of every live task nested below
+// it, the task itself first.
+func (s *service) subtreeIDs(tx *gorm.DB, taskID uint) ([]uint, error) {
+	var ids []uint
+	if err := tx.Raw(`
+		WITH RECURSIVE subtree(id) AS (
+			SELECT ?
+			UNION
+			SELECT tasks.id FROM tasks
+			JOIN subtree ON tasks.parent_id = subtree.id
+			WHERE tasks.deleted_at IS NULL
+		)
+		SELECT id FROM subtree`, taskID).
+		Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
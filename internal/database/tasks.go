@@ -1,22 +1,88 @@
 package database
 
 import (
+	"fmt"
 	"go-tasker/schemas"
 	"go-tasker/types"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
-func (s *service) GetTasks(projectID string, listID string) ([]schemas.Task, error) {
+// priorityRank is an SQL expression mapping tasks.priority to its index in
+// schemas.TaskPriorities so tasks can be ordered by importance.
+var priorityRank = func() string {
+	var b strings.Builder
+	b.WriteString("CASE tasks.priority")
+	for rank, priority := range schemas.TaskPriorities {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", priority, rank)
+	}
+	b.WriteString(" ELSE -1 END")
+	return b.String()
+}()
+
+var taskSortColumns = map[string]sortColumn[schemas.Task]{
+	"position":   {"tasks.position", func(t schemas.Task) any { return t.Position }},
+	"id":         {"tasks.id", func(t schemas.Task) any { return t.ID }},
+	"title":      {"tasks.title", func(t schemas.Task) any { return t.Title }},
+	"created_at": {"tasks.created_at", func(t schemas.Task) any { return t.CreatedAt }},
+	"updated_at": {"tasks.updated_at", func(t schemas.Task) any { return t.UpdatedAt }},
+	"priority":   {priorityRank, func(t schemas.Task) any { return slices.Index(schemas.TaskPriorities, t.Priority) }},
+}
+
+func (s *service) GetTasks(projectID string, listID string, query types.TaskQuery) ([]schemas.Task, string, error) {
 	var list schemas.List
 	if err := s.db.Where("id = ? AND project_id = ?", listID, projectID).First(&list).Error; err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	var tasks []schemas.Task
-	if err := s.db.Where("list_id = ?", listID).Find(&tasks).Error; err != nil {
-		return nil, err
+	tx := s.db.Model(&schemas.Task{}).Where("tasks.list_id = ?", listID)
+
+	if len(query.Priorities) > 0 {
+		tx = tx.Where("tasks.priority IN ?", query.Priorities)
 	}
-	return tasks, nil
+	if query.Done != nil {
+		tx = tx.Where("tasks.done = ?", *query.Done)
+	}
+	if query.CreatedAfter != nil {
+		tx = tx.Where("tasks.created_at > ?", toUTC(query.CreatedAfter))
+	}
+	if query.CreatedBefore != nil {
+		tx = tx.Where("tasks.created_at < ?", toUTC(query.CreatedBefore))
+	}
+
+	if len(query.Labels) > 0 {
+		labelled := s.db.Table("task_labels").
+			Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Where("labels.project_id = ? AND labels.name IN ?", projectID, query.Labels)
+
+		// "all" keeps tasks carrying every requested label, "any" at least one
+		if query.LabelMatch == "all" {
+			names := slices.Clone(query.Labels)
+			slices.Sort(names)
+			labelled = labelled.
+				Group("task_labels.task_id").
+				Having("COUNT(DISTINCT labels.id) = ?", len(slices.Compact(names)))
+		}
+
+		tx = tx.Where("tasks.id IN (?)", labelled)
+	}
+
+	sort := query.Sort
+	if sort == "" {
+		sort = "position"
+	}
+
+	return paginate(tx.Preload("Assignees").Preload("Labels"), "tasks", sort, taskSortColumns, query.PageQuery,
+		func(t schemas.Task) uint { return t.ID })
+}
+
+func (s *service) GetTask(projectID string, listID string, taskID string) (*schemas.Task, error) {
+	return s.findTask(s.db, projectID, listID, taskID)
 }
 
 func (s *service) CreateTask(projectID string, listID string, payload types.CreateTaskPayload) (*schemas.Task, error) {
@@ -30,12 +96,36 @@ func (s *service) CreateTask(projectID string, listID string, payload types.Crea
 		return nil, err
 	}
 
+	if payload.ParentID != nil {
+		if err := s.checkParent(s.db, list.ID, *payload.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
 	task := schemas.Task{
-		Title:  payload.Title,
-		ListID: uint(listIDUint),
+		Title:    payload.Title,
+		Priority: priorityOrDefault(payload.Priority),
+		StartAt:  toUTC(payload.StartAt),
+		DueAt:    toUTC(payload.DueAt),
+		ListID:   uint(listIDUint),
+		ParentID: payload.ParentID,
+		Version:  1,
 	}
+	task.Recurrence, task.AnchorAt = recurrenceOrNone(payload.Recurrence, payload.AnchorAt, payload.DueAt, nil)
 
-	if err := s.db.Create(&task).Error; err != nil {
+	err = s.transaction(func(tx *gorm.DB) error {
+		// The position is read in the transaction so that concurrent creates
+		// in the same list do not take the same one
+		var err error
+		if task.Position, err = nextPosition(tx, "tasks", "list_id", list.ID); err != nil {
+			return err
+		}
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		return s.recordTask(tx, projectID, &task, schemas.ActionCreated, nil, &task)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -50,10 +140,30 @@ func (s *service) UpdateTask(projectID string, listID string, taskID string, pay
 		return nil, err
 	}
 
+	if payload.Done && !task.Done {
+		blockers, err := s.openBlockerCount(s.db, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		if blockers > 0 {
+			return nil, ErrTaskBlocked
+		}
+	}
+
+	completed := payload.Done && !task.Done
+	before := task
+
 	task.Title = payload.Title
 	task.Done = payload.Done
+	task.Priority = priorityOrDefault(payload.Priority)
+	task.StartAt = toUTC(payload.StartAt)
+	task.DueAt = toUTC(payload.DueAt)
+	task.Recurrence, task.AnchorAt = recurrenceOrNone(payload.Recurrence, payload.AnchorAt, payload.DueAt, &before)
 
-	if err := s.db.Save(&task).Error; err != nil {
+	err := s.transaction(func(tx *gorm.DB) error {
+		return s.saveTask(tx, projectID, &before, &task, schemas.ActionUpdated, completed)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -61,33 +171,153 @@ func (s *service) UpdateTask(projectID string, listID string, taskID string, pay
 }
 
 func (s *service) UpdateTaskDone(projectID string, listID string, taskID string, payload types.UpdateTaskDonePayload) (*schemas.Task, error) {
-	var task schemas.Task
-	if err := s.db.Joins("JOIN lists ON lists.id = tasks.list_id").
-		Where("tasks.id = ? AND tasks.list_id = ? AND lists.project_id = ?", taskID, listID, projectID).
-		First(&task).Error; err != nil {
+	var task *schemas.Task
+	err := s.transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = s.findTask(tx, projectID, listID, taskID)
+		if err != nil {
+			return err
+		}
+		return s.setTaskDone(tx, projectID, task, payload.Done, payload.Force)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	task.Done = payload.Done
+	return task, nil
+}
 
-	if err := s.db.Save(&task).Error; err != nil {
-		return nil, err
+// setTaskDone marks a task loaded within tx as done or undone. Unless force
+// is set, a task cannot be completed while tasks blocking it are open.
+func (s *service) setTaskDone(tx *gorm.DB, projectID string, task *schemas.Task, done bool, force bool) error {
+	if done && !task.Done && !force {
+		blockers, err := s.openBlockerCount(tx, task.ID)
+		if err != nil {
+			return err
+		}
+		if blockers > 0 {
+			return ErrTaskBlocked
+		}
 	}
 
-	return &task, nil
+	completed := done && !task.Done
+	before := *task
+	task.Done = done
+
+	action := schemas.ActionUndone
+	if task.Done {
+		action = schemas.ActionDone
+	}
+
+	return s.saveTask(tx, projectID, &before, task, action, completed)
+}
+
+// saveTask saves the task, records the change and, when it has just been
+// completed, schedules the next instance of a recurring task.
+func (s *service) saveTask(tx *gorm.DB, projectID string, before *schemas.Task, task *schemas.Task, action string, completed bool) error {
+	var err error
+	if task.Version, err = s.bumpVersion(tx, "tasks", task.ID); err != nil {
+		return err
+	}
+	if err := tx.Save(task).Error; err != nil {
+		return err
+	}
+	if err := s.recordTask(tx, projectID, task, action, before, task); err != nil {
+		return err
+	}
+	if completed && task.Recurrence != "" {
+		return s.createNextOccurrence(tx, projectID, task)
+	}
+	return nil
 }
 
+// DeleteTask deletes the task together with all of its subtasks.
 func (s *service) DeleteTask(projectID string, listID string, taskID string) error {
-	var task schemas.Task
+	return s.transaction(func(tx *gorm.DB) error {
+		task, err := s.findTask(tx, projectID, listID, taskID)
+		if err != nil {
+			return err
+		}
+		_, err = s.deleteTask(tx, projectID, task)
+		return err
+	})
+}
+
+// deleteTask deletes a task loaded within tx with all of its subtasks and
+// returns the IDs of the tasks deleted.
+func (s *service) deleteTask(tx *gorm.DB, projectID string, task *schemas.Task) ([]uint, error) {
+	if _, err := s.bumpVersion(tx, "tasks", task.ID); err != nil {
+		return nil, err
+	}
+
+	ids, err := s.subtreeIDs(tx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Where("id IN ?", ids).Delete(&schemas.Task{}).Error; err != nil {
+		return nil, err
+	}
+	return ids, s.recordTask(tx, projectID, task, schemas.ActionDeleted, task, nil)
+}
+
+func (s *service) GetOverdueTasks(projectID string, now time.Time) ([]schemas.Task, error) {
+	var project schemas.Project
+	if err := s.db.First(&project, projectID).Error; err != nil {
+		return nil, err
+	}
+
+	var tasks []schemas.Task
 	if err := s.db.Joins("JOIN lists ON lists.id = tasks.list_id").
+		Where("lists.project_id = ? AND lists.deleted_at IS NULL", projectID).
+		Where("tasks.done = ? AND tasks.due_at < ?", false, now.UTC()).
+		Order("tasks.due_at").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (s *service) GetTasksDueBetween(projectID string, from time.Time, to time.Time) ([]schemas.Task, error) {
+	var project schemas.Project
+	if err := s.db.First(&project, projectID).Error; err != nil {
+		return nil, err
+	}
+
+	var tasks []schemas.Task
+	if err := s.db.Joins("JOIN lists ON lists.id = tasks.list_id").
+		Where("lists.project_id = ? AND lists.deleted_at IS NULL", projectID).
+		Where("tasks.due_at >= ? AND tasks.due_at < ?", from.UTC(), to.UTC()).
+		Order("tasks.due_at").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// findTask loads a task, checking that it belongs to the list and project.
+func (s *service) findTask(tx *gorm.DB, projectID string, listID string, taskID string) (*schemas.Task, error) {
+	var task schemas.Task
+	if err := tx.Joins("JOIN lists ON lists.id = tasks.list_id").
 		Where("tasks.id = ? AND tasks.list_id = ? AND lists.project_id = ?", taskID, listID, projectID).
 		First(&task).Error; err != nil {
-		return err
+		return nil, err
 	}
+	return &task, nil
+}
 
-	if err := s.db.Delete(&task).Error; err != nil {
-		return err
+// toUTC normalises optional timestamps so they compare correctly as SQLite text.
+func toUTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
 	}
+	utc := t.UTC()
+	return &utc
+}
 
-	return nil
+func priorityOrDefault(priority string) string {
+	if priority == "" {
+		return schemas.PriorityNone
+	}
+	return priority
 }
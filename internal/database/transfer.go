@@ -0,0 +1,316 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+var ErrTargetListNotFound = errors.New("target list not found in the target project")
+
+// MoveTask moves a task and its subtasks next to a sibling, into another
+// list, or both. Labels, assignees and dependencies follow the task into
+// another project where they can: labels are matched by name and created
+// when missing, and assignees or dependencies that cannot exist there are
+// dropped.
+func (s *service) MoveTask(projectID string, listID string, taskID string, payload types.MoveTaskPayload) (*schemas.Task, error) {
+	var task *schemas.Task
+	err := s.transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = s.findTask(tx, projectID, listID, taskID)
+		if err != nil {
+			return err
+		}
+		return s.moveTask(tx, task, payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// moveTask moves a task loaded within tx, as MoveTask describes.
+func (s *service) moveTask(tx *gorm.DB, task *schemas.Task, payload types.MoveTaskPayload) error {
+	before := *task
+
+	source, target, err := s.transferLists(tx, task.ListID, payload.ProjectID, payload.ListID)
+	if err != nil {
+		return err
+	}
+
+	if target.ID != source.ID {
+		subtree, err := s.subtreeIDs(tx, task.ID)
+		if err != nil {
+			return err
+		}
+
+		// The subtree keeps its order, after everything already in the
+		// target list. The task itself is placed below.
+		var descendants []schemas.Task
+		if err := tx.Where("id IN ? AND id <> ?", subtree, task.ID).Order("position").Find(&descendants).Error; err != nil {
+			return err
+		}
+		for _, descendant := range descendants {
+			position, err := nextPosition(tx, "tasks", "list_id", target.ID)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&descendant).Updates(map[string]any{"list_id": target.ID, "position": position}).Error; err != nil {
+				return err
+			}
+		}
+
+		if target.ProjectID != source.ProjectID {
+			if err := s.carryTaskRelations(tx, subtree, source.ProjectID, target.ProjectID); err != nil {
+				return err
+			}
+		}
+
+		// The parent stays behind in the old list
+		task.ParentID = nil
+		task.ListID = target.ID
+	}
+
+	if payload.BeforeID != nil || payload.AfterID != nil {
+		if payload.AfterID != nil {
+			task.Position, err = positionNextTo(tx, "tasks", "list_id", target.ID, task.ID, *payload.AfterID, true)
+		} else {
+			task.Position, err = positionNextTo(tx, "tasks", "list_id", target.ID, task.ID, *payload.BeforeID, false)
+		}
+	} else {
+		task.Position, err = nextPosition(tx, "tasks", "list_id", target.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if task.Version, err = s.bumpVersion(tx, "tasks", task.ID); err != nil {
+		return err
+	}
+	if err := tx.Model(task).Updates(map[string]any{
+		"list_id":   task.ListID,
+		"parent_id": task.ParentID,
+		"position":  task.Position,
+	}).Error; err != nil {
+		return err
+	}
+
+	// A move between projects shows up in the feeds of both
+	for _, feed := range slices.Compact([]uint{source.ProjectID, target.ProjectID}) {
+		if err := s.record(tx, schemas.Activity{
+			ProjectID:  feed,
+			TaskID:     &task.ID,
+			EntityType: schemas.EntityTask,
+			EntityID:   task.ID,
+			Action:     schemas.ActionMoved,
+		}, &before, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CopyTask copies a task and its subtasks to the end of a list. The copies
+// keep their fields, labels, assignees and the dependencies among
+// themselves; dependencies on other tasks are kept within the same project.
+func (s *service) CopyTask(projectID string, listID string, taskID string, payload types.CopyTaskPayload) (*schemas.Task, error) {
+	var root schemas.Task
+	err := s.transaction(func(tx *gorm.DB) error {
+		task, err := s.findTask(tx, projectID, listID, taskID)
+		if err != nil {
+			return err
+		}
+
+		source, target, err := s.transferLists(tx, task.ListID, payload.ProjectID, payload.ListID)
+		if err != nil {
+			return err
+		}
+
+		subtree, err := s.subtreeIDs(tx, task.ID)
+		if err != nil {
+			return err
+		}
+
+		var originals []schemas.Task
+		if err := tx.Where("id IN ?", subtree).Order("position").Find(&originals).Error; err != nil {
+			return err
+		}
+		// The copied task comes first so it is placed before its subtasks
+		slices.SortStableFunc(originals, func(a, b schemas.Task) int {
+			return boolRank(a.ID != task.ID) - boolRank(b.ID != task.ID)
+		})
+
+		copies := make(map[uint]uint, len(originals))
+		for _, original := range originals {
+			position, err := nextPosition(tx, "tasks", "list_id", target.ID)
+			if err != nil {
+				return err
+			}
+
+			duplicate := schemas.Task{
+				Title:      original.Title,
+				Done:       original.Done,
+				Position:   position,
+				Priority:   original.Priority,
+				StartAt:    original.StartAt,
+				DueAt:      original.DueAt,
+				Recurrence: original.Recurrence,
+				AnchorAt:   original.AnchorAt,
+				ListID:     target.ID,
+				Version:    1,
+			}
+			if err := tx.Create(&duplicate).Error; err != nil {
+				return err
+			}
+			copies[original.ID] = duplicate.ID
+
+			if original.ID == task.ID {
+				root = duplicate
+			}
+		}
+
+		for _, original := range originals {
+			if original.ID == task.ID || original.ParentID == nil {
+				continue
+			}
+			if err := tx.Model(&schemas.Task{}).Where("id = ?", copies[original.ID]).
+				Update("parent_id", copies[*original.ParentID]).Error; err != nil {
+				return err
+			}
+		}
+
+		for original, duplicate := range copies {
+			if err := tx.Exec("INSERT INTO task_labels (task_id, label_id) SELECT ?, label_id FROM task_labels WHERE task_id = ?",
+				duplicate, original).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("INSERT INTO task_assignees (task_id, user_id) SELECT ?, user_id FROM task_assignees WHERE task_id = ?",
+				duplicate, original).Error; err != nil {
+				return err
+			}
+
+			var blockers []uint
+			if err := tx.Table("task_dependencies").Where("task_id = ?", original).
+				Pluck("blocker_id", &blockers).Error; err != nil {
+				return err
+			}
+			for _, blocker := range blockers {
+				if copied, ok := copies[blocker]; ok {
+					blocker = copied
+				} else if target.ProjectID != source.ProjectID {
+					continue
+				}
+				if err := tx.Exec("INSERT INTO task_dependencies (task_id, blocker_id) VALUES (?, ?)", duplicate, blocker).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if target.ProjectID != source.ProjectID {
+			if err := s.carryTaskRelations(tx, mapValues(copies), source.ProjectID, target.ProjectID); err != nil {
+				return err
+			}
+		}
+
+		created := snapshot(&root)
+		created["copied_from_id"] = task.ID
+		return s.record(tx, schemas.Activity{
+			ProjectID:  target.ProjectID,
+			TaskID:     &root.ID,
+			EntityType: schemas.EntityTask,
+			EntityID:   root.ID,
+			Action:     schemas.ActionCreated,
+		}, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &root, nil
+}
+
+// transferLists returns the list a task is in and the list it is moved or
+// copied to, which defaults to the same list and must belong to the target
+// project.
+func (s *service) transferLists(tx *gorm.DB, listID uint, targetProjectID *uint, targetListID *uint) (*schemas.List, *schemas.List, error) {
+	var source schemas.List
+	if err := tx.First(&source, listID).Error; err != nil {
+		return nil, nil, err
+	}
+	if targetListID == nil {
+		return &source, &source, nil
+	}
+
+	projectID := source.ProjectID
+	if targetProjectID != nil {
+		projectID = *targetProjectID
+	}
+
+	var target schemas.List
+	if err := tx.Where("id = ? AND project_id = ?", *targetListID, projectID).First(&target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrTargetListNotFound
+		}
+		return nil, nil, err
+	}
+	return &source, &target, nil
+}
+
+// carryTaskRelations adapts tasks that now live in another project: labels
+// are swapped for the target project's labels of the same name, created as
+// needed, and assignees who are not members there are removed, as are
+// dependencies on tasks outside the set.
+func (s *service) carryTaskRelations(tx *gorm.DB, taskIDs []uint, fromProjectID uint, toProjectID uint) error {
+	var labels []schemas.Label
+	if err := tx.Where("project_id = ? AND id IN (?)", fromProjectID,
+		tx.Table("task_labels").Select("label_id").Where("task_id IN ?", taskIDs)).
+		Find(&labels).Error; err != nil {
+		return err
+	}
+
+	for _, label := range labels {
+		var targetLabel schemas.Label
+		err := tx.Where("project_id = ? AND name = ?", toProjectID, label.Name).First(&targetLabel).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			targetLabel = schemas.Label{Name: label.Name, Color: label.Color, ProjectID: toProjectID}
+			err = tx.Create(&targetLabel).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Exec("UPDATE task_labels SET label_id = ? WHERE label_id = ? AND task_id IN ?",
+			targetLabel.ID, label.ID, taskIDs).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Exec(`DELETE FROM task_assignees WHERE task_id IN ? AND user_id NOT IN
+		(SELECT user_id FROM project_members WHERE project_id = ? AND deleted_at IS NULL)`,
+		taskIDs, toProjectID).Error; err != nil {
+		return err
+	}
+
+	return tx.Exec(`DELETE FROM task_dependencies WHERE
+		(task_id IN ? AND blocker_id NOT IN ?) OR (blocker_id IN ? AND task_id NOT IN ?)`,
+		taskIDs, taskIDs, taskIDs, taskIDs).Error
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func mapValues(m map[uint]uint) []uint {
+	values := make([]uint, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
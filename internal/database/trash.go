@@ -0,0 +1,499 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/schemas"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrRestoreParentFirst is returned when restoring an item whose list or
+// parent task is still in the trash.
+var ErrRestoreParentFirst = errors.New("the list or parent task of this item is deleted, restore it first")
+
+// Deleting a project or list soft-deletes its children with the same
+// deleted_at, so restoring it brings back exactly the children that were
+// deleted with it and not those deleted separately before. The stored value
+// is compared in SQL rather than round-tripped through time.Time.
+
+// GetTrash returns the deleted lists and tasks of a project, most recently
+// deleted first.
+func (s *service) GetTrash(projectID string) ([]schemas.List, []schemas.Task, error) {
+	var lists []schemas.List
+	if err := s.db.Unscoped().
+		Where("project_id = ? AND deleted_at IS NOT NULL", projectID).
+		Order("deleted_at DESC").Order("id").
+		Find(&lists).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var tasks []schemas.Task
+	if err := s.db.Unscoped().
+		Joins("JOIN lists ON lists.id = tasks.list_id").
+		Where("lists.project_id = ? AND tasks.deleted_at IS NOT NULL", projectID).
+		Order("tasks.deleted_at DESC").Order("tasks.id").
+		Find(&tasks).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return lists, tasks, nil
+}
+
+// GetDeletedProjects returns the deleted projects the user owns.
+func (s *service) GetDeletedProjects(userID uint) ([]schemas.Project, error) {
+	var projects []schemas.Project
+	if err := s.db.Unscoped().
+		Joins("JOIN project_members ON project_members.project_id = projects.id AND project_members.deleted_at IS NULL").
+		Where("project_members.user_id = ? AND project_members.role = ?", userID, schemas.RoleOwner).
+		Where("projects.deleted_at IS NOT NULL").
+		Order("projects.deleted_at DESC").Order("projects.id").
+		Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// RestoreProject restores a deleted project owned by the user, with the
+// lists and tasks deleted along with it.
+func (s *service) RestoreProject(projectID string, userID uint) (*schemas.Project, error) {
+	var project *schemas.Project
+	err := s.transaction(func(tx *gorm.DB) error {
+		var err error
+		project, err = s.findDeletedProject(tx, projectID, userID)
+		if err != nil {
+			return err
+		}
+		deletedAt := tx.Table("projects").Select("deleted_at").Where("id = ?", project.ID)
+
+		lists := tx.Table("lists").Select("id").Where("project_id = ?", project.ID)
+		if err := tx.Table("tasks").
+			Where("list_id IN (?) AND deleted_at = (?)", lists, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("lists").
+			Where("project_id = ? AND deleted_at = (?)", project.ID, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("projects").Where("id = ?", project.ID).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		project.DeletedAt = gorm.DeletedAt{}
+		return s.recordProject(tx, project, schemas.ActionRestored, nil, project)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// RestoreList restores a deleted list with the tasks deleted along with it.
+// Dependencies of those tasks that would now close a cycle are removed.
+func (s *service) RestoreList(projectID string, listID string) (*schemas.List, error) {
+	var list schemas.List
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Where("id = ? AND project_id = ? AND deleted_at IS NOT NULL", listID, projectID).
+			First(&list).Error; err != nil {
+			return err
+		}
+
+		var ids []uint
+		if err := tx.Table("tasks").
+			Where("list_id = ? AND deleted_at = (SELECT deleted_at FROM lists WHERE id = ?)", list.ID, list.ID).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("tasks").Where("id IN ?", ids).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("lists").Where("id = ?", list.ID).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := s.dropCyclicDependencies(tx, projectID, ids); err != nil {
+			return err
+		}
+
+		list.DeletedAt = gorm.DeletedAt{}
+		return s.recordList(tx, &list, schemas.ActionRestored, nil, &list)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+// RestoreTask restores a deleted task with the subtasks deleted along with
+// it. Its list and parent task must not be deleted. Dependencies of the
+// restored tasks that would now close a cycle are removed.
+func (s *service) RestoreTask(projectID string, taskID string) (*schemas.Task, error) {
+	var task *schemas.Task
+	err := s.transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = s.findDeletedTask(tx, projectID, taskID)
+		if err != nil {
+			return err
+		}
+
+		var live int64
+		if err := tx.Model(&schemas.List{}).Where("id = ?", task.ListID).Count(&live).Error; err != nil {
+			return err
+		}
+		if live == 0 {
+			return ErrRestoreParentFirst
+		}
+		if task.ParentID != nil {
+			if err := tx.Model(&schemas.Task{}).Where("id = ?", *task.ParentID).Count(&live).Error; err != nil {
+				return err
+			}
+			if live == 0 {
+				return ErrRestoreParentFirst
+			}
+		}
+
+		ids, err := s.deletedSubtreeIDs(tx, task.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Table("tasks").Where("id IN ?", ids).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := s.dropCyclicDependencies(tx, projectID, ids); err != nil {
+			return err
+		}
+
+		task.DeletedAt = gorm.DeletedAt{}
+		return s.recordTask(tx, projectID, task, schemas.ActionRestored, nil, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// PurgeProject permanently deletes a deleted project owned by the user,
+// with everything in it.
+func (s *service) PurgeProject(projectID string, userID uint) error {
+	return s.transaction(func(tx *gorm.DB) error {
+		project, err := s.findDeletedProject(tx, projectID, userID)
+		if err != nil {
+			return err
+		}
+		if err := purgeProjects(tx, []uint{project.ID}); err != nil {
+			return err
+		}
+		return s.recordProject(tx, project, schemas.ActionPurged, project, nil)
+	})
+}
+
+// PurgeList permanently deletes a deleted list with all of its tasks.
+func (s *service) PurgeList(projectID string, listID string) error {
+	return s.transaction(func(tx *gorm.DB) error {
+		var list schemas.List
+		if err := tx.Unscoped().
+			Where("id = ? AND project_id = ? AND deleted_at IS NOT NULL", listID, projectID).
+			First(&list).Error; err != nil {
+			return err
+		}
+		if err := purgeLists(tx, []uint{list.ID}); err != nil {
+			return err
+		}
+		return s.recordList(tx, &list, schemas.ActionPurged, &list, nil)
+	})
+}
+
+// PurgeTask permanently deletes a deleted task with all of its subtasks.
+func (s *service) PurgeTask(projectID string, taskID string) error {
+	return s.transaction(func(tx *gorm.DB) error {
+		task, err := s.findDeletedTask(tx, projectID, taskID)
+		if err != nil {
+			return err
+		}
+
+		var ids []uint
+		if err := tx.Raw(`
+			WITH RECURSIVE subtree(id) AS (
+				SELECT ?
+				UNION
+				SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id
+			)
+			SELECT id FROM subtree`, task.ID).
+			Scan(&ids).Error; err != nil {
+			return err
+		}
+		if err := purgeTasks(tx, ids); err != nil {
+			return err
+		}
+		return s.recordTask(tx, projectID, task, schemas.ActionPurged, task, nil)
+	})
+}
+
+// EmptyTrash permanently deletes every deleted list and task of a project.
+func (s *service) EmptyTrash(projectID string) error {
+	return s.transaction(func(tx *gorm.DB) error {
+		lists, err := trashedRows(tx, "lists", "project_id = ?", projectID)
+		if err != nil {
+			return err
+		}
+		if err := s.purgeRows(tx, schemas.EntityList, lists, purgeLists); err != nil {
+			return err
+		}
+
+		tasks, err := trashedRows(tx, "tasks", "lists.project_id = ?", projectID)
+		if err != nil {
+			return err
+		}
+		return s.purgeRows(tx, schemas.EntityTask, tasks, purgeTasks)
+	})
+}
+
+// PurgeDeleted permanently deletes the projects, lists and tasks deleted
+// before the cutoff and returns how many of them were removed, counting the
+// lists and tasks of a purged project or list as part of it.
+func (s *service) PurgeDeleted(before time.Time) (int64, error) {
+	before = before.UTC()
+	var purged int64
+	err := s.transaction(func(tx *gorm.DB) error {
+		projects, err := trashedRows(tx, "projects", "projects.deleted_at < ?", before)
+		if err != nil {
+			return err
+		}
+		lists, err := trashedRows(tx, "lists", "lists.deleted_at < ?", before)
+		if err != nil {
+			return err
+		}
+		tasks, err := trashedRows(tx, "tasks", "tasks.deleted_at < ?", before)
+		if err != nil {
+			return err
+		}
+
+		// Purging a project removes its lists, its tasks and its activity
+		// log, so none of them are recorded
+		purgedProjects := make(map[uint]bool, len(projects))
+		projectIDs := make([]uint, len(projects))
+		for i, project := range projects {
+			purgedProjects[project.ID] = true
+			projectIDs[i] = project.ID
+		}
+		if err := purgeProjects(tx, projectIDs); err != nil {
+			return err
+		}
+		inPurgedProject := func(row trashedRow) bool { return purgedProjects[row.ProjectID] }
+		lists = slices.DeleteFunc(lists, inPurgedProject)
+		tasks = slices.DeleteFunc(tasks, inPurgedProject)
+
+		// Likewise the tasks of a purged list go with it
+		purgedLists := make(map[uint]bool, len(lists))
+		for _, list := range lists {
+			purgedLists[list.ID] = true
+		}
+		tasks = slices.DeleteFunc(tasks, func(row trashedRow) bool { return purgedLists[row.ListID] })
+
+		if err := s.purgeRows(tx, schemas.EntityList, lists, purgeLists); err != nil {
+			return err
+		}
+		if err := s.purgeRows(tx, schemas.EntityTask, tasks, purgeTasks); err != nil {
+			return err
+		}
+
+		purged = int64(len(projects) + len(lists) + len(tasks))
+		return nil
+	})
+	return purged, err
+}
+
+func (s *service) findDeletedProject(tx *gorm.DB, projectID string, userID uint) (*schemas.Project, error) {
+	var project schemas.Project
+	if err := tx.Unscoped().
+		Joins("JOIN project_members ON project_members.project_id = projects.id AND project_members.deleted_at IS NULL").
+		Where("projects.id = ? AND projects.deleted_at IS NOT NULL", projectID).
+		Where("project_members.user_id = ? AND project_members.role = ?", userID, schemas.RoleOwner).
+		First(&project).Error; err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (s *service) findDeletedTask(tx *gorm.DB, projectID string, taskID string) (*schemas.Task, error) {
+	var task schemas.Task
+	if err := tx.Unscoped().
+		Joins("JOIN lists ON lists.id = tasks.list_id").
+		Where("tasks.id = ? AND lists.project_id = ? AND tasks.deleted_at IS NOT NULL", taskID, projectID).
+		First(&task).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// deletedSubtreeIDs returns the task and the subtasks below it that were
+// deleted at the same time.
+func (s *service) deletedSubtreeIDs(tx *gorm.DB, taskID uint) ([]uint, error) {
+	var ids []uint
+	if err := tx.Raw(`
+		WITH RECURSIVE subtree(id) AS (
+			SELECT ?
+			UNION
+			SELECT tasks.id FROM tasks
+			JOIN subtree ON tasks.parent_id = subtree.id
+			WHERE tasks.deleted_at = (SELECT deleted_at FROM tasks WHERE id = ?)
+		)
+		SELECT id FROM subtree`, taskID, taskID).
+		Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// trashedRow identifies a deleted project, list or task and the project it
+// belongs to, along with the list of a task.
+type trashedRow struct {
+	ID        uint
+	ProjectID uint
+	ListID    uint
+}
+
+// trashedRows returns the deleted rows of table matching the condition,
+// which may refer to the lists table for tasks.
+func trashedRows(tx *gorm.DB, table string, query string, args ...any) ([]trashedRow, error) {
+	var rows []trashedRow
+	q := tx.Table(table).Where(table+".deleted_at IS NOT NULL").Where(query, args...)
+	switch table {
+	case "projects":
+		q = q.Select("projects.id, projects.id AS project_id")
+	case "lists":
+		q = q.Select("lists.id, lists.project_id")
+	default:
+		q = q.Joins("JOIN lists ON lists.id = tasks.list_id").Select("tasks.id, lists.project_id, tasks.list_id")
+	}
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// purgeRows permanently deletes the rows with purge and records each of them
+// in the activity log of its project. The entry only refers to the purged row
+// by its entity ID, since its own activities are deleted with it.
+func (s *service) purgeRows(tx *gorm.DB, entityType string, rows []trashedRow, purge func(*gorm.DB, []uint) error) error {
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	if err := purge(tx, ids); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		activity := schemas.Activity{
+			ProjectID:  row.ProjectID,
+			EntityType: entityType,
+			EntityID:   row.ID,
+			Action:     schemas.ActionPurged,
+		}
+		if err := s.record(tx, activity, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// softDeleteAt marks the live rows of table matching the condition as
+// deleted at the given time.
+func softDeleteAt(tx *gorm.DB, table string, deletedAt time.Time, query string, args ...any) error {
+	return tx.Table(table).
+		Where(query, args...).
+		Where("deleted_at IS NULL").
+		Update("deleted_at", deletedAt).Error
+}
+
+func purgeProjects(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var lists []uint
+	if err := tx.Table("lists").Where("project_id IN ?", ids).Pluck("id", &lists).Error; err != nil {
+		return err
+	}
+	if err := purgeLists(tx, lists); err != nil {
+		return err
+	}
+
+	if err := tx.Exec("DELETE FROM webhook_deliveries WHERE webhook_id IN (SELECT id FROM webhooks WHERE project_id IN ?)", ids).Error; err != nil {
+		return err
+	}
+
+	for _, table := range []string{"webhooks", "labels", "project_members", "project_status_changes", "activities"} {
+		if err := tx.Exec("DELETE FROM "+table+" WHERE project_id IN ?", ids).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Exec("DELETE FROM projects WHERE id IN ?", ids).Error
+}
+
+func purgeLists(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var tasks []uint
+	if err := tx.Table("tasks").Where("list_id IN ?", ids).Pluck("id", &tasks).Error; err != nil {
+		return err
+	}
+	if err := purgeTasks(tx, tasks); err != nil {
+		return err
+	}
+
+	if err := purgeActivities(tx, "entity_type = ? AND entity_id IN ?", schemas.EntityList, ids); err != nil {
+		return err
+	}
+	return tx.Exec("DELETE FROM lists WHERE id IN ?", ids).Error
+}
+
+// purgeTasks hard-deletes tasks with their join table rows. SQLite does not
+// enforce the foreign keys, so the rows referencing them are removed here.
+func purgeTasks(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := purgeActivities(tx, "task_id IN ?", ids); err != nil {
+		return err
+	}
+
+	statements := []string{
+		"DELETE FROM mentions WHERE task_id IN @ids",
+		"DELETE FROM comment_revisions WHERE comment_id IN (SELECT id FROM comments WHERE task_id IN @ids)",
+		"DELETE FROM comments WHERE task_id IN @ids",
+		"DELETE FROM attachments WHERE task_id IN @ids",
+		"DELETE FROM task_labels WHERE task_id IN @ids",
+		"DELETE FROM task_assignees WHERE task_id IN @ids",
+		"DELETE FROM task_dependencies WHERE task_id IN @ids OR blocker_id IN @ids",
+		"UPDATE tasks SET parent_id = NULL WHERE parent_id IN @ids",
+		"UPDATE tasks SET recurrence_from_id = NULL WHERE recurrence_from_id IN @ids",
+		"DELETE FROM tasks WHERE id IN @ids",
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement, map[string]any{"ids": ids}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeActivities deletes the activities matching the condition along with
+// their webhook deliveries, whose payloads copy them.
+func purgeActivities(tx *gorm.DB, query string, args ...any) error {
+	activities := tx.Table("activities").Select("id").Where(query, args...)
+	if err := tx.Where("activity_id IN (?)", activities).Delete(&schemas.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	return tx.Where(query, args...).Delete(&schemas.Activity{}).Error
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"errors"
+	"go-tasker/internal/auth"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUsernameTaken = errors.New("username or email is already taken")
+	ErrAPIKeyExpired = errors.New("api key has expired")
+)
+
+func (s *service) CreateUser(payload types.RegisterPayload) (*schemas.User, error) {
+	var count int64
+	if err := s.db.Model(&schemas.User{}).
+		Where("username = ? OR email = ?", payload.Username, payload.Email).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrUsernameTaken
+	}
+
+	hash, err := auth.HashPassword(payload.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := schemas.User{
+		Username:     payload.Username,
+		Email:        payload.Email,
+		PasswordHash: hash,
+	}
+
+	if err := s.db.Create(&user).Error; err != nil {
+		// Another registration may take the name between the check above
+		// and the insert
+		if isDuplicateKey(s.db, err) {
+			return nil, ErrUsernameTaken
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// isDuplicateKey reports whether err is a unique constraint violation.
+func isDuplicateKey(db *gorm.DB, err error) bool {
+	translator, ok := db.Dialector.(gorm.ErrorTranslator)
+	return ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
+}
+
+func (s *service) GetUser(userID uint) (*schemas.User, error) {
+	var user schemas.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *service) GetUserByUsername(username string) (*schemas.User, error) {
+	var user schemas.User
+	if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// apiKeyUseResolution is how stale the last use of an API key can get
+// before it is recorded again.
+const apiKeyUseResolution = time.Minute
+
+// GetUserByAPIKey resolves the owner of an API key and records its use.
+func (s *service) GetUserByAPIKey(key string) (*schemas.User, error) {
+	var apiKey schemas.APIKey
+	if err := s.db.Preload("User").Where("key_hash = ?", auth.HashAPIKey(key)).First(&apiKey).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	// Recording every use would make each request a write competing for
+	// the SQLite write lock, so recent uses are not recorded again
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyUseResolution {
+		if err := s.db.Model(&apiKey).UpdateColumn("last_used_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if apiKey.User.ID == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &apiKey.User, nil
+}
+
+func (s *service) GetAPIKeys(userID uint) ([]schemas.APIKey, error) {
+	var apiKeys []schemas.APIKey
+	if err := s.db.Where("user_id = ?", userID).Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// CreateAPIKey stores a new API key for the user and returns it together with
+// the plaintext key, which is not recoverable afterwards.
+func (s *service) CreateAPIKey(userID uint, payload types.CreateAPIKeyPayload) (*schemas.APIKey, string, error) {
+	key, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	apiKey := schemas.APIKey{
+		Name:      payload.Name,
+		Prefix:    key[:len(auth.APIKeyPrefix)+6],
+		KeyHash:   auth.HashAPIKey(key),
+		ExpiresAt: payload.ExpiresAt,
+		UserID:    userID,
+	}
+
+	if err := s.db.Create(&apiKey).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &apiKey, key, nil
+}
+
+func (s *service) DeleteAPIKey(userID uint, apiKeyID string) error {
+	var apiKey schemas.APIKey
+	if err := s.db.Where("id = ? AND user_id = ?", apiKeyID, userID).First(&apiKey).Error; err != nil {
+		return err
+	}
+
+	if err := s.db.Unscoped().Delete(&apiKey).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
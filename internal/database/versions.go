@@ -0,0 +1,42 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrVersionMismatch = errors.New("the resource has changed since the version you have")
+
+// IfMatch returns a Service whose updates and deletes of projects, lists
+// and tasks only apply while the entity is still at version.
+func (s *service) IfMatch(version uint) Service {
+	scoped := *s
+	scoped.version = &version
+	return &scoped
+}
+
+// bumpVersion increments the version of a project, list or task and returns
+// the new one. When a version is expected it is checked by the same
+// statement, so of two clients holding the same version only the first to
+// write succeeds.
+func (s *service) bumpVersion(tx *gorm.DB, table string, id uint) (uint, error) {
+	update := tx.Table(table).Where("id = ?", id)
+	if s.version != nil {
+		update = update.Where("version = ?", *s.version)
+	}
+
+	result := update.Update("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrVersionMismatch
+	}
+
+	var version uint
+	if err := tx.Table(table).Select("version").Where("id = ?", id).Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	return version, nil
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"go-tasker/internal/webhook"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var webhookDeliverySortColumns = map[string]sortColumn[schemas.WebhookDelivery]{
+	"id": {"webhook_deliveries.id", func(d schemas.WebhookDelivery) any { return d.ID }},
+}
+
+func (s *service) GetWebhooks(projectID string) ([]schemas.Webhook, error) {
+	var webhooks []schemas.Webhook
+	if err := s.db.Where("project_id = ?", projectID).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (s *service) GetWebhook(projectID string, webhookID string) (*schemas.Webhook, error) {
+	var hook schemas.Webhook
+	if err := s.db.Where("id = ? AND project_id = ?", webhookID, projectID).First(&hook).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// CreateWebhook registers a webhook with a new signing secret. The secret is
+// only ever returned here, so it must be shown to the user.
+func (s *service) CreateWebhook(projectID string, payload types.CreateWebhookPayload) (*schemas.Webhook, error) {
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	hook := schemas.Webhook{
+		ProjectID: parseID(projectID),
+		URL:       payload.URL,
+		Events:    payload.Events,
+		Secret:    secret,
+		Active:    payload.Active == nil || *payload.Active,
+	}
+	if err := s.db.Omit("Project").Create(&hook).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (s *service) UpdateWebhook(projectID string, webhookID string, payload types.UpdateWebhookPayload) (*schemas.Webhook, error) {
+	hook, err := s.GetWebhook(projectID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	hook.URL = payload.URL
+	hook.Events = payload.Events
+	hook.Active = payload.Active
+	if err := s.db.Model(hook).Select("url", "events", "active").Updates(hook).Error; err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// DeleteWebhook removes a webhook. Its pending deliveries are not sent.
+func (s *service) DeleteWebhook(projectID string, webhookID string) error {
+	hook, err := s.GetWebhook(projectID, webhookID)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(hook).Error
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook, newest first.
+func (s *service) GetWebhookDeliveries(projectID string, webhookID string, page types.PageQuery) ([]schemas.WebhookDelivery, string, error) {
+	hook, err := s.GetWebhook(projectID, webhookID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tx := s.db.Model(&schemas.WebhookDelivery{}).Where("webhook_deliveries.webhook_id = ?", hook.ID)
+	return paginate(tx, "webhook_deliveries", "-id", webhookDeliverySortColumns, page,
+		func(d schemas.WebhookDelivery) uint { return d.ID })
+}
+
+// RedeliverWebhookDelivery queues a new delivery of the same event as an
+// earlier delivery, to be sent right away.
+func (s *service) RedeliverWebhookDelivery(projectID string, webhookID string, deliveryID string) (*schemas.WebhookDelivery, error) {
+	hook, err := s.GetWebhook(projectID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	var original schemas.WebhookDelivery
+	if err := s.db.Where("id = ? AND webhook_id = ?", deliveryID, hook.ID).First(&original).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	delivery := schemas.WebhookDelivery{
+		WebhookID:      hook.ID,
+		ActivityID:     original.ActivityID,
+		RedeliveryOfID: &original.ID,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         schemas.DeliveryPending,
+		NextAttemptAt:  &now,
+	}
+	if err := s.db.Omit("Webhook").Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries that are due,
+// with their webhooks loaded. Their next attempt is pushed back by lease so
+// they are not claimed again while they are being sent.
+func (s *service) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]schemas.WebhookDelivery, error) {
+	var deliveries []schemas.WebhookDelivery
+	err := s.transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		if err := tx.Preload("Webhook").
+			Joins("JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id AND webhooks.deleted_at IS NULL AND webhooks.active = ?", true).
+			Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", schemas.DeliveryPending, now).
+			Order("webhook_deliveries.next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&schemas.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// SaveWebhookDeliveryAttempt stores the outcome of an attempt to send a
+// delivery.
+func (s *service) SaveWebhookDeliveryAttempt(delivery *schemas.WebhookDelivery) error {
+	return s.db.Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "response_status", "error", "delivered_at").
+		Updates(delivery).Error
+}
+
+// enqueueWebhooks queues a delivery of the activity to every active webhook
+// of its project that subscribes to it. It runs in the transaction of the
+// change, so events are only sent for changes that were committed.
+func (s *service) enqueueWebhooks(tx *gorm.DB, activity *schemas.Activity) error {
+	var hooks []schemas.Webhook
+	if err := tx.Where("project_id = ? AND active = ?", activity.ProjectID, true).Find(&hooks).Error; err != nil {
+		return err
+	}
+
+	event := webhook.Event(activity.EntityType, activity.Action)
+	var payload []byte
+	for _, hook := range hooks {
+		if !webhook.Matches(hook.Events, event) {
+			continue
+		}
+
+		if payload == nil {
+			var err error
+			if payload, err = activityPayload(activity); err != nil {
+				return err
+			}
+		}
+
+		delivery := schemas.WebhookDelivery{
+			WebhookID:     hook.ID,
+			ActivityID:    &activity.ID,
+			Event:         event,
+			Payload:       payload,
+			Status:        schemas.DeliveryPending,
+			NextAttemptAt: &activity.CreatedAt,
+		}
+		if err := tx.Omit("Webhook").Create(&delivery).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Package events fans the changes made to projects out to the clients
+// following them in real time.
+package events
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is dropped. Dropped clients reconnect and catch up from the replay buffer.
+const subscriberBuffer = 64
+
+// Event is a change to a project. Name is the event name, such as
+// task.created, and Data its JSON payload.
+type Event struct {
+	ID        string
+	ProjectID uint
+	Name      string
+	Data      []byte
+
+	seq uint64
+}
+
+// Subscription receives the events of a project on C until it is closed,
+// either by Unsubscribe, by Revoke or because the subscriber fell too far
+// behind.
+type Subscription struct {
+	C <-chan Event
+
+	c         chan Event
+	projectID uint
+	userID    uint
+}
+
+// Hub is an in-process publish/subscribe hub. It keeps the most recent
+// events so reconnecting clients can replay the ones they missed.
+type Hub struct {
+	mu          sync.Mutex
+	epoch       string
+	seq         uint64
+	size        int
+	buffer      []Event
+	subscribers map[*Subscription]struct{}
+}
+
+// NewHub returns a hub keeping the last size events for replay. Event IDs
+// carry the start time of the hub, so IDs handed out before a restart are
+// recognised as unknown rather than mistaken for recent ones.
+func NewHub(size int) *Hub {
+	return &Hub{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:        size,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event to the subscribers of its project.
+func (h *Hub) Publish(projectID uint, name string, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	event := Event{
+		ID:        h.epoch + "-" + strconv.FormatUint(h.seq, 10),
+		ProjectID: projectID,
+		Name:      name,
+		Data:      data,
+		seq:       h.seq,
+	}
+
+	h.buffer = append(h.buffer, event)
+	if len(h.buffer) > h.size {
+		h.buffer = h.buffer[len(h.buffer)-h.size:]
+	}
+
+	for sub := range h.subscribers {
+		if sub.projectID != projectID {
+			continue
+		}
+		select {
+		case sub.c <- event:
+		default:
+			h.drop(sub)
+		}
+	}
+}
+
+// Subscribe follows the events of a project on behalf of a user. When
+// lastEventID is set, the events of the project published after it are
+// returned for replay; ok is false when some of them are no longer buffered,
+// or the ID is unknown, and the client has to reload its state instead.
+func (h *Hub) Subscribe(projectID uint, userID uint, lastEventID string) (sub *Subscription, replay []Event, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: c, c: c, projectID: projectID, userID: userID}
+	h.subscribers[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+
+	epoch, seqStr, _ := strings.Cut(lastEventID, "-")
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || epoch != h.epoch || seq > h.seq {
+		return sub, nil, false
+	}
+	if len(h.buffer) > 0 && seq+1 < h.buffer[0].seq {
+		return sub, nil, false
+	}
+
+	for _, event := range h.buffer {
+		if event.seq > seq && event.ProjectID == projectID {
+			replay = append(replay, event)
+		}
+	}
+	return sub, replay, true
+}
+
+// Unsubscribe stops a subscription and closes its channel.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.drop(sub)
+}
+
+// Revoke closes the subscriptions of a user to a project once they may no
+// longer follow it, or those of every user when userID is 0.
+func (h *Hub) Revoke(projectID uint, userID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if sub.projectID == projectID && (userID == 0 || sub.userID == userID) {
+			h.drop(sub)
+		}
+	}
+}
+
+func (h *Hub) drop(sub *Subscription) {
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.c)
+	}
+}
@@ -0,0 +1,379 @@
+// Package recurrence implements the subset of iCalendar (RFC 5545)
+// recurrence rules used by recurring tasks: FREQ, INTERVAL, COUNT, UNTIL,
+// BYDAY, BYMONTHDAY, BYMONTH and WKST.
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxEmptyYears bounds the search for occurrences, so rules that can never
+// match (BYMONTHDAY=31 with BYMONTH=2) fail instead of looping forever. The
+// Gregorian calendar repeats every 400 years, so sparse rules such as a
+// DAILY one on February 29 still find every occurrence.
+const maxEmptyYears = 400
+
+// WeekdayNum is a BYDAY entry such as MO, 2TU or -1FR. N is zero when the
+// entry has no ordinal.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Parse parses a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH". An
+// optional "RRULE:" prefix is accepted.
+func Parse(rule string) (*Rule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, fmt.Errorf("empty rule")
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		name = strings.ToUpper(name)
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate rule part %s", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			if !slices.Contains([]Frequency{Daily, Weekly, Monthly, Yearly}, r.Freq) {
+				err = fmt.Errorf("unsupported FREQ %s", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parsePositive(name, value)
+		case "COUNT":
+			r.Count, err = parsePositive(name, value)
+		case "UNTIL":
+			var until time.Time
+			until, err = parseUntil(value)
+			r.Until = &until
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseIntList(name, value, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(name, value, 1, 12)
+			for _, month := range months {
+				r.ByMonth = append(r.ByMonth, time.Month(month))
+			}
+		case "WKST":
+			day, ok := weekdays[strings.ToUpper(value)]
+			if !ok {
+				err = fmt.Errorf("invalid WKST %s", value)
+			}
+			r.WeekStart = day
+		default:
+			err = fmt.Errorf("unsupported rule part %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("COUNT and UNTIL cannot both be set")
+	}
+	if r.Freq == Weekly || r.Freq == Daily {
+		for _, day := range r.ByDay {
+			if day.N != 0 {
+				return nil, fmt.Errorf("BYDAY ordinals are only allowed with MONTHLY or YEARLY")
+			}
+		}
+	}
+	if r.Freq == Yearly && len(r.ByDay) > 0 && len(r.ByMonth) == 0 {
+		return nil, fmt.Errorf("BYDAY with YEARLY requires BYMONTH")
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return nil, fmt.Errorf("BYMONTHDAY is not allowed with WEEKLY")
+	}
+
+	return r, nil
+}
+
+// Valid reports whether rule parses.
+func Valid(rule string) bool {
+	_, err := Parse(rule)
+	return err == nil
+}
+
+// After returns up to n occurrences of the rule starting at dtstart that
+// fall strictly after after. Fewer are returned once COUNT or UNTIL ends
+// the series.
+func (r *Rule) After(dtstart time.Time, after time.Time, n int) []time.Time {
+	occurrences := []time.Time{}
+	emitted := 0
+	lastMatch := dtstart
+
+	for period := 0; len(occurrences) < n; period++ {
+		start := r.periodStart(dtstart, period)
+		if start.After(lastMatch.AddDate(maxEmptyYears, 0, 0)) {
+			break
+		}
+
+		candidates := r.candidates(dtstart, period)
+		if len(candidates) == 0 {
+			continue
+		}
+		lastMatch = start
+
+		for _, candidate := range candidates {
+			// DTSTART is always the first occurrence of the series
+			if candidate.Before(dtstart) {
+				continue
+			}
+			if r.Until != nil && candidate.After(*r.Until) {
+				return occurrences
+			}
+			if r.Count > 0 && emitted >= r.Count {
+				return occurrences
+			}
+			emitted++
+
+			if candidate.After(after) {
+				occurrences = append(occurrences, candidate)
+				if len(occurrences) == n {
+					break
+				}
+			}
+		}
+	}
+
+	return occurrences
+}
+
+// candidates returns the sorted occurrences in the period-th interval after
+// dtstart, before COUNT and UNTIL are applied.
+// periodStart returns roughly when the given period of the rule begins.
+func (r *Rule) periodStart(dtstart time.Time, period int) time.Time {
+	step := period * r.Interval
+	switch r.Freq {
+	case Daily:
+		return dtstart.AddDate(0, 0, step)
+	case Weekly:
+		return dtstart.AddDate(0, 0, 7*step)
+	case Monthly:
+		return dtstart.AddDate(0, step, 0)
+	default:
+		return dtstart.AddDate(step, 0, 0)
+	}
+}
+
+func (r *Rule) candidates(dtstart time.Time, period int) []time.Time {
+	step := period * r.Interval
+	var days []time.Time
+
+	switch r.Freq {
+	case Daily:
+		day := dtstart.AddDate(0, 0, step)
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := dtstart.AddDate(0, 0, 7*step-offset)
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && day.Weekday() != dtstart.Weekday() {
+				continue
+			}
+			if r.matchesMonth(day) && r.matchesWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		month := time.Date(dtstart.Year(), dtstart.Month()+time.Month(step), 1,
+			dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+		if r.matchesMonth(month) {
+			days = r.daysInMonth(dtstart, month)
+		}
+	case Yearly:
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{dtstart.Month()}
+		}
+		for _, m := range months {
+			month := time.Date(dtstart.Year()+step, m, 1,
+				dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+			days = append(days, r.daysInMonth(dtstart, month)...)
+		}
+	}
+
+	slices.SortFunc(days, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(days, func(a, b time.Time) bool { return a.Equal(b) })
+}
+
+// daysInMonth expands BYMONTHDAY and BYDAY within the month starting at
+// first. Without either, the day of month of dtstart is used, and months
+// lacking that day are skipped as RFC 5545 requires.
+func (r *Rule) daysInMonth(dtstart time.Time, first time.Time) []time.Time {
+	length := first.AddDate(0, 1, -1).Day()
+
+	var days []time.Time
+	for d := 1; d <= length; d++ {
+		day := first.AddDate(0, 0, d-1)
+		switch {
+		case len(r.ByMonthDay) == 0 && len(r.ByDay) == 0:
+			if d != dtstart.Day() {
+				continue
+			}
+		case !r.matchesMonthDay(day):
+			continue
+		case !r.matchesWeekdayInMonth(day, length):
+			continue
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+func (r *Rule) matchesMonth(day time.Time) bool {
+	return len(r.ByMonth) == 0 || slices.Contains(r.ByMonth, day.Month())
+}
+
+func (r *Rule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	length := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.ByMonthDay {
+		if md == day.Day() || (md < 0 && length+md+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekdayInMonth checks BYDAY with ordinals, where 2TU is the second
+// Tuesday of the month and -1FR the last Friday.
+func (r *Rule) matchesWeekdayInMonth(day time.Time, length int) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	fromStart := (day.Day()-1)/7 + 1
+	fromEnd := -((length-day.Day())/7 + 1)
+	for _, wd := range r.ByDay {
+		if wd.Weekday != day.Weekday() {
+			continue
+		}
+		if wd.N == 0 || wd.N == fromStart || wd.N == fromEnd {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePositive(name string, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// A date-only UNTIL includes that whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("UNTIL must be a UTC date-time such as 20240131T000000Z or a date such as 20240131")
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, entry := range strings.Split(strings.ToUpper(value), ",") {
+		if len(entry) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %s", entry)
+		}
+		day, ok := weekdays[entry[len(entry)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %s", entry)
+		}
+
+		n := 0
+		if ordinal := entry[:len(entry)-2]; ordinal != "" {
+			var err error
+			n, err = strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("invalid BYDAY %s", entry)
+			}
+		}
+		days = append(days, WeekdayNum{Weekday: day, N: n})
+	}
+	return days, nil
+}
+
+func parseIntList(name string, value string, lo int, hi int) ([]int, error) {
+	var values []int
+	for _, entry := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(entry)
+		if err != nil || n < lo || n > hi || n == 0 {
+			return nil, fmt.Errorf("invalid %s %s", name, entry)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/internal/database"
+	"go-tasker/schemas"
+	"go-tasker/utils"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// requireProjectRole wraps a handler for a route under
+// /projects/{projectID} and only calls it when the current user holds at
+// least the given role in that project. Non-members get 404 so project IDs
+// cannot be probed, and members with a lesser role get 403.
+func (s *Server) requireProjectRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := auth.UserFromContext(r.Context())
+		if user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("authentication required"))
+			return
+		}
+
+		if !s.checkProjectRole(w, r.PathValue("projectID"), user.ID, role) {
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// checkProjectRole reports whether the user holds at least role in the
+// project. Otherwise it writes the same 404 or 403 response as
+// requireProjectRole and returns false.
+func (s *Server) checkProjectRole(w http.ResponseWriter, projectID string, userID uint, role string) bool {
+	memberRole, err := s.db.GetProjectRole(projectID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+			return false
+		}
+		utils.WriteInternalServerError(w, err)
+		return false
+	}
+
+	if !schemas.RoleAllows(memberRole, role) {
+		utils.WriteError(w, http.StatusForbidden,
+			fmt.Errorf("this action requires the %s role in the project", role))
+		return false
+	}
+
+	return true
+}
+
+// dbAs returns the database service acting as the current user, so the
+// changes it makes are attributed to them in the activity log.
+func (s *Server) dbAs(r *http.Request) database.Service {
+	if user := auth.UserFromContext(r.Context()); user != nil {
+		return s.db.As(user.ID)
+	}
+	return s.db
+}
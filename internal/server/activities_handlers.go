@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/database"
+	"go-tasker/utils"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetProjectActivitiesHandler godoc
+// @Summary Get the activity feed of a project
+// @Description Get a page of the changes made to a project and everything in it, newest first. Each entry names the actor, the entity and action, and the fields that changed before and after.
+// @Tags activities
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param entity_type query string false "Filter by entity type: project, list, task, label, member"
+// @Param action query string false "Filter by action, e.g. created, updated, deleted, done"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/activities [get]
+func (s *Server) GetProjectActivitiesHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	query, err := parseActivityQuery(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.ValidateStruct(w, query); err != nil {
+		return
+	}
+
+	activities, nextCursor, err := s.db.GetProjectActivities(projectID, query)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidCursor) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithPagination("Activities retrieved successfully", activities, nextCursor)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetTaskActivitiesHandler godoc
+// @Summary Get the activity feed of a task
+// @Description Get a page of the changes made to a task, newest first. The history of deleted tasks can still be read.
+// @Tags activities
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param action query string false "Filter by action, e.g. updated, done, assigned"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/activities [get]
+func (s *Server) GetTaskActivitiesHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	query, err := parseActivityQuery(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.ValidateStruct(w, query); err != nil {
+		return
+	}
+
+	activities, nextCursor, err := s.db.GetTaskActivities(projectID, listID, taskID, query)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		if errors.Is(err, database.ErrInvalidCursor) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithPagination("Activities retrieved successfully", activities, nextCursor)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/internal/database"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// PostTaskAssigneesHandler godoc
+// @Summary Assign a user to a task
+// @Description Add a project member to the assignees of a task
+// @Tags assignees
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param assignee body types.AssignTaskPayload true "Assign Task Payload"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/assignees [post]
+func (s *Server) PostTaskAssigneesHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	var assignTaskPayload types.AssignTaskPayload
+	if err := utils.ParseAndValidateJSON(w, r, &assignTaskPayload); err != nil {
+		return
+	}
+
+	task, err := s.dbAs(r).AssignTask(projectID, listID, taskID, assignTaskPayload.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		if errors.Is(err, database.ErrNotProjectMember) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Task assigned successfully", task)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteTaskAssigneeHandler godoc
+// @Summary Unassign a user from a task
+// @Description Remove a user from the assignees of a task
+// @Tags assignees
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param userID path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/assignees/{userID} [delete]
+func (s *Server) DeleteTaskAssigneeHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+	userID := r.PathValue("userID")
+
+	task, err := s.dbAs(r).UnassignTask(projectID, listID, taskID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("assignment not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Task unassigned successfully", task)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetMyTasksHandler godoc
+// @Summary Get my open tasks
+// @Description Get the open tasks assigned to the current user across all projects, grouped by project and list
+// @Tags assignees
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/me/tasks [get]
+func (s *Server) GetMyTasksHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	tasks, err := s.db.GetAssignedTasks(user.ID)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	// Tasks arrive ordered by project and list, so groups can be built by
+	// appending whenever the project or list changes.
+	projects := []map[string]interface{}{}
+	var lists []map[string]interface{}
+	for i, task := range tasks {
+		if i == 0 || task.List.ProjectID != tasks[i-1].List.ProjectID {
+			lists = []map[string]interface{}{}
+			projects = append(projects, map[string]interface{}{
+				"project_id":    task.List.ProjectID,
+				"project_title": task.List.Project.Title,
+			})
+		}
+		if i == 0 || task.ListID != tasks[i-1].ListID {
+			lists = append(lists, map[string]interface{}{
+				"list_id":    task.ListID,
+				"list_title": task.List.Title,
+				"tasks":      []interface{}{},
+			})
+		}
+
+		list := lists[len(lists)-1]
+		list["tasks"] = append(list["tasks"].([]interface{}), map[string]interface{}{
+			"id":         task.ID,
+			"title":      task.Title,
+			"priority":   task.Priority,
+			"start_at":   task.StartAt,
+			"due_at":     task.DueAt,
+			"created_at": task.CreatedAt,
+			"updated_at": task.UpdatedAt,
+		})
+		projects[len(projects)-1]["lists"] = lists
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Assigned tasks retrieved successfully",
+		"data":    projects,
+	})
+}
@@ -0,0 +1,305 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/schemas"
+	"go-tasker/utils"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"gorm.io/gorm"
+)
+
+const (
+	// attachmentTransferTimeout replaces the server's read and write
+	// timeouts while a file is uploaded or downloaded
+	attachmentTransferTimeout = 10 * time.Minute
+
+	// multipartOverhead allows for the part headers and boundaries around
+	// the uploaded file
+	multipartOverhead = 64 << 10
+)
+
+var errAttachmentTooLarge = errors.New("file is too large")
+
+// GetAttachmentsHandler godoc
+// @Summary Get the attachments of a task
+// @Description Get the files attached to a task, oldest first
+// @Tags attachments
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/attachments [get]
+func (s *Server) GetAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	attachments, err := s.db.GetAttachments(projectID, listID, taskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Attachments retrieved successfully", attachments)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostAttachmentsHandler godoc
+// @Summary Attach a file to a task
+// @Description Upload a file as the "file" field of a multipart/form-data body. Its type is detected from its content, and identical files are stored once.
+// @Tags attachments
+// @Accept mpfd
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/attachments [post]
+func (s *Server) PostAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	// Not every ResponseWriter supports deadlines, in which case the
+	// server's timeouts apply
+	_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(attachmentTransferTimeout))
+	r.Body = http.MaxBytesReader(w, r.Body, s.attachmentMaxSize+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("expected a multipart/form-data body"))
+		return
+	}
+
+	var part io.Reader
+	var filename string
+	for part == nil {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing file field"))
+			return
+		}
+		if err != nil {
+			writeUploadError(w, err)
+			return
+		}
+		if p.FormName() == "file" {
+			part, filename = p, p.FileName()
+		}
+	}
+	if filename == "" || filename == "." || filename == "/" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("file field must carry a filename"))
+		return
+	}
+
+	file, hash, size, err := s.spoolUpload(part)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if size == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("file is empty"))
+		return
+	}
+
+	contentType, err := mimetype.DetectReader(file)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	attachment := schemas.Attachment{
+		UploaderID:  auth.UserFromContext(r.Context()).ID,
+		Filename:    filename,
+		ContentType: contentType.String(),
+		Size:        size,
+		Hash:        hash,
+	}
+
+	err = s.dbAs(r).CreateAttachment(projectID, listID, taskID, &attachment, func() error {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return s.store.Put(r.Context(), hash, file, size)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Attachment uploaded successfully", attachment)
+
+	utils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// GetAttachmentHandler godoc
+// @Summary Download an attachment
+// @Description Download the content of a file attached to a task
+// @Tags attachments
+// @Produce octet-stream
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param attachmentID path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/attachments/{attachmentID} [get]
+func (s *Server) GetAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+	attachmentID := r.PathValue("attachmentID")
+
+	attachment, err := s.db.GetAttachment(projectID, listID, taskID, attachmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("attachment not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	content, err := s.store.Get(r.Context(), attachment.Hash)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+	defer content.Close()
+
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(attachmentTransferTimeout))
+
+	// The content is served as a download with the detected type, so
+	// browsers neither render it inline nor guess another type
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("sending attachment %d: %v", attachment.ID, err)
+	}
+}
+
+// DeleteAttachmentHandler godoc
+// @Summary Delete an attachment
+// @Description Remove a file from a task. Its content is deleted once no other attachment shares it.
+// @Tags attachments
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param attachmentID path string true "Attachment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/attachments/{attachmentID} [delete]
+func (s *Server) DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+	attachmentID := r.PathValue("attachmentID")
+
+	err := s.dbAs(r).DeleteAttachment(projectID, listID, taskID, attachmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("attachment not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	s.deleteUnusedBlobs()
+
+	response := utils.PrepareJSONWithMessage("Attachment deleted successfully", nil)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// spoolUpload copies an uploaded file to a temporary file, hashing it on the
+// way, and returns it rewound to its start with its hex SHA-256 hash and
+// size. Files larger than the attachment size limit are rejected.
+func (s *Server) spoolUpload(upload io.Reader) (*os.File, string, int64, error) {
+	file, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(upload, s.attachmentMaxSize+1))
+	if err == nil && size > s.attachmentMaxSize {
+		err = errAttachmentTooLarge
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, "", 0, err
+	}
+
+	return file, hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// deleteUnusedBlobs removes the content no attachment refers to any more
+// from the blob store. Failures are only logged, as the next run retries.
+func (s *Server) deleteUnusedBlobs() {
+	_, err := s.db.DeleteUnusedBlobs(func(hash string) error {
+		return s.store.Delete(context.Background(), hash)
+	})
+	if err != nil {
+		log.Printf("deleting unused attachment content: %v", err)
+	}
+}
+
+func writeUploadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, errAttachmentTooLarge) || errors.As(err, &maxBytesErr) {
+		utils.WriteError(w, http.StatusRequestEntityTooLarge, errAttachmentTooLarge)
+		return
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+	utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid multipart body: %w", err))
+}
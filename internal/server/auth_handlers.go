@@ -0,0 +1,224 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/internal/database"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RegisterHandler godoc
+// @Summary Register a new user
+// @Description Create a user account that can log in and create API keys
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body types.RegisterPayload true "Register Payload"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/auth/register [post]
+func (s *Server) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	var registerPayload types.RegisterPayload
+	if err := utils.ParseAndValidateJSON(w, r, &registerPayload); err != nil {
+		return
+	}
+
+	user, err := s.db.CreateUser(registerPayload)
+	if err != nil {
+		if errors.Is(err, database.ErrUsernameTaken) {
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("User registered successfully", user)
+
+	utils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// LoginHandler godoc
+// @Summary Log in
+// @Description Exchange a username and password for a signed bearer token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body types.LoginPayload true "Login Payload"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/auth/login [post]
+func (s *Server) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var loginPayload types.LoginPayload
+	if err := utils.ParseAndValidateJSON(w, r, &loginPayload); err != nil {
+		return
+	}
+
+	user, err := s.db.GetUserByUsername(loginPayload.Username)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+	if user == nil {
+		auth.RejectPassword(loginPayload.Password)
+	}
+	if user == nil || !auth.CheckPassword(user.PasswordHash, loginPayload.Password) {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid username or password"))
+		return
+	}
+
+	token, expiresAt, err := s.tokens.Issue(user.ID, time.Now())
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Logged in successfully",
+		"data": map[string]interface{}{
+			"token":      token,
+			"token_type": "Bearer",
+			"expires_at": expiresAt.UTC(),
+		},
+	})
+}
+
+// PostStreamTokenHandler godoc
+// @Summary Get a stream token
+// @Description Get a token that opens event streams when passed as the access_token query parameter, for browsers that cannot set the Authorization header on them. It expires after a minute and is refused by every other route.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/auth/stream-token [post]
+func (s *Server) PostStreamTokenHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	token, expiresAt, err := s.tokens.IssueScoped(user.ID, auth.StreamScope, streamTokenTTL, time.Now())
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "Stream token created successfully",
+		"data": map[string]interface{}{
+			"token":      token,
+			"expires_at": expiresAt.UTC(),
+		},
+	})
+}
+
+// GetMeHandler godoc
+// @Summary Get the current user
+// @Description Get the authenticated user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/me [get]
+func (s *Server) GetMeHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	response := utils.PrepareJSONWithMessage("User retrieved successfully", user)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetAPIKeysHandler godoc
+// @Summary Get the current user's API keys
+// @Description Get the API keys of the authenticated user. Key values are never returned.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/me/api-keys [get]
+func (s *Server) GetAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	apiKeys, err := s.db.GetAPIKeys(user.ID)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("API keys retrieved successfully", apiKeys)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostAPIKeysHandler godoc
+// @Summary Create an API key
+// @Description Create a long-lived API key for scripts. The key is only returned once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param apiKey body types.CreateAPIKeyPayload true "Create API Key Payload"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/me/api-keys [post]
+func (s *Server) PostAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	var createAPIKeyPayload types.CreateAPIKeyPayload
+	if err := utils.ParseAndValidateJSON(w, r, &createAPIKeyPayload); err != nil {
+		return
+	}
+
+	apiKey, key, err := s.db.CreateAPIKey(user.ID, createAPIKeyPayload)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("API key created successfully", apiKey)
+	response["data"].(map[string]interface{})["key"] = key
+
+	utils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// DeleteAPIKeyHandler godoc
+// @Summary Revoke an API key
+// @Description Permanently revoke one of the authenticated user's API keys
+// @Tags auth
+// @Security BearerAuth
+// @Param id path string true "API Key ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/me/api-keys/{id} [delete]
+func (s *Server) DeleteAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	err := s.db.DeleteAPIKey(user.ID, r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("api key not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("API key revoked successfully", nil)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/database"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// PostProjectTasksBulkHandler godoc
+// @Summary Change many tasks of a project at once
+// @Description Apply one operation (done, undone, rename, move or delete) to the tasks of a project picked by task_ids or a filter, in a single transaction. Each task succeeds or fails on its own; with atomic=true nothing is changed unless every task succeeds.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param bulk body types.BulkTaskPayload true "Bulk Task Payload"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/tasks/bulk [post]
+func (s *Server) PostProjectTasksBulkHandler(w http.ResponseWriter, r *http.Request) {
+	s.bulkUpdateTasks(w, r, "")
+}
+
+// PostListTasksBulkHandler godoc
+// @Summary Change many tasks of a list at once
+// @Description Apply one operation (done, undone, rename, move or delete) to the tasks of a list picked by task_ids or a filter, in a single transaction. Each task succeeds or fails on its own; with atomic=true nothing is changed unless every task succeeds.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param bulk body types.BulkTaskPayload true "Bulk Task Payload"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/bulk [post]
+func (s *Server) PostListTasksBulkHandler(w http.ResponseWriter, r *http.Request) {
+	s.bulkUpdateTasks(w, r, r.PathValue("listID"))
+}
+
+func (s *Server) bulkUpdateTasks(w http.ResponseWriter, r *http.Request, listID string) {
+	projectID := r.PathValue("projectID")
+
+	var bulkTaskPayload types.BulkTaskPayload
+	if err := utils.ParseAndValidateJSON(w, r, &bulkTaskPayload); err != nil {
+		return
+	}
+
+	results, err := s.dbAs(r).BulkUpdateTasks(projectID, listID, bulkTaskPayload)
+	if err != nil && !errors.Is(err, database.ErrBulkFailed) {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("list not found"))
+			return
+		}
+		if errors.Is(err, database.ErrTooManyTasks) || errors.Is(err, database.ErrTargetListNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	rolledBack := err != nil
+	resultsJSON := []interface{}{}
+	succeeded, failed := 0, 0
+	for _, result := range results {
+		resultJSON := map[string]interface{}{"task_id": result.TaskID}
+		switch {
+		case result.Err != nil:
+			failed++
+			resultJSON["status"] = "failed"
+			resultJSON["error"] = bulkErrorMessage(result.Err)
+		case rolledBack:
+			resultJSON["status"] = "rolled_back"
+		default:
+			succeeded++
+			resultJSON["status"] = "ok"
+			if result.Task != nil {
+				resultJSON["task"] = utils.PreparePayloadMap(result.Task)
+			}
+		}
+		resultsJSON = append(resultsJSON, resultJSON)
+	}
+
+	data := map[string]interface{}{
+		"succeeded": succeeded,
+		"failed":    failed,
+		"results":   resultsJSON,
+	}
+
+	if rolledBack {
+		utils.WriteJSON(w, http.StatusConflict, map[string]interface{}{
+			"error": err.Error(),
+			"data":  data,
+		})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Bulk operation applied",
+		"data":    data,
+	})
+}
+
+// bulkErrorMessage describes why a bulk operation failed for a task without
+// exposing unexpected errors.
+func bulkErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "task not found"
+	case errors.Is(err, database.ErrTaskBlocked), errors.Is(err, database.ErrEmptyTitle):
+		return err.Error()
+	default:
+		return "internal server error"
+	}
+}
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/internal/database"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetCommentsHandler godoc
+// @Summary Get the comments of a task
+// @Description Get a page of the comments of a task, oldest first
+// @Tags comments
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/comments [get]
+func (s *Server) GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	page, err := parsePageQuery(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.ValidateStruct(w, page); err != nil {
+		return
+	}
+
+	comments, nextCursor, err := s.db.GetComments(projectID, listID, taskID, page)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		if errors.Is(err, database.ErrInvalidCursor) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	data := []interface{}{}
+	for _, comment := range comments {
+		data = append(data, commentJSON(&comment))
+	}
+
+	response := map[string]interface{}{
+		"message":     "Comments retrieved successfully",
+		"data":        data,
+		"next_cursor": nil,
+	}
+	if nextCursor != "" {
+		response["next_cursor"] = nextCursor
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetCommentHandler godoc
+// @Summary Get a comment
+// @Description Get a comment of a task, with the previous bodies of an edited comment in revisions
+// @Tags comments
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param commentID path string true "Comment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/comments/{commentID} [get]
+func (s *Server) GetCommentHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+	commentID := r.PathValue("commentID")
+
+	comment, err := s.db.GetComment(projectID, listID, taskID, commentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("comment not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Comment retrieved successfully",
+		"data":    commentWithRevisionsJSON(comment),
+	})
+}
+
+// PostCommentsHandler godoc
+// @Summary Comment on a task
+// @Description Add a Markdown comment to a task. Project members mentioned as @username are recorded so they can be notified.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param comment body types.CreateCommentPayload true "Create Comment Payload"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/comments [post]
+func (s *Server) PostCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	var createCommentPayload types.CreateCommentPayload
+	if err := utils.ParseAndValidateJSON(w, r, &createCommentPayload); err != nil {
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	comment, err := s.dbAs(r).CreateComment(projectID, listID, taskID, user.ID, createCommentPayload)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "Comment created successfully",
+		"data":    commentJSON(comment),
+	})
+}
+
+// PutCommentHandler godoc
+// @Summary Edit a comment
+// @Description Replace the body of a comment. Only its author can edit it, and the previous body is kept as a revision.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param commentID path string true "Comment ID"
+// @Param comment body types.UpdateCommentPayload true "Update Comment Payload"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/comments/{commentID} [put]
+func (s *Server) PutCommentHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+	commentID := r.PathValue("commentID")
+
+	var updateCommentPayload types.UpdateCommentPayload
+	if err := utils.ParseAndValidateJSON(w, r, &updateCommentPayload); err != nil {
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	comment, err := s.dbAs(r).UpdateComment(projectID, listID, taskID, commentID, user.ID, updateCommentPayload)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("comment not found"))
+			return
+		}
+		if errors.Is(err, database.ErrNotCommentAuthor) {
+			utils.WriteError(w, http.StatusForbidden, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Comment updated successfully",
+		"data":    commentWithRevisionsJSON(comment),
+	})
+}
+
+// DeleteCommentHandler godoc
+// @Summary Delete a comment
+// @Description Delete a comment. Authors can delete their own comments and project owners anyone's.
+// @Tags comments
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param commentID path string true "Comment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/comments/{commentID} [delete]
+func (s *Server) DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+	commentID := r.PathValue("commentID")
+
+	user := auth.UserFromContext(r.Context())
+
+	err := s.dbAs(r).DeleteComment(projectID, listID, taskID, commentID, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("comment not found"))
+			return
+		}
+		if errors.Is(err, database.ErrNotCommentAuthor) {
+			utils.WriteError(w, http.StatusForbidden, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Comment deleted successfully", nil)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetMyMentionsHandler godoc
+// @Summary Get my mentions
+// @Description Get a page of the comments mentioning the current user, newest first
+// @Tags comments
+// @Produce json
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/me/mentions [get]
+func (s *Server) GetMyMentionsHandler(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageQuery(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.ValidateStruct(w, page); err != nil {
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	mentions, nextCursor, err := s.db.GetMentions(user.ID, page)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidCursor) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	data := []interface{}{}
+	for _, mention := range mentions {
+		data = append(data, map[string]interface{}{
+			"id":         mention.ID,
+			"created_at": mention.CreatedAt,
+			"task_id":    mention.TaskID,
+			"comment":    commentJSON(&mention.Comment),
+		})
+	}
+
+	response := map[string]interface{}{
+		"message":     "Mentions retrieved successfully",
+		"data":        data,
+		"next_cursor": nil,
+	}
+	if nextCursor != "" {
+		response["next_cursor"] = nextCursor
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// commentJSON renders a comment with its author and the usernames it
+// mentions instead of the raw associations.
+func commentJSON(comment *schemas.Comment) map[string]interface{} {
+	data := utils.PreparePayloadMap(comment)
+
+	data["author"] = map[string]interface{}{
+		"id":       comment.Author.ID,
+		"username": comment.Author.Username,
+	}
+
+	mentions := []string{}
+	for _, mention := range comment.Mentions {
+		mentions = append(mentions, mention.User.Username)
+	}
+	data["mentions"] = mentions
+
+	return data
+}
+
+func commentWithRevisionsJSON(comment *schemas.Comment) map[string]interface{} {
+	data := commentJSON(comment)
+
+	revisions := []interface{}{}
+	for _, revision := range comment.Revisions {
+		revisions = append(revisions, map[string]interface{}{
+			"body":       revision.Body,
+			"created_at": revision.CreatedAt,
+		})
+	}
+	data["revisions"] = revisions
+
+	return data
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/database"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetTaskDependenciesHandler godoc
+// @Summary Get the tasks blocking a task
+// @Description Get the tasks that must be done before a task can be completed
+// @Tags dependencies
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/dependencies [get]
+func (s *Server) GetTaskDependenciesHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	blockers, err := s.db.GetTaskDependencies(projectID, listID, taskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Dependencies retrieved successfully", blockers)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostTaskDependenciesHandler godoc
+// @Summary Add a dependency to a task
+// @Description Record that a task is blocked by another task of the same project. Dependencies that would create a cycle are rejected.
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param dependency body types.AddTaskDependencyPayload true "Add Task Dependency Payload"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/dependencies [post]
+func (s *Server) PostTaskDependenciesHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	var addTaskDependencyPayload types.AddTaskDependencyPayload
+	if err := utils.ParseAndValidateJSON(w, r, &addTaskDependencyPayload); err != nil {
+		return
+	}
+
+	blockers, err := s.dbAs(r).AddTaskDependency(projectID, listID, taskID, addTaskDependencyPayload.BlockerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		if errors.Is(err, database.ErrDependencyCycle) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, database.ErrDependencyExists) {
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Dependency added successfully", blockers)
+
+	utils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// DeleteTaskDependencyHandler godoc
+// @Summary Remove a dependency from a task
+// @Description Remove a blocking task from a task's dependencies
+// @Tags dependencies
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param blockerID path string true "Blocking Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/dependencies/{blockerID} [delete]
+func (s *Server) DeleteTaskDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+	blockerID := r.PathValue("blockerID")
+
+	blockers, err := s.dbAs(r).RemoveTaskDependency(projectID, listID, taskID, blockerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("dependency not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Dependency removed successfully", blockers)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetTasksInDependencyOrderHandler godoc
+// @Summary Get a project's tasks in dependency order
+// @Description Get every task of a project topologically sorted, so each task follows the tasks blocking it. Each task lists the IDs of its blockers in blocked_by.
+// @Tags dependencies
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/tasks/ordered [get]
+func (s *Server) GetTasksInDependencyOrderHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	tasks, err := s.db.GetTasksInDependencyOrder(projectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	data := []interface{}{}
+	for _, task := range tasks {
+		blockedBy := []uint{}
+		for _, blocker := range task.BlockedBy {
+			blockedBy = append(blockedBy, blocker.ID)
+		}
+
+		taskJSON := utils.PreparePayloadMap(task)
+		taskJSON["blocked_by"] = blockedBy
+		data = append(data, taskJSON)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Tasks retrieved successfully",
+		"data":    data,
+	})
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/internal/events"
+	"go-tasker/utils"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// eventsHeartbeat is how often an idle event stream sends a comment, so
+// proxies do not close it.
+const eventsHeartbeat = 15 * time.Second
+
+// GetProjectEventsHandler godoc
+// @Summary Stream the changes to a project
+// @Description Stream the changes to a project as Server-Sent Events. Each event is named after the change, such as task.created, and carries the activity log entry as JSON. Clients that reconnect with Last-Event-ID receive the events they missed, or a reset event when those are no longer available and their state has to be reloaded. Browsers authenticate with a stream token in access_token, and pages from origins outside ALLOWED_ORIGINS are refused.
+// @Tags events
+// @Produce text/event-stream
+// @Param projectID path string true "Project ID"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Param last_event_id query string false "ID of the last event received, for clients that cannot set Last-Event-ID"
+// @Param access_token query string false "Stream token"
+// @Success 200 {string} string
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/events [get]
+func (s *Server) GetProjectEventsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID, _ := strconv.ParseUint(r.PathValue("projectID"), 10, 64)
+
+	if !s.allowedOrigin(r) {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("origin not allowed"))
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	// The stream stays open for as long as the client listens, well past
+	// the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	hub := s.db.Events()
+	sub, replay, ok := hub.Subscribe(uint(projectID), auth.UserFromContext(r.Context()).ID, lastEventID)
+	defer hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !ok {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range replay {
+		writeEvent(w, event)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-sub.C:
+			// A closed subscription fell behind, or the user may no
+			// longer follow the project; the client reconnects and either
+			// replays what it missed or is refused
+			if !open {
+				return
+			}
+			writeEvent(w, event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w io.Writer, event events.Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Name, event.Data)
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/schemas"
+	"go-tasker/utils"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// idempotencyLease is how long a request may take before a retry with
+	// the same key is handled again rather than told to wait
+	idempotencyLease = time.Minute
+
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyMemoryLimit is how much of a request body is kept in memory
+	// while it is fingerprinted; larger bodies, such as attachment uploads,
+	// are spooled to a temporary file
+	idempotencyMemoryLimit = 64 << 10
+)
+
+// idempotent lets clients retry POST requests safely. A request with an
+// Idempotency-Key header is handled once; repeating it with the same key
+// replays the stored response, and reusing the key for a different request
+// is rejected. Keys belong to the authenticated user and expire after
+// idempotencyKeyTTL. Responses with a 5xx status are not stored, so those
+// requests can be retried, and responses carrying a secret are replayed
+// without it.
+func (s *Server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		user := auth.UserFromContext(r.Context())
+		if r.Method != http.MethodPost || key == "" || user == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			utils.WriteError(w, http.StatusBadRequest,
+				fmt.Errorf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength))
+			return
+		}
+
+		// No route accepts a body larger than an attachment upload
+		body, fingerprint, err := spoolRequest(r, http.MaxBytesReader(w, r.Body, s.attachmentMaxSize+multipartOverhead))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				utils.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body is too large"))
+				return
+			}
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("cannot read request body"))
+			return
+		}
+		defer body.Close()
+		r.Body = body
+
+		now := time.Now().UTC()
+		request := &schemas.IdempotentRequest{
+			UserID:      user.ID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   now.Add(s.idempotencyKeyTTL),
+		}
+
+		previous, err := s.db.ClaimIdempotentRequest(request, now.Add(-idempotencyLease))
+		if err != nil {
+			utils.WriteInternalServerError(w, err)
+			return
+		}
+		if previous != nil {
+			replayIdempotentRequest(w, request, previous)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		if recorder.status >= http.StatusInternalServerError {
+			if err := s.db.ReleaseIdempotentRequest(request); err != nil {
+				log.Printf("releasing idempotency key: %v", err)
+			}
+			return
+		}
+
+		request.Status = recorder.status
+		request.Header = recorder.Header().Clone()
+		request.Body = recorder.body.Bytes()
+		if returnsSecret(r) {
+			// Only the status is kept, so a secret shown once is never
+			// written to the database
+			request.Header = http.Header{"Content-Type": {"application/json"}}
+			request.Body, _ = json.Marshal(map[string]string{
+				"message": "The response to this request carried a secret and is not replayed",
+			})
+		}
+		if err := s.db.SaveIdempotentResponse(request); err != nil {
+			log.Printf("storing idempotent response: %v", err)
+		}
+	})
+}
+
+func replayIdempotentRequest(w http.ResponseWriter, request *schemas.IdempotentRequest, previous *schemas.IdempotentRequest) {
+	if previous.Fingerprint != request.Fingerprint {
+		utils.WriteError(w, http.StatusUnprocessableEntity,
+			fmt.Errorf("this Idempotency-Key was already used for a different request"))
+		return
+	}
+	if previous.Status == 0 {
+		utils.WriteError(w, http.StatusConflict,
+			fmt.Errorf("a request with this Idempotency-Key is still being handled"))
+		return
+	}
+
+	for name, values := range previous.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(previous.Status)
+	w.Write(previous.Body)
+}
+
+// returnsSecret reports whether a request is answered with a secret that
+// must only be returned once: an API key, a token or a webhook signing
+// secret.
+func returnsSecret(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api/v1/auth/") || r.URL.Path == "/api/v1/me/api-keys" {
+		return true
+	}
+	ok, _ := path.Match("/api/v1/projects/*/webhooks", r.URL.Path)
+	return ok
+}
+
+// spoolRequest reads the body of a request and returns a copy of it for the
+// handler, along with a fingerprint identifying what the request asks for, to
+// tell a retry from another request made with the same key. Bodies larger
+// than idempotencyMemoryLimit are copied to a temporary file, removed when
+// the copy is closed.
+func spoolRequest(r *http.Request, body io.Reader) (io.ReadCloser, string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", r.Method, r.URL.RequestURI())
+
+	var head bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(hash, &head), io.LimitReader(body, idempotencyMemoryLimit+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if n <= idempotencyMemoryLimit {
+		return io.NopCloser(&head), hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	file, err := os.CreateTemp("", "idempotent-request-*")
+	if err != nil {
+		return nil, "", err
+	}
+	spooled := &spooledBody{file}
+	if _, err := file.Write(head.Bytes()); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	if _, err := io.Copy(io.MultiWriter(hash, file), body); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	return spooled, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// spooledBody is a request body copied to a temporary file.
+type spooledBody struct {
+	*os.File
+}
+
+func (b *spooledBody) Close() error {
+	b.File.Close()
+	return os.Remove(b.Name())
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(p)
+	return rr.ResponseWriter.Write(p)
+}
+
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// expireIdempotencyKeys deletes expired idempotency keys once per interval.
+func (s *Server) expireIdempotencyKeys(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.db.DeleteExpiredIdempotentRequests(time.Now().UTC()); err != nil {
+			log.Printf("deleting expired idempotency keys: %v", err)
+		}
+	}
+}
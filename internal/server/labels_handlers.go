@@ -0,0 +1,209 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/database"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetLabelsHandler godoc
+// @Summary Get all labels for a project
+// @Description Get all labels for a project, ordered by name
+// @Tags labels
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/labels [get]
+func (s *Server) GetLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	labels, err := s.db.GetLabels(projectID)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Labels retrieved successfully", labels)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostLabelsHandler godoc
+// @Summary Create a new label within a project
+// @Description Create a new label within a project
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param label body types.CreateLabelPayload true "Create Label Payload"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/labels [post]
+func (s *Server) PostLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	var createLabelPayload types.CreateLabelPayload
+	if err := utils.ParseAndValidateJSON(w, r, &createLabelPayload); err != nil {
+		return
+	}
+
+	label, err := s.dbAs(r).CreateLabel(projectID, createLabelPayload)
+	if err != nil {
+		writeLabelError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Label created successfully", label)
+
+	utils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// PutLabelHandler godoc
+// @Summary Update a label within a project
+// @Description Update a label within a project
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param labelID path string true "Label ID"
+// @Param label body types.UpdateLabelPayload true "Update Label Payload"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/labels/{labelID} [put]
+func (s *Server) PutLabelHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	labelID := r.PathValue("labelID")
+
+	var updateLabelPayload types.UpdateLabelPayload
+	if err := utils.ParseAndValidateJSON(w, r, &updateLabelPayload); err != nil {
+		return
+	}
+
+	label, err := s.dbAs(r).UpdateLabel(projectID, labelID, updateLabelPayload)
+	if err != nil {
+		writeLabelError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Label updated successfully", label)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteLabelHandler godoc
+// @Summary Delete a label within a project
+// @Description Delete a label and remove it from every task
+// @Tags labels
+// @Param projectID path string true "Project ID"
+// @Param labelID path string true "Label ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/labels/{labelID} [delete]
+func (s *Server) DeleteLabelHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	labelID := r.PathValue("labelID")
+
+	err := s.dbAs(r).DeleteLabel(projectID, labelID)
+	if err != nil {
+		writeLabelError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Label deleted successfully", nil)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostTaskLabelsHandler godoc
+// @Summary Add a label to a task
+// @Description Add one of the project's labels to a task
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param label body types.AttachLabelPayload true "Attach Label Payload"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/labels [post]
+func (s *Server) PostTaskLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	var attachLabelPayload types.AttachLabelPayload
+	if err := utils.ParseAndValidateJSON(w, r, &attachLabelPayload); err != nil {
+		return
+	}
+
+	task, err := s.dbAs(r).AttachLabel(projectID, listID, taskID, attachLabelPayload.LabelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task or label not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Label added to task successfully", task)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteTaskLabelHandler godoc
+// @Summary Remove a label from a task
+// @Description Remove a label from a task
+// @Tags labels
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param labelID path string true "Label ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/labels/{labelID} [delete]
+func (s *Server) DeleteTaskLabelHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+	labelID := r.PathValue("labelID")
+
+	task, err := s.dbAs(r).DetachLabel(projectID, listID, taskID, labelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("label is not on this task"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Label removed from task successfully", task)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func writeLabelError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("label not found"))
+	case errors.Is(err, database.ErrLabelExists):
+		utils.WriteError(w, http.StatusConflict, err)
+	default:
+		utils.WriteInternalServerError(w, err)
+	}
+}
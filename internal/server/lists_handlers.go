@@ -1,6 +1,9 @@
 package server
 
 import (
+	"errors"
+	"go-tasker/internal/database"
+	"go-tasker/schemas"
 	"go-tasker/types"
 	"go-tasker/utils"
 	"net/http"
@@ -8,23 +11,42 @@ import (
 
 // GetListsHandler godoc
 // @Summary Get all lists for a project
-// @Description Get all lists for a project
+// @Description Get a page of lists for a project
 // @Tags lists
 // @Produce json
 // @Param projectID path string true "Project ID"
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param sort query string false "Sort field, prefixed with - for descending: id, title, created_at, updated_at"
+// @Param created_after query string false "Only lists created after this RFC 3339 timestamp"
+// @Param created_before query string false "Only lists created before this RFC 3339 timestamp"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/projects/{projectID}/lists [get]
 func (s *Server) GetListsHandler(w http.ResponseWriter, r *http.Request) {
 	projectID := r.PathValue("projectID")
 
-	lists, err := s.db.GetLists(projectID)
+	query, err := parseListQuery(r)
 	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.ValidateStruct(w, query); err != nil {
+		return
+	}
+
+	lists, nextCursor, err := s.db.GetLists(projectID, query)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidCursor) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
 		http.Error(w, "Error getting lists", http.StatusInternalServerError)
 		return
 	}
 
-	response := utils.PrepareJSONWithMessage("Lists retrieved successfully", lists)
+	response := utils.PrepareJSONWithPagination("Lists retrieved successfully", lists, nextCursor)
 
 	utils.WriteJSON(w, http.StatusOK, response)
 }
@@ -49,11 +71,12 @@ func (s *Server) PostListsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := s.db.CreateList(projectID, createListPayload)
+	list, err := s.dbAs(r).CreateList(projectID, createListPayload)
 	if err != nil {
 		utils.WriteInternalServerError(w, err)
 		return
 	}
+	setETag(w, list.Version)
 
 	response := utils.PrepareJSONWithMessage("List created successfully", list)
 
@@ -69,8 +92,10 @@ func (s *Server) PostListsHandler(w http.ResponseWriter, r *http.Request) {
 // @Param projectID path string true "Project ID"
 // @Param id path string true "List ID"
 // @Param list body types.UpdateListPayload true "Update List Payload"
+// @Param If-Match header string false "ETag of the version being updated"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/projects/{projectID}/lists/{id} [put]
 func (s *Server) PutListHandler(w http.ResponseWriter, r *http.Request) {
@@ -82,11 +107,64 @@ func (s *Server) PutListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := s.db.UpdateList(projectID, listID, updateListPayload)
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := db.UpdateList(projectID, listID, updateListPayload)
 	if err != nil {
+		if errors.Is(err, database.ErrVersionMismatch) {
+			utils.WriteError(w, http.StatusPreconditionFailed, err)
+			return
+		}
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
+	setETag(w, list.Version)
+
+	response := utils.PrepareJSONWithMessage("List updated successfully", list)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PatchListHandler godoc
+// @Summary Partially update a list within a project
+// @Description Apply a JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json) to the fields of UpdateListPayload; fields the patch leaves out keep their value
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param id path string true "List ID"
+// @Param patch body object true "Merge patch or JSON Patch"
+// @Param If-Match header string false "ETag of the version being updated"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{id} [patch]
+func (s *Server) PatchListHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("id")
+
+	var list *schemas.List
+	load := func(db database.Service) (types.UpdateListPayload, uint, error) {
+		current, err := db.GetList(projectID, listID)
+		if err != nil {
+			return types.UpdateListPayload{}, 0, err
+		}
+		return types.UpdateListPayload{Title: current.Title}, current.Version, nil
+	}
+	save := func(db database.Service, payload types.UpdateListPayload) (err error) {
+		list, err = db.UpdateList(projectID, listID, payload)
+		return err
+	}
+	if !patchEntity(s, w, r, "list", load, save) {
+		return
+	}
+	setETag(w, list.Version)
 
 	response := utils.PrepareJSONWithMessage("List updated successfully", list)
 
@@ -99,16 +177,27 @@ func (s *Server) PutListHandler(w http.ResponseWriter, r *http.Request) {
 // @Tags lists
 // @Param projectID path string true "Project ID"
 // @Param id path string true "List ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/projects/{projectID}/lists/{id} [delete]
 func (s *Server) DeleteListHandler(w http.ResponseWriter, r *http.Request) {
 	projectID := r.PathValue("projectID")
 	listID := r.PathValue("id")
 
-	err := s.db.DeleteList(projectID, listID)
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err := db.DeleteList(projectID, listID)
 	if err != nil {
+		if errors.Is(err, database.ErrVersionMismatch) {
+			utils.WriteError(w, http.StatusPreconditionFailed, err)
+			return
+		}
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -138,6 +227,7 @@ func (s *Server) GetListHandler(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
+	setETag(w, list.Version)
 
 	response := utils.PrepareJSONWithMessage("List retrieved successfully", list)
 
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/database"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetMembersHandler godoc
+// @Summary Get the members of a project
+// @Description Get the members of a project and their roles
+// @Tags members
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/members [get]
+func (s *Server) GetMembersHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	members, err := s.db.GetProjectMembers(projectID)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Members retrieved successfully", members)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostMembersHandler godoc
+// @Summary Add a member to a project
+// @Description Give an existing user a role (viewer, editor or owner) in a project
+// @Tags members
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param member body types.AddProjectMemberPayload true "Add Project Member Payload"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/members [post]
+func (s *Server) PostMembersHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	var addMemberPayload types.AddProjectMemberPayload
+	if err := utils.ParseAndValidateJSON(w, r, &addMemberPayload); err != nil {
+		return
+	}
+
+	member, err := s.dbAs(r).AddProjectMember(projectID, addMemberPayload)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+			return
+		}
+		if errors.Is(err, database.ErrAlreadyMember) {
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Member added successfully", member)
+
+	utils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// PutMemberHandler godoc
+// @Summary Change a member's role
+// @Description Change the role of a project member
+// @Tags members
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param userID path string true "User ID"
+// @Param member body types.UpdateProjectMemberPayload true "Update Project Member Payload"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/members/{userID} [put]
+func (s *Server) PutMemberHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	userID := r.PathValue("userID")
+
+	var updateMemberPayload types.UpdateProjectMemberPayload
+	if err := utils.ParseAndValidateJSON(w, r, &updateMemberPayload); err != nil {
+		return
+	}
+
+	member, err := s.dbAs(r).UpdateProjectMember(projectID, userID, updateMemberPayload)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Member updated successfully", member)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteMemberHandler godoc
+// @Summary Remove a member from a project
+// @Description Remove a user from a project
+// @Tags members
+// @Param projectID path string true "Project ID"
+// @Param userID path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/members/{userID} [delete]
+func (s *Server) DeleteMemberHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	userID := r.PathValue("userID")
+
+	err := s.dbAs(r).RemoveProjectMember(projectID, userID)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Member removed successfully", nil)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func writeMemberError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("member not found"))
+	case errors.Is(err, database.ErrLastOwner):
+		utils.WriteError(w, http.StatusConflict, err)
+	default:
+		utils.WriteInternalServerError(w, err)
+	}
+}
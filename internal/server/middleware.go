@@ -0,0 +1,127 @@
+package server
+
+import (
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/schemas"
+	"go-tasker/utils"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// publicRoutes lists the API routes that can be called without credentials.
+// Everything outside /api/ (the root and Swagger UI) is public as well.
+var publicRoutes = map[string]bool{
+	"POST /api/v1/auth/register": true,
+	"POST /api/v1/auth/login":    true,
+}
+
+// authenticate resolves the caller from a bearer token or API key and stores
+// it in the request context. API routes other than publicRoutes reject
+// anonymous requests with 401.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || publicRoutes[r.Method+" "+r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := s.userFromCredentials(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="go-tasker"`)
+			utils.WriteError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+	})
+}
+
+// userFromCredentials reads either an "Authorization: Bearer" header holding
+// a signed token or API key, or an "X-API-Key" header. Event streams also
+// accept a stream token, since browsers cannot set headers on them.
+func (s *Server) userFromCredentials(r *http.Request) (*schemas.User, error) {
+	credential := r.Header.Get("X-API-Key")
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, value, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return nil, fmt.Errorf("unsupported authorization scheme")
+		}
+		credential = strings.TrimSpace(value)
+	}
+
+	if credential == "" {
+		if token := streamToken(r); token != "" {
+			return s.userFromToken(token, auth.StreamScope)
+		}
+		return nil, fmt.Errorf("authentication required")
+	}
+
+	if auth.IsAPIKey(credential) {
+		user, err := s.db.GetUserByAPIKey(credential)
+		if err != nil {
+			return nil, fmt.Errorf("invalid or expired api key")
+		}
+		return user, nil
+	}
+
+	return s.userFromToken(credential, "")
+}
+
+// userFromToken returns the user a signed token was issued to, provided it
+// carries the given scope. Scoped tokens are refused everywhere else.
+func (s *Server) userFromToken(token string, scope string) (*schemas.User, error) {
+	claims, err := s.tokens.Verify(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Scope != scope {
+		return nil, auth.ErrInvalidToken
+	}
+
+	user, err := s.db.GetUser(claims.UserID)
+	if err != nil {
+		return nil, auth.ErrInvalidToken
+	}
+	return user, nil
+}
+
+// streamToken returns the stream token passed as the access_token query
+// parameter of an event stream or WebSocket request, or offered by a
+// WebSocket client as a "bearer.<token>" subprotocol.
+func streamToken(r *http.Request) string {
+	if r.Method != http.MethodGet {
+		return ""
+	}
+	if ok, _ := path.Match("/api/v1/projects/*/events", r.URL.Path); !ok && r.URL.Path != "/api/v1/ws" {
+		return ""
+	}
+
+	if token := r.URL.Query().Get("access_token"); token != "" {
+		return token
+	}
+	for _, protocol := range strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",") {
+		if token, ok := strings.CutPrefix(strings.TrimSpace(protocol), wsTokenProtocolPrefix); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// allowedOrigin reports whether a browser request comes from the server's
+// own origin or one listed in ALLOWED_ORIGINS. Requests without an Origin
+// header are not sent by a browser page and are allowed.
+func (s *Server) allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+		return true
+	}
+	return slices.Contains(s.allowedOrigins, "*") || slices.Contains(s.allowedOrigins, origin)
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/internal/database"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// PostListMoveHandler godoc
+// @Summary Reorder a list within a project
+// @Description Move a list right before or right after another list of the same project. Only the moved list is rewritten.
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param id path string true "List ID"
+// @Param move body types.MovePayload true "Move Payload"
+// @Param If-Match header string false "ETag of the version being moved"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{id}/move [post]
+func (s *Server) PostListMoveHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("id")
+
+	var movePayload types.MovePayload
+	if err := utils.ParseAndValidateJSON(w, r, &movePayload); err != nil {
+		return
+	}
+
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	siblingID, after := moveSibling(movePayload)
+	list, err := db.MoveList(projectID, listID, siblingID, after)
+	if err != nil {
+		writeMoveError(w, err, "list not found")
+		return
+	}
+	setETag(w, list.Version)
+
+	response := utils.PrepareJSONWithMessage("List moved successfully", list)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostTaskMoveHandler godoc
+// @Summary Move a task
+// @Description Move a task and its subtasks right before or after a sibling, or into another list, possibly of another project, at the end or next to a sibling there. Moving to another project requires the editor role there; labels are matched by name and assignees who are not members are dropped.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param move body types.MoveTaskPayload true "Move Task Payload"
+// @Param If-Match header string false "ETag of the version being moved"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/move [post]
+func (s *Server) PostTaskMoveHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	var moveTaskPayload types.MoveTaskPayload
+	if err := utils.ParseAndValidateJSON(w, r, &moveTaskPayload); err != nil {
+		return
+	}
+
+	if !s.checkTargetProject(w, r, moveTaskPayload.ProjectID) {
+		return
+	}
+
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := db.MoveTask(projectID, listID, taskID, moveTaskPayload)
+	if err != nil {
+		writeMoveError(w, err, "task not found")
+		return
+	}
+	setETag(w, task.Version)
+
+	response := utils.PrepareJSONWithMessage("Task moved successfully", task)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostTaskCopyHandler godoc
+// @Summary Copy a task
+// @Description Copy a task and its subtasks to the end of a list, by default its own, possibly in another project. Copying to another project requires the editor role there.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param copy body types.CopyTaskPayload true "Copy Task Payload"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/copy [post]
+func (s *Server) PostTaskCopyHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	var copyTaskPayload types.CopyTaskPayload
+	if err := utils.ParseAndValidateJSON(w, r, &copyTaskPayload); err != nil {
+		return
+	}
+
+	if !s.checkTargetProject(w, r, copyTaskPayload.ProjectID) {
+		return
+	}
+
+	task, err := s.dbAs(r).CopyTask(projectID, listID, taskID, copyTaskPayload)
+	if err != nil {
+		writeMoveError(w, err, "task not found")
+		return
+	}
+	setETag(w, task.Version)
+
+	response := utils.PrepareJSONWithMessage("Task copied successfully", task)
+
+	utils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// checkTargetProject requires the editor role in the project a task is
+// moved or copied to, when it differs from the project in the URL.
+func (s *Server) checkTargetProject(w http.ResponseWriter, r *http.Request, targetProjectID *uint) bool {
+	if targetProjectID == nil {
+		return true
+	}
+	target := strconv.FormatUint(uint64(*targetProjectID), 10)
+	if target == r.PathValue("projectID") {
+		return true
+	}
+
+	user := auth.UserFromContext(r.Context())
+	return s.checkProjectRole(w, target, user.ID, schemas.RoleEditor)
+}
+
+// moveSibling returns the sibling to move next to and whether the item goes
+// after it. The payload has been validated to set exactly one of the two.
+func moveSibling(payload types.MovePayload) (uint, bool) {
+	if payload.AfterID != nil {
+		return *payload.AfterID, true
+	}
+	return *payload.BeforeID, false
+}
+
+func writeMoveError(w http.ResponseWriter, err error, notFound string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("%s", notFound))
+		return
+	}
+	if errors.Is(err, database.ErrInvalidSibling) || errors.Is(err, database.ErrTargetListNotFound) {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if errors.Is(err, database.ErrVersionMismatch) {
+		utils.WriteError(w, http.StatusPreconditionFailed, err)
+		return
+	}
+	utils.WriteInternalServerError(w, err)
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/database"
+	"go-tasker/utils"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// patchAttempts bounds how often a patch sent without If-Match is applied
+// again when the entity changes between reading and saving it.
+const patchAttempts = 3
+
+// patchEntity applies the JSON Merge Patch or JSON Patch in the request body
+// to the update payload load builds from the current state of an entity,
+// validates the result and saves it with save, so that only the fields the
+// patch names change. Without If-Match, save only goes through at the
+// version load read, and the patch is applied again to the newer state when
+// another request changed the entity in between. It writes the error
+// response itself and reports whether the entity was saved.
+func patchEntity[P any](s *Server, w http.ResponseWriter, r *http.Request, entity string,
+	load func(database.Service) (P, uint, error), save func(database.Service, P) error) bool {
+	patch, err := utils.ReadPatch(w, r)
+	if err != nil {
+		return false
+	}
+
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return false
+	}
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	retry := ifMatch == "" || ifMatch == "*"
+
+	for attempt := 1; ; attempt++ {
+		current, version, err := load(db)
+		if err != nil {
+			writePatchError(w, entity, err)
+			return false
+		}
+
+		var payload P
+		if err := patch.ApplyAndValidate(w, current, &payload); err != nil {
+			return false
+		}
+
+		saveDB := db
+		if retry {
+			saveDB = db.IfMatch(version)
+		}
+		err = save(saveDB, payload)
+		if retry && errors.Is(err, database.ErrVersionMismatch) {
+			if attempt < patchAttempts {
+				continue
+			}
+			utils.WriteError(w, http.StatusConflict, fmt.Errorf("the %s kept changing while the patch was applied", entity))
+			return false
+		}
+		if err != nil {
+			writePatchError(w, entity, err)
+			return false
+		}
+		return true
+	}
+}
+
+// writePatchError maps the errors of loading or saving a patched entity to
+// a response. Anything unexpected is an internal error, so its message is
+// not shown to the client.
+func writePatchError(w http.ResponseWriter, entity string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("%s not found", entity))
+	case errors.Is(err, database.ErrVersionMismatch):
+		utils.WriteError(w, http.StatusPreconditionFailed, err)
+	case errors.Is(err, database.ErrUnknownStatus):
+		utils.WriteError(w, http.StatusBadRequest, err)
+	case errors.Is(err, database.ErrTaskBlocked), errors.Is(err, database.ErrStatusTransition),
+		errors.Is(err, database.ErrOpenTasks):
+		utils.WriteError(w, http.StatusConflict, err)
+	default:
+		utils.WriteInternalServerError(w, err)
+	}
+}
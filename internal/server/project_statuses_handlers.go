@@ -0,0 +1,148 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/database"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetProjectTransitionsHandler godoc
+// @Summary Get the statuses a project can move to
+// @Description Get the current status of a project, the statuses it can move to next and every status of the workflow
+// @Tags projects
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/transitions [get]
+func (s *Server) GetProjectTransitionsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	project, err := s.db.GetProject(projectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+	setETag(w, project.Version)
+
+	workflow := s.db.Workflow()
+	next := workflow.Next(project.Status)
+	if next == nil {
+		next = []string{}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Transitions retrieved successfully",
+		"data": map[string]interface{}{
+			"status":   project.Status,
+			"next":     next,
+			"statuses": workflow.Statuses(),
+		},
+	})
+}
+
+// PostProjectTransitionHandler godoc
+// @Summary Move a project to another status
+// @Description Move a project to a status its current one allows in the workflow. Completing a project is refused while any of its tasks is open
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param transition body types.ProjectTransitionPayload true "Project Transition Payload"
+// @Param If-Match header string false "ETag of the version being updated"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/transitions [post]
+func (s *Server) PostProjectTransitionHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	var transitionPayload types.ProjectTransitionPayload
+	if err := utils.ParseAndValidateJSON(w, r, &transitionPayload); err != nil {
+		return
+	}
+
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	project, err := db.TransitionProject(projectID, transitionPayload)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+		case errors.Is(err, database.ErrUnknownStatus):
+			utils.WriteError(w, http.StatusBadRequest, err)
+		case errors.Is(err, database.ErrStatusTransition), errors.Is(err, database.ErrOpenTasks):
+			utils.WriteError(w, http.StatusConflict, err)
+		case errors.Is(err, database.ErrVersionMismatch):
+			utils.WriteError(w, http.StatusPreconditionFailed, err)
+		default:
+			utils.WriteInternalServerError(w, err)
+		}
+		return
+	}
+	setETag(w, project.Version)
+
+	response := utils.PrepareJSONWithMessage("Project status changed successfully", project)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetProjectStatusHistoryHandler godoc
+// @Summary Get the status history of a project
+// @Description Get a page of the status changes of a project, newest first, starting with the status it was created with
+// @Tags projects
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/status-history [get]
+func (s *Server) GetProjectStatusHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	page, err := parsePageQuery(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.ValidateStruct(w, page); err != nil {
+		return
+	}
+
+	changes, nextCursor, err := s.db.GetProjectStatusHistory(projectID, page)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+			return
+		}
+		if errors.Is(err, database.ErrInvalidCursor) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithPagination("Status history retrieved successfully", changes, nextCursor)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
@@ -1,40 +1,99 @@
 package server
 
 import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/internal/database"
+	"go-tasker/schemas"
 	"go-tasker/types"
 	"go-tasker/utils"
 	"net/http"
+
+	"gorm.io/gorm"
 )
 
 // GetProjectsHandler godoc
 // @Summary Get all projects
-// @Description Get all projects
+// @Description Get a page of the projects the current user is a member of
 // @Tags projects
 // @Produce json
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param sort query string false "Sort field, prefixed with - for descending: id, title, created_at, updated_at"
+// @Param status query string false "Filter by status"
+// @Param created_after query string false "Only projects created after this RFC 3339 timestamp"
+// @Param created_before query string false "Only projects created before this RFC 3339 timestamp"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/projects [get]
 func (s *Server) GetProjectsHandler(w http.ResponseWriter, r *http.Request) {
-	projects, err := s.db.GetProjects()
+	query, err := parseProjectQuery(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.ValidateStruct(w, query); err != nil {
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	projects, nextCursor, err := s.db.GetProjects(user.ID, query)
 	if err != nil {
+		if errors.Is(err, database.ErrInvalidCursor) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
 		http.Error(w, "Error getting projects", http.StatusInternalServerError)
 		return
 	}
 
-	response := utils.PrepareJSONWithMessage("Projects retrieved successfully", projects)
+	response := utils.PrepareJSONWithPagination("Projects retrieved successfully", projects, nextCursor)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetProjectHandler godoc
+// @Summary Get a project
+// @Description Get a project the current user is a member of
+// @Tags projects
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID} [get]
+func (s *Server) GetProjectHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	project, err := s.db.GetProject(projectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+	setETag(w, project.Version)
+
+	response := utils.PrepareJSONWithMessage("Project retrieved successfully", project)
 
 	utils.WriteJSON(w, http.StatusOK, response)
 }
 
 // PostProjectsHandler godoc
 // @Summary Create a new project
-// @Description Create a new project
+// @Description Create a new project owned by the current user. It starts in the initial status of the project workflow, which is also used when the status is left out
 // @Tags projects
 // @Accept json
 // @Produce json
 // @Param project body types.CreateProjectPayload true "Create Project Payload"
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/projects [post]
 func (s *Server) PostProjectsHandler(w http.ResponseWriter, r *http.Request) {
@@ -43,11 +102,22 @@ func (s *Server) PostProjectsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	project, err := s.db.CreateProject(createProjectPayload)
+	user := auth.UserFromContext(r.Context())
+
+	project, err := s.dbAs(r).CreateProject(user.ID, createProjectPayload)
 	if err != nil {
+		if errors.Is(err, database.ErrUnknownStatus) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, database.ErrStatusTransition) {
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		}
 		utils.WriteInternalServerError(w, err)
 		return
 	}
+	setETag(w, project.Version)
 
 	response := utils.PrepareJSONWithMessage("Project created successfully", project)
 
@@ -56,29 +126,88 @@ func (s *Server) PostProjectsHandler(w http.ResponseWriter, r *http.Request) {
 
 // PutProjectHandler godoc
 // @Summary Update a project
-// @Description Update a project
+// @Description Update a project. A status change must be allowed by the project workflow
 // @Tags projects
 // @Accept json
 // @Produce json
-// @Param id path string true "Project ID"
+// @Param projectID path string true "Project ID"
 // @Param project body types.UpdateProjectPayload true "Update Project Payload"
+// @Param If-Match header string false "ETag of the version being updated"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
-// @Router /api/v1/projects/{id} [put]
+// @Router /api/v1/projects/{projectID} [put]
 func (s *Server) PutProjectHandler(w http.ResponseWriter, r *http.Request) {
-	projectID := r.PathValue("id")
+	projectID := r.PathValue("projectID")
 
 	var updateProjectPayload types.UpdateProjectPayload
 	if err := utils.ParseAndValidateJSON(w, r, &updateProjectPayload); err != nil {
 		return
 	}
 
-	project, err := s.db.UpdateProject(projectID, updateProjectPayload)
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	project, err := db.UpdateProject(projectID, updateProjectPayload)
 	if err != nil {
+		if errors.Is(err, database.ErrStatusTransition) || errors.Is(err, database.ErrOpenTasks) {
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		}
+		if errors.Is(err, database.ErrVersionMismatch) {
+			utils.WriteError(w, http.StatusPreconditionFailed, err)
+			return
+		}
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
+	setETag(w, project.Version)
+
+	response := utils.PrepareJSONWithMessage("Project updated successfully", project)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PatchProjectHandler godoc
+// @Summary Partially update a project
+// @Description Apply a JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json) to the fields of UpdateProjectPayload; fields the patch leaves out keep their value
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param patch body object true "Merge patch or JSON Patch"
+// @Param If-Match header string false "ETag of the version being updated"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID} [patch]
+func (s *Server) PatchProjectHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	var project *schemas.Project
+	load := func(db database.Service) (types.UpdateProjectPayload, uint, error) {
+		current, err := db.GetProject(projectID)
+		if err != nil {
+			return types.UpdateProjectPayload{}, 0, err
+		}
+		return types.UpdateProjectPayload{Title: current.Title, Status: current.Status}, current.Version, nil
+	}
+	save := func(db database.Service, payload types.UpdateProjectPayload) (err error) {
+		project, err = db.UpdateProject(projectID, payload)
+		return err
+	}
+	if !patchEntity(s, w, r, "project", load, save) {
+		return
+	}
+	setETag(w, project.Version)
 
 	response := utils.PrepareJSONWithMessage("Project updated successfully", project)
 
@@ -89,16 +218,28 @@ func (s *Server) PutProjectHandler(w http.ResponseWriter, r *http.Request) {
 // @Summary Delete a project
 // @Description Delete a project
 // @Tags projects
-// @Param id path string true "Project ID"
+// @Param projectID path string true "Project ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
-// @Router /api/v1/projects/{id} [delete]
+// @Router /api/v1/projects/{projectID} [delete]
 func (s *Server) DeleteProjectHandler(w http.ResponseWriter, r *http.Request) {
-	projectID := r.PathValue("id")
+	projectID := r.PathValue("projectID")
+
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
 
-	err := s.db.DeleteProject(projectID)
+	err := db.DeleteProject(projectID)
 	if err != nil {
+		if errors.Is(err, database.ErrVersionMismatch) {
+			utils.WriteError(w, http.StatusPreconditionFailed, err)
+			return
+		}
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"fmt"
+	"go-tasker/types"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func parseProjectQuery(r *http.Request) (types.ProjectQuery, error) {
+	var query types.ProjectQuery
+	var err error
+
+	if query.PageQuery, err = parsePageQuery(r); err != nil {
+		return query, err
+	}
+	if query.CreatedAfter, err = parseTimeParam(r, "created_after"); err != nil {
+		return query, err
+	}
+	if query.CreatedBefore, err = parseTimeParam(r, "created_before"); err != nil {
+		return query, err
+	}
+	query.Status = r.URL.Query().Get("status")
+	query.Sort = r.URL.Query().Get("sort")
+
+	return query, nil
+}
+
+func parseListQuery(r *http.Request) (types.ListQuery, error) {
+	var query types.ListQuery
+	var err error
+
+	if query.PageQuery, err = parsePageQuery(r); err != nil {
+		return query, err
+	}
+	if query.CreatedAfter, err = parseTimeParam(r, "created_after"); err != nil {
+		return query, err
+	}
+	if query.CreatedBefore, err = parseTimeParam(r, "created_before"); err != nil {
+		return query, err
+	}
+	query.Sort = r.URL.Query().Get("sort")
+
+	return query, nil
+}
+
+func parseTaskQuery(r *http.Request) (types.TaskQuery, error) {
+	var query types.TaskQuery
+	var err error
+
+	if query.PageQuery, err = parsePageQuery(r); err != nil {
+		return query, err
+	}
+	if query.Done, err = parseBoolParam(r, "done"); err != nil {
+		return query, err
+	}
+	if query.CreatedAfter, err = parseTimeParam(r, "created_after"); err != nil {
+		return query, err
+	}
+	if query.CreatedBefore, err = parseTimeParam(r, "created_before"); err != nil {
+		return query, err
+	}
+	query.Priorities = splitQueryValues(r.URL.Query()["priority"])
+	query.Labels = splitQueryValues(r.URL.Query()["labels"])
+	query.LabelMatch = r.URL.Query().Get("label_match")
+	query.Sort = r.URL.Query().Get("sort")
+
+	return query, nil
+}
+
+func parseActivityQuery(r *http.Request) (types.ActivityQuery, error) {
+	var query types.ActivityQuery
+	var err error
+
+	if query.PageQuery, err = parsePageQuery(r); err != nil {
+		return query, err
+	}
+	query.EntityType = r.URL.Query().Get("entity_type")
+	query.Action = r.URL.Query().Get("action")
+
+	return query, nil
+}
+
+// defaultOccurrences is how many occurrences are previewed without ?count=.
+const defaultOccurrences = 5
+
+func parseOccurrencesQuery(r *http.Request) (types.OccurrencesQuery, error) {
+	query := types.OccurrencesQuery{Count: defaultOccurrences}
+
+	if value := r.URL.Query().Get("count"); value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return query, fmt.Errorf("invalid 'count': must be an integer")
+		}
+		query.Count = count
+	}
+
+	return query, nil
+}
+
+func parsePageQuery(r *http.Request) (types.PageQuery, error) {
+	page := types.PageQuery{Cursor: r.URL.Query().Get("cursor")}
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return page, fmt.Errorf("invalid 'limit': must be an integer")
+		}
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
+// parseTimeParam reads an optional RFC 3339 timestamp from the query string.
+func parseTimeParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s': must be an RFC 3339 timestamp", name)
+	}
+	return &t, nil
+}
+
+// parseBoolParam reads an optional boolean from the query string.
+func parseBoolParam(r *http.Request, name string) (*bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s': must be true or false", name)
+	}
+	return &b, nil
+}
+
+// splitQueryValues flattens repeated and comma-separated query values, so
+// ?priority=high,urgent and ?priority=high&priority=urgent are equivalent.
+func splitQueryValues(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"go-tasker/schemas"
 	"log"
 	"net/http"
 
@@ -19,9 +20,20 @@ func (s *Server) RegisterRoutes() http.Handler {
 	AddListsHandlers(mux, s, apiV1)
 	AddTasksHandlers(mux, s, apiV1)
 	AddProjectsHandlers(mux, s, apiV1)
+	AddMembersHandlers(mux, s, apiV1)
+	AddLabelsHandlers(mux, s, apiV1)
+	AddSearchHandlers(mux, s, apiV1)
+	AddTrashHandlers(mux, s, apiV1)
+	AddActivitiesHandlers(mux, s, apiV1)
+	AddCommentsHandlers(mux, s, apiV1)
+	AddAttachmentsHandlers(mux, s, apiV1)
+	AddWebhooksHandlers(mux, s, apiV1)
+	AddAuthHandlers(mux, s, apiV1)
 	AddSwaggerHandler(mux)
 
-	return mux
+	s.routes = mux
+
+	return s.authenticate(s.idempotent(mux))
 }
 
 func AddSwaggerHandler(mux *http.ServeMux) {
@@ -31,27 +43,127 @@ func AddSwaggerHandler(mux *http.ServeMux) {
 }
 
 func AddListsHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
-	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists", s.GetListsHandler)
-	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{id}", s.GetListHandler)
-	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists", s.PostListsHandler)
-	mux.HandleFunc("PUT "+apiVersion+"/projects/{projectID}/lists/{id}", s.PutListHandler)
-	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/lists/{id}", s.DeleteListHandler)
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists", s.requireProjectRole(schemas.RoleViewer, s.GetListsHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{id}", s.requireProjectRole(schemas.RoleViewer, s.GetListHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists", s.requireProjectRole(schemas.RoleEditor, s.PostListsHandler))
+	mux.HandleFunc("PUT "+apiVersion+"/projects/{projectID}/lists/{id}", s.requireProjectRole(schemas.RoleEditor, s.PutListHandler))
+	mux.HandleFunc("PATCH "+apiVersion+"/projects/{projectID}/lists/{id}", s.requireProjectRole(schemas.RoleEditor, s.PatchListHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/lists/{id}", s.requireProjectRole(schemas.RoleEditor, s.DeleteListHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{id}/move", s.requireProjectRole(schemas.RoleEditor, s.PostListMoveHandler))
 }
 
 func AddTasksHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
-	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks", s.GetTasksHandler)
-	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks", s.PostTasksHandler)
-	mux.HandleFunc("PUT "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}", s.PutTaskHandler)
-	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}", s.DeleteTaskHandler)
-	mux.HandleFunc("PATCH "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/done", s.PatchTaskDoneHandler)
-	mux.HandleFunc("PATCH "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/undone", s.PatchTaskUndoneHandler)
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks", s.requireProjectRole(schemas.RoleViewer, s.GetTasksHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks", s.requireProjectRole(schemas.RoleEditor, s.PostTasksHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}", s.requireProjectRole(schemas.RoleViewer, s.GetTaskHandler))
+	mux.HandleFunc("PUT "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}", s.requireProjectRole(schemas.RoleEditor, s.PutTaskHandler))
+	mux.HandleFunc("PATCH "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}", s.requireProjectRole(schemas.RoleEditor, s.PatchTaskHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}", s.requireProjectRole(schemas.RoleEditor, s.DeleteTaskHandler))
+	mux.HandleFunc("PATCH "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/done", s.requireProjectRole(schemas.RoleEditor, s.PatchTaskDoneHandler))
+	mux.HandleFunc("PATCH "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/undone", s.requireProjectRole(schemas.RoleEditor, s.PatchTaskUndoneHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/move", s.requireProjectRole(schemas.RoleEditor, s.PostTaskMoveHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/copy", s.requireProjectRole(schemas.RoleEditor, s.PostTaskCopyHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/subtasks", s.requireProjectRole(schemas.RoleEditor, s.PostSubtasksHandler))
+	mux.HandleFunc("PUT "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/parent", s.requireProjectRole(schemas.RoleEditor, s.PutTaskParentHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/dependencies", s.requireProjectRole(schemas.RoleViewer, s.GetTaskDependenciesHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/dependencies", s.requireProjectRole(schemas.RoleEditor, s.PostTaskDependenciesHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/dependencies/{blockerID}", s.requireProjectRole(schemas.RoleEditor, s.DeleteTaskDependencyHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/occurrences", s.requireProjectRole(schemas.RoleViewer, s.GetTaskOccurrencesHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/bulk", s.requireProjectRole(schemas.RoleEditor, s.PostListTasksBulkHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/tasks/bulk", s.requireProjectRole(schemas.RoleEditor, s.PostProjectTasksBulkHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/tasks/ordered", s.requireProjectRole(schemas.RoleViewer, s.GetTasksInDependencyOrderHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/tasks/overdue", s.requireProjectRole(schemas.RoleViewer, s.GetOverdueTasksHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/tasks/due", s.requireProjectRole(schemas.RoleViewer, s.GetTasksDueHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/assignees", s.requireProjectRole(schemas.RoleEditor, s.PostTaskAssigneesHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/assignees/{userID}", s.requireProjectRole(schemas.RoleEditor, s.DeleteTaskAssigneeHandler))
+	mux.HandleFunc("GET "+apiVersion+"/me/tasks", s.GetMyTasksHandler)
 }
 
 func AddProjectsHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
 	mux.HandleFunc("GET "+apiVersion+"/projects", s.GetProjectsHandler)
 	mux.HandleFunc("POST "+apiVersion+"/projects", s.PostProjectsHandler)
-	mux.HandleFunc("PUT "+apiVersion+"/projects/{id}", s.PutProjectHandler)
-	mux.HandleFunc("DELETE "+apiVersion+"/projects/{id}", s.DeleteProjectHandler)
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}", s.requireProjectRole(schemas.RoleViewer, s.GetProjectHandler))
+	mux.HandleFunc("PUT "+apiVersion+"/projects/{projectID}", s.requireProjectRole(schemas.RoleEditor, s.PutProjectHandler))
+	mux.HandleFunc("PATCH "+apiVersion+"/projects/{projectID}", s.requireProjectRole(schemas.RoleEditor, s.PatchProjectHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}", s.requireProjectRole(schemas.RoleOwner, s.DeleteProjectHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/transitions", s.requireProjectRole(schemas.RoleViewer, s.GetProjectTransitionsHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/transitions", s.requireProjectRole(schemas.RoleEditor, s.PostProjectTransitionHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/status-history", s.requireProjectRole(schemas.RoleViewer, s.GetProjectStatusHistoryHandler))
+}
+
+func AddMembersHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/members", s.requireProjectRole(schemas.RoleViewer, s.GetMembersHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/members", s.requireProjectRole(schemas.RoleOwner, s.PostMembersHandler))
+	mux.HandleFunc("PUT "+apiVersion+"/projects/{projectID}/members/{userID}", s.requireProjectRole(schemas.RoleOwner, s.PutMemberHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/members/{userID}", s.requireProjectRole(schemas.RoleOwner, s.DeleteMemberHandler))
+}
+
+func AddLabelsHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/labels", s.requireProjectRole(schemas.RoleViewer, s.GetLabelsHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/labels", s.requireProjectRole(schemas.RoleEditor, s.PostLabelsHandler))
+	mux.HandleFunc("PUT "+apiVersion+"/projects/{projectID}/labels/{labelID}", s.requireProjectRole(schemas.RoleEditor, s.PutLabelHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/labels/{labelID}", s.requireProjectRole(schemas.RoleEditor, s.DeleteLabelHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/labels", s.requireProjectRole(schemas.RoleEditor, s.PostTaskLabelsHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/labels/{labelID}", s.requireProjectRole(schemas.RoleEditor, s.DeleteTaskLabelHandler))
+}
+
+func AddSearchHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
+	mux.HandleFunc("GET "+apiVersion+"/search", s.SearchHandler)
+}
+
+func AddTrashHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/trash", s.requireProjectRole(schemas.RoleViewer, s.GetTrashHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/trash", s.requireProjectRole(schemas.RoleOwner, s.DeleteTrashHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/trash/lists/{listID}/restore", s.requireProjectRole(schemas.RoleEditor, s.PostRestoreListHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/trash/lists/{listID}", s.requireProjectRole(schemas.RoleOwner, s.DeleteTrashListHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/trash/tasks/{taskID}/restore", s.requireProjectRole(schemas.RoleEditor, s.PostRestoreTaskHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/trash/tasks/{taskID}", s.requireProjectRole(schemas.RoleOwner, s.DeleteTrashTaskHandler))
+	mux.HandleFunc("GET "+apiVersion+"/trash/projects", s.GetDeletedProjectsHandler)
+	mux.HandleFunc("POST "+apiVersion+"/trash/projects/{projectID}/restore", s.PostRestoreProjectHandler)
+	mux.HandleFunc("DELETE "+apiVersion+"/trash/projects/{projectID}", s.DeleteTrashProjectHandler)
+}
+
+func AddActivitiesHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/events", s.requireProjectRole(schemas.RoleViewer, s.GetProjectEventsHandler))
+	mux.HandleFunc("GET "+apiVersion+"/ws", s.GetWebSocketHandler)
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/activities", s.requireProjectRole(schemas.RoleViewer, s.GetProjectActivitiesHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/activities", s.requireProjectRole(schemas.RoleViewer, s.GetTaskActivitiesHandler))
+}
+
+func AddCommentsHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/comments", s.requireProjectRole(schemas.RoleViewer, s.GetCommentsHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/comments", s.requireProjectRole(schemas.RoleEditor, s.PostCommentsHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/comments/{commentID}", s.requireProjectRole(schemas.RoleViewer, s.GetCommentHandler))
+	mux.HandleFunc("PUT "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/comments/{commentID}", s.requireProjectRole(schemas.RoleEditor, s.PutCommentHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/comments/{commentID}", s.requireProjectRole(schemas.RoleEditor, s.DeleteCommentHandler))
+	mux.HandleFunc("GET "+apiVersion+"/me/mentions", s.GetMyMentionsHandler)
+}
+
+func AddAttachmentsHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/attachments", s.requireProjectRole(schemas.RoleViewer, s.GetAttachmentsHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/attachments", s.requireProjectRole(schemas.RoleEditor, s.PostAttachmentsHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/attachments/{attachmentID}", s.requireProjectRole(schemas.RoleViewer, s.GetAttachmentHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/lists/{listID}/tasks/{taskID}/attachments/{attachmentID}", s.requireProjectRole(schemas.RoleEditor, s.DeleteAttachmentHandler))
+}
+
+func AddWebhooksHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/webhooks", s.requireProjectRole(schemas.RoleOwner, s.GetWebhooksHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/webhooks", s.requireProjectRole(schemas.RoleOwner, s.PostWebhooksHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/webhooks/{webhookID}", s.requireProjectRole(schemas.RoleOwner, s.GetWebhookHandler))
+	mux.HandleFunc("PUT "+apiVersion+"/projects/{projectID}/webhooks/{webhookID}", s.requireProjectRole(schemas.RoleOwner, s.PutWebhookHandler))
+	mux.HandleFunc("DELETE "+apiVersion+"/projects/{projectID}/webhooks/{webhookID}", s.requireProjectRole(schemas.RoleOwner, s.DeleteWebhookHandler))
+	mux.HandleFunc("GET "+apiVersion+"/projects/{projectID}/webhooks/{webhookID}/deliveries", s.requireProjectRole(schemas.RoleOwner, s.GetWebhookDeliveriesHandler))
+	mux.HandleFunc("POST "+apiVersion+"/projects/{projectID}/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", s.requireProjectRole(schemas.RoleOwner, s.PostRedeliverHandler))
+}
+
+func AddAuthHandlers(mux *http.ServeMux, s *Server, apiVersion string) {
+	mux.HandleFunc("POST "+apiVersion+"/auth/register", s.RegisterHandler)
+	mux.HandleFunc("POST "+apiVersion+"/auth/login", s.LoginHandler)
+	mux.HandleFunc("POST "+apiVersion+"/auth/stream-token", s.PostStreamTokenHandler)
+	mux.HandleFunc("GET "+apiVersion+"/me", s.GetMeHandler)
+	mux.HandleFunc("GET "+apiVersion+"/me/api-keys", s.GetAPIKeysHandler)
+	mux.HandleFunc("POST "+apiVersion+"/me/api-keys", s.PostAPIKeysHandler)
+	mux.HandleFunc("DELETE "+apiVersion+"/me/api-keys/{id}", s.DeleteAPIKeyHandler)
 }
 
 func (s *Server) HelloWorldHandler(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+	"strconv"
+)
+
+// SearchHandler godoc
+// @Summary Search projects, lists and tasks
+// @Description Full-text search over the titles of the projects, lists and tasks the current user can see. Results are ranked by relevance, carry a snippet with matches wrapped in <mark> tags, and link to their parent project and list.
+// @Tags search
+// @Produce json
+// @Param q query string true "Search terms; every word must match, as a prefix"
+// @Param limit query int false "Maximum number of results (1-100, default 50)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/search [get]
+func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	query := types.SearchQuery{Q: r.URL.Query().Get("q")}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid 'limit': must be an integer"))
+			return
+		}
+		query.Limit = limit
+	}
+	if err := utils.ValidateStruct(w, query); err != nil {
+		return
+	}
+
+	results, err := s.db.Search(user.ID, query)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Search results retrieved successfully", results)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
@@ -1,29 +1,96 @@
 package server
 
 import (
+	"crypto/rand"
 	"fmt"
+	"go-tasker/internal/auth"
 	"go-tasker/internal/database"
+	"go-tasker/internal/storage"
+	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 )
 
+const (
+	defaultTokenTTL           = 24 * time.Hour
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeInterval = time.Hour
+	defaultAttachmentMaxSize  = 25 << 20
+	defaultWebhookInterval    = time.Second
+	defaultIdempotencyKeyTTL  = 24 * time.Hour
+	defaultWSMaxLifetime      = time.Hour
+
+	// streamTokenTTL is how long a stream token can be used to open an
+	// event stream; the stream itself stays open past it
+	streamTokenTTL = time.Minute
+)
+
 type Server struct {
 	port int
 
-	db database.Service
+	db     database.Service
+	tokens *auth.TokenSigner
+
+	// allowedOrigins lists the browser origins other than the server's own
+	// that can open event streams
+	allowedOrigins []string
+
+	store             storage.Store
+	attachmentMaxSize int64
+
+	idempotencyKeyTTL time.Duration
+
+	// webhookAllowPrivate lets webhooks call addresses of the server's own
+	// network, which are refused by default
+	webhookAllowPrivate bool
+
+	// routes serves the API without authentication, for requests made by
+	// already authenticated WebSocket clients
+	routes   http.Handler
+	presence *presence
+
+	// wsMaxLifetime bounds how long a WebSocket stays open, so the
+	// credentials it was opened with are checked again at least that often
+	wsMaxLifetime time.Duration
 }
 
 func NewServer() *http.Server {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
+
+	store, err := storage.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	NewServer := &Server{
 		port: port,
 
-		db: database.New(),
+		db:     database.New(),
+		tokens: auth.NewTokenSigner(tokenSecret(), tokenTTL()),
+
+		allowedOrigins: allowedOrigins(),
+
+		store:             store,
+		attachmentMaxSize: attachmentMaxSize(),
+
+		idempotencyKeyTTL: idempotencyKeyTTL(),
+
+		webhookAllowPrivate: os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true",
+
+		presence:      newPresence(),
+		wsMaxLifetime: wsMaxLifetime(),
+	}
+
+	if retention := trashRetention(); retention > 0 {
+		go NewServer.purgeTrash(retention, trashPurgeInterval())
 	}
+	go NewServer.deliverWebhooks(webhookInterval())
+	go NewServer.expireIdempotencyKeys(time.Hour)
 
 	// Declare Server config
 	server := &http.Server{
@@ -36,3 +103,99 @@ func NewServer() *http.Server {
 
 	return server
 }
+
+// tokenSecret returns the bearer token signing key from AUTH_SECRET. Without
+// one a random key is generated, so tokens do not survive a restart.
+func tokenSecret() []byte {
+	if secret := os.Getenv("AUTH_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+
+	if os.Getenv("APP_ENV") != "test" {
+		log.Println("AUTH_SECRET is not set, using a random token signing key")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatal(err)
+	}
+	return secret
+}
+
+func tokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("AUTH_TOKEN_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultTokenTTL
+	}
+	return ttl
+}
+
+// allowedOrigins returns the comma-separated origins of ALLOWED_ORIGINS, such
+// as https://app.example.com, or * to allow any origin.
+func allowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+	return origins
+}
+
+// trashRetention returns how long deleted items are kept in the trash, from
+// TRASH_RETENTION. A value of 0 keeps them until they are purged by hand.
+func trashRetention() time.Duration {
+	retention, err := time.ParseDuration(os.Getenv("TRASH_RETENTION"))
+	if err != nil || retention < 0 {
+		return defaultTrashRetention
+	}
+	return retention
+}
+
+func trashPurgeInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("TRASH_PURGE_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return defaultTrashPurgeInterval
+	}
+	return interval
+}
+
+// webhookInterval returns how often pending webhook deliveries are checked
+// for, from WEBHOOK_INTERVAL.
+func webhookInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("WEBHOOK_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return defaultWebhookInterval
+	}
+	return interval
+}
+
+// idempotencyKeyTTL returns how long the response to a POST made with an
+// Idempotency-Key header is kept for retries, from IDEMPOTENCY_KEY_TTL.
+func idempotencyKeyTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_KEY_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultIdempotencyKeyTTL
+	}
+	return ttl
+}
+
+// wsMaxLifetime returns how long a WebSocket connection is kept open before
+// the client has to reconnect, from WS_MAX_LIFETIME.
+func wsMaxLifetime() time.Duration {
+	lifetime, err := time.ParseDuration(os.Getenv("WS_MAX_LIFETIME"))
+	if err != nil || lifetime <= 0 {
+		return defaultWSMaxLifetime
+	}
+	return lifetime
+}
+
+// attachmentMaxSize returns the largest file that can be attached to a task,
+// in bytes, from ATTACHMENT_MAX_SIZE.
+func attachmentMaxSize() int64 {
+	size, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_SIZE"), 10, 64)
+	if err != nil || size <= 0 {
+		return defaultAttachmentMaxSize
+	}
+	return size
+}
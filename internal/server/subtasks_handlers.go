@@ -0,0 +1,180 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/database"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"net/http"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// GetTaskHandler godoc
+// @Summary Get a task with its subtasks
+// @Description Get a task together with its whole subtree of subtasks. With rollup=true a task is only reported as done when it and all of its subtasks are done.
+// @Tags subtasks
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param rollup query bool false "Roll completion up from subtasks"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID} [get]
+func (s *Server) GetTaskHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	rollup, err := parseBoolParam(r, "rollup")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tree, err := s.db.GetTaskTree(projectID, listID, taskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+	setETag(w, tree.Task.Version)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Task retrieved successfully",
+		"data":    taskTreeJSON(tree, rollup != nil && *rollup),
+	})
+}
+
+// PostSubtasksHandler godoc
+// @Summary Create a subtask
+// @Description Create a new task nested under an existing task of the same list
+// @Tags subtasks
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Parent Task ID"
+// @Param task body types.CreateTaskPayload true "Create Task Payload"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/subtasks [post]
+func (s *Server) PostSubtasksHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	var createTaskPayload types.CreateTaskPayload
+	if err := utils.ParseAndValidateJSON(w, r, &createTaskPayload); err != nil {
+		return
+	}
+
+	parentID, err := strconv.ParseUint(taskID, 10, 64)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+		return
+	}
+	parent := uint(parentID)
+	createTaskPayload.ParentID = &parent
+
+	// The parent is looked up within the list, so a missing parent means the
+	// task in the URL does not exist there.
+	task, err := s.dbAs(r).CreateTask(projectID, listID, createTaskPayload)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, database.ErrParentNotInList) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+	setETag(w, task.Version)
+
+	response := utils.PrepareJSONWithMessage("Subtask created successfully", task)
+
+	utils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// PutTaskParentHandler godoc
+// @Summary Move a task under another task
+// @Description Nest a task under another task of the same list, or make it a top-level task with a null parent_id
+// @Tags subtasks
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param parent body types.SetTaskParentPayload true "Set Task Parent Payload"
+// @Param If-Match header string false "ETag of the version being updated"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/parent [put]
+func (s *Server) PutTaskParentHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	var setTaskParentPayload types.SetTaskParentPayload
+	if err := utils.ParseAndValidateJSON(w, r, &setTaskParentPayload); err != nil {
+		return
+	}
+
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := db.SetTaskParent(projectID, listID, taskID, setTaskParentPayload.ParentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+			return
+		}
+		if errors.Is(err, database.ErrTaskCycle) || errors.Is(err, database.ErrParentNotInList) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, database.ErrVersionMismatch) {
+			utils.WriteError(w, http.StatusPreconditionFailed, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+	setETag(w, task.Version)
+
+	response := utils.PrepareJSONWithMessage("Task parent updated successfully", task)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// taskTreeJSON renders a task and its subtasks. With rollup a task is only
+// done when it and its whole subtree are done.
+func taskTreeJSON(node *database.TaskTree, rollup bool) map[string]interface{} {
+	task := utils.PreparePayloadMap(node.Task)
+
+	subtasks := []interface{}{}
+	for _, child := range node.Subtasks {
+		childJSON := taskTreeJSON(child, rollup)
+		if rollup && !childJSON["done"].(bool) {
+			task["done"] = false
+		}
+		subtasks = append(subtasks, childJSON)
+	}
+	task["subtasks"] = subtasks
+
+	return task
+}
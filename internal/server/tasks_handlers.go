@@ -2,38 +2,66 @@ package server
 
 import (
 	"errors"
+	"fmt"
+	"go-tasker/internal/database"
+	"go-tasker/schemas"
 	"go-tasker/types"
 	"go-tasker/utils"
 	"net/http"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 // GetTasksHandler godoc
 // @Summary Get all tasks for a list within a project
-// @Description Get all tasks for a list within a project
+// @Description Get a page of tasks for a list within a project
 // @Tags tasks
 // @Produce json
 // @Param projectID path string true "Project ID"
 // @Param listID path string true "List ID"
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param sort query string false "Sort field, prefixed with - for descending: id, title, created_at, updated_at, priority"
+// @Param priority query string false "Comma-separated priorities to filter by (none, low, medium, high, urgent)"
+// @Param done query bool false "Filter by completion"
+// @Param labels query string false "Comma-separated label names to filter by"
+// @Param label_match query string false "any (default) to match tasks with at least one label, all to require every label"
+// @Param created_after query string false "Only tasks created after this RFC 3339 timestamp"
+// @Param created_before query string false "Only tasks created before this RFC 3339 timestamp"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/projects/{projectID}/lists/{listID}/tasks [get]
 func (s *Server) GetTasksHandler(w http.ResponseWriter, r *http.Request) {
 	projectID := r.PathValue("projectID")
 	listID := r.PathValue("listID")
 
-	tasks, err := s.db.GetTasks(projectID, listID)
+	query, err := parseTaskQuery(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.ValidateStruct(w, query); err != nil {
+		return
+	}
+
+	tasks, nextCursor, err := s.db.GetTasks(projectID, listID, query)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, "List not found", http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, database.ErrInvalidCursor) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
 		http.Error(w, "Error getting tasks", http.StatusInternalServerError)
 		return
 	}
 
-	response := utils.PrepareJSONWithMessage("Tasks retrieved successfully", tasks)
+	response := utils.PrepareJSONWithPagination("Tasks retrieved successfully", tasks, nextCursor)
 
 	utils.WriteJSON(w, http.StatusOK, response)
 }
@@ -60,11 +88,16 @@ func (s *Server) PostTasksHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := s.db.CreateTask(projectID, listID, createTaskPayload)
+	task, err := s.dbAs(r).CreateTask(projectID, listID, createTaskPayload)
 	if err != nil {
+		if errors.Is(err, database.ErrParentNotInList) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
 		utils.WriteInternalServerError(w, err)
 		return
 	}
+	setETag(w, task.Version)
 
 	response := utils.PrepareJSONWithMessage("Task created successfully", task)
 
@@ -81,8 +114,10 @@ func (s *Server) PostTasksHandler(w http.ResponseWriter, r *http.Request) {
 // @Param listID path string true "List ID"
 // @Param taskID path string true "Task ID"
 // @Param task body types.UpdateTaskPayload true "Update Task Payload"
+// @Param If-Match header string false "ETag of the version being updated"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID} [put]
 func (s *Server) PutTaskHandler(w http.ResponseWriter, r *http.Request) {
@@ -95,11 +130,78 @@ func (s *Server) PutTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := s.db.UpdateTask(projectID, listID, taskID, updateTaskPayload)
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := db.UpdateTask(projectID, listID, taskID, updateTaskPayload)
 	if err != nil {
+		if errors.Is(err, database.ErrTaskBlocked) {
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		}
+		if errors.Is(err, database.ErrVersionMismatch) {
+			utils.WriteError(w, http.StatusPreconditionFailed, err)
+			return
+		}
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
+	setETag(w, task.Version)
+
+	response := utils.PrepareJSONWithMessage("Task updated successfully", task)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PatchTaskHandler godoc
+// @Summary Partially update a task within a list and project
+// @Description Apply a JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json) to the fields of UpdateTaskPayload; fields the patch leaves out keep their value
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Param taskID path string true "Task ID"
+// @Param patch body object true "Merge patch or JSON Patch"
+// @Param If-Match header string false "ETag of the version being updated"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID} [patch]
+func (s *Server) PatchTaskHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+	taskID := r.PathValue("taskID")
+
+	var task *schemas.Task
+	load := func(db database.Service) (types.UpdateTaskPayload, uint, error) {
+		current, err := db.GetTask(projectID, listID, taskID)
+		if err != nil {
+			return types.UpdateTaskPayload{}, 0, err
+		}
+		return types.UpdateTaskPayload{
+			Title:      current.Title,
+			Done:       current.Done,
+			Priority:   current.Priority,
+			StartAt:    current.StartAt,
+			DueAt:      current.DueAt,
+			Recurrence: current.Recurrence,
+			AnchorAt:   current.AnchorAt,
+		}, current.Version, nil
+	}
+	save := func(db database.Service, payload types.UpdateTaskPayload) (err error) {
+		task, err = db.UpdateTask(projectID, listID, taskID, payload)
+		return err
+	}
+	if !patchEntity(s, w, r, "task", load, save) {
+		return
+	}
+	setETag(w, task.Version)
 
 	response := utils.PrepareJSONWithMessage("Task updated successfully", task)
 
@@ -113,8 +215,10 @@ func (s *Server) PutTaskHandler(w http.ResponseWriter, r *http.Request) {
 // @Param projectID path string true "Project ID"
 // @Param listID path string true "List ID"
 // @Param taskID path string true "Task ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID} [delete]
 func (s *Server) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
@@ -122,8 +226,17 @@ func (s *Server) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	listID := r.PathValue("listID")
 	taskID := r.PathValue("taskID")
 
-	err := s.db.DeleteTask(projectID, listID, taskID)
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err := db.DeleteTask(projectID, listID, taskID)
 	if err != nil {
+		if errors.Is(err, database.ErrVersionMismatch) {
+			utils.WriteError(w, http.StatusPreconditionFailed, err)
+			return
+		}
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -142,8 +255,12 @@ func (s *Server) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 // @Param projectID path string true "Project ID"
 // @Param listID path string true "List ID"
 // @Param taskID path string true "Task ID"
+// @Param force query bool false "Complete the task even if tasks blocking it are still open"
+// @Param If-Match header string false "ETag of the version being updated"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/done [patch]
 func (s *Server) PatchTaskDoneHandler(w http.ResponseWriter, r *http.Request) {
@@ -151,14 +268,35 @@ func (s *Server) PatchTaskDoneHandler(w http.ResponseWriter, r *http.Request) {
 	listID := r.PathValue("listID")
 	taskID := r.PathValue("taskID")
 
+	force, err := parseBoolParam(r, "force")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	var updateTaskDonePayload types.UpdateTaskDonePayload
 	updateTaskDonePayload.Done = true
+	updateTaskDonePayload.Force = force != nil && *force
 
-	task, err := s.db.UpdateTaskDone(projectID, listID, taskID, updateTaskDonePayload)
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := db.UpdateTaskDone(projectID, listID, taskID, updateTaskDonePayload)
 	if err != nil {
+		if errors.Is(err, database.ErrTaskBlocked) {
+			utils.WriteError(w, http.StatusConflict, fmt.Errorf("%w; pass force=true to complete it anyway", err))
+			return
+		}
+		if errors.Is(err, database.ErrVersionMismatch) {
+			utils.WriteError(w, http.StatusPreconditionFailed, err)
+			return
+		}
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
+	setETag(w, task.Version)
 
 	response := utils.PrepareJSONWithMessage("Task marked as done successfully", task)
 
@@ -174,8 +312,10 @@ func (s *Server) PatchTaskDoneHandler(w http.ResponseWriter, r *http.Request) {
 // @Param projectID path string true "Project ID"
 // @Param listID path string true "List ID"
 // @Param taskID path string true "Task ID"
+// @Param If-Match header string false "ETag of the version being updated"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 412 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/projects/{projectID}/lists/{listID}/tasks/{taskID}/undone [patch]
 func (s *Server) PatchTaskUndoneHandler(w http.ResponseWriter, r *http.Request) {
@@ -186,13 +326,99 @@ func (s *Server) PatchTaskUndoneHandler(w http.ResponseWriter, r *http.Request)
 	var updateTaskDonePayload types.UpdateTaskDonePayload
 	updateTaskDonePayload.Done = false
 
-	task, err := s.db.UpdateTaskDone(projectID, listID, taskID, updateTaskDonePayload)
+	db, ok := s.dbIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := db.UpdateTaskDone(projectID, listID, taskID, updateTaskDonePayload)
 	if err != nil {
+		if errors.Is(err, database.ErrVersionMismatch) {
+			utils.WriteError(w, http.StatusPreconditionFailed, err)
+			return
+		}
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
+	setETag(w, task.Version)
 
 	response := utils.PrepareJSONWithMessage("Task marked as undone successfully", task)
 
 	utils.WriteJSON(w, http.StatusOK, response)
 }
+
+// GetOverdueTasksHandler godoc
+// @Summary Get overdue tasks for a project
+// @Description Get all open tasks across a project whose due date has passed
+// @Tags tasks
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/tasks/overdue [get]
+func (s *Server) GetOverdueTasksHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	tasks, err := s.db.GetOverdueTasks(projectID, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Overdue tasks retrieved successfully", tasks)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetTasksDueHandler godoc
+// @Summary Get tasks due in a time window for a project
+// @Description Get all tasks across a project with a due date in [from, to). Both bounds are RFC 3339 timestamps.
+// @Tags tasks
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param from query string true "Window start (RFC 3339)"
+// @Param to query string true "Window end (RFC 3339)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/tasks/due [get]
+func (s *Server) GetTasksDueHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or missing 'from' timestamp"))
+		return
+	}
+
+	to, err := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or missing 'to' timestamp"))
+		return
+	}
+
+	if !from.Before(to) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("'from' must be before 'to'"))
+		return
+	}
+
+	tasks, err := s.db.GetTasksDueBetween(projectID, from, to)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Tasks retrieved successfully", tasks)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/internal/database"
+	"go-tasker/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetTrashHandler godoc
+// @Summary Get a project's trash
+// @Description Get the deleted lists and tasks of a project, most recently deleted first
+// @Tags trash
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/trash [get]
+func (s *Server) GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	lists, tasks, err := s.db.GetTrash(projectID)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	listsJSON := []interface{}{}
+	for _, list := range lists {
+		listsJSON = append(listsJSON, utils.PreparePayloadMap(list))
+	}
+	tasksJSON := []interface{}{}
+	for _, task := range tasks {
+		tasksJSON = append(tasksJSON, utils.PreparePayloadMap(task))
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Trash retrieved successfully",
+		"data": map[string]interface{}{
+			"lists": listsJSON,
+			"tasks": tasksJSON,
+		},
+	})
+}
+
+// DeleteTrashHandler godoc
+// @Summary Empty a project's trash
+// @Description Permanently delete every deleted list and task of a project
+// @Tags trash
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/trash [delete]
+func (s *Server) DeleteTrashHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	if err := s.dbAs(r).EmptyTrash(projectID); err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	s.deleteUnusedBlobs()
+
+	response := utils.PrepareJSONWithMessage("Trash emptied successfully", nil)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostRestoreListHandler godoc
+// @Summary Restore a deleted list
+// @Description Restore a deleted list together with the tasks deleted along with it
+// @Tags trash
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/trash/lists/{listID}/restore [post]
+func (s *Server) PostRestoreListHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+
+	list, err := s.dbAs(r).RestoreList(projectID, listID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("deleted list not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("List restored successfully", list)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteTrashListHandler godoc
+// @Summary Permanently delete a deleted list
+// @Description Permanently delete a list from the trash together with all of its tasks
+// @Tags trash
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param listID path string true "List ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/trash/lists/{listID} [delete]
+func (s *Server) DeleteTrashListHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	listID := r.PathValue("listID")
+
+	if err := s.dbAs(r).PurgeList(projectID, listID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("deleted list not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	s.deleteUnusedBlobs()
+
+	response := utils.PrepareJSONWithMessage("List permanently deleted", nil)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostRestoreTaskHandler godoc
+// @Summary Restore a deleted task
+// @Description Restore a deleted task together with the subtasks deleted along with it. Its list and parent task must be restored first.
+// @Tags trash
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param taskID path string true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/trash/tasks/{taskID}/restore [post]
+func (s *Server) PostRestoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	taskID := r.PathValue("taskID")
+
+	task, err := s.dbAs(r).RestoreTask(projectID, taskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("deleted task not found"))
+			return
+		}
+		if errors.Is(err, database.ErrRestoreParentFirst) {
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Task restored successfully", task)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteTrashTaskHandler godoc
+// @Summary Permanently delete a deleted task
+// @Description Permanently delete a task from the trash together with all of its subtasks
+// @Tags trash
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param taskID path string true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/trash/tasks/{taskID} [delete]
+func (s *Server) DeleteTrashTaskHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	taskID := r.PathValue("taskID")
+
+	if err := s.dbAs(r).PurgeTask(projectID, taskID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("deleted task not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	s.deleteUnusedBlobs()
+
+	response := utils.PrepareJSONWithMessage("Task permanently deleted", nil)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetDeletedProjectsHandler godoc
+// @Summary Get deleted projects
+// @Description Get the deleted projects owned by the current user
+// @Tags trash
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/trash/projects [get]
+func (s *Server) GetDeletedProjectsHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	projects, err := s.db.GetDeletedProjects(user.ID)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Deleted projects retrieved successfully", projects)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostRestoreProjectHandler godoc
+// @Summary Restore a deleted project
+// @Description Restore a deleted project owned by the current user, together with the lists and tasks deleted along with it
+// @Tags trash
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/trash/projects/{projectID}/restore [post]
+func (s *Server) PostRestoreProjectHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	user := auth.UserFromContext(r.Context())
+
+	project, err := s.dbAs(r).RestoreProject(projectID, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("deleted project not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Project restored successfully", project)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteTrashProjectHandler godoc
+// @Summary Permanently delete a deleted project
+// @Description Permanently delete a project owned by the current user from the trash, with everything in it
+// @Tags trash
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/trash/projects/{projectID} [delete]
+func (s *Server) DeleteTrashProjectHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	user := auth.UserFromContext(r.Context())
+
+	if err := s.dbAs(r).PurgeProject(projectID, user.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("deleted project not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	s.deleteUnusedBlobs()
+
+	response := utils.PrepareJSONWithMessage("Project permanently deleted", nil)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// purgeTrash permanently deletes everything that has been in the trash for
+// longer than the retention period, checking once per interval, along with
+// the attachment content nothing refers to any more.
+func (s *Server) purgeTrash(retention time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purged, err := s.db.PurgeDeleted(time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("purging trash: %v", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("purged %d items from the trash", purged)
+		}
+		s.deleteUnusedBlobs()
+	}
+}
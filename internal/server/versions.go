@@ -0,0 +1,39 @@
+package server
+
+import (
+	"go-tasker/internal/database"
+	"go-tasker/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setETag tags the response with the version of the project, list or task
+// it carries, for clients to send back in If-Match.
+func setETag(w http.ResponseWriter, version uint) {
+	w.Header().Set("ETag", `"`+strconv.FormatUint(uint64(version), 10)+`"`)
+}
+
+// dbIfMatch returns the database service acting as the current user and,
+// when the request has an If-Match header, only changing the entity while
+// it is at the version named there. A header naming no version the API
+// hands out can never match, so it gets a 412 response right away and
+// dbIfMatch returns false.
+func (s *Server) dbIfMatch(w http.ResponseWriter, r *http.Request) (database.Service, bool) {
+	db := s.dbAs(r)
+
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return db, true
+	}
+
+	tag, quoted := strings.CutPrefix(ifMatch, `"`)
+	tag, closed := strings.CutSuffix(tag, `"`)
+	version, err := strconv.ParseUint(tag, 10, 64)
+	if !quoted || !closed || err != nil {
+		utils.WriteError(w, http.StatusPreconditionFailed, database.ErrVersionMismatch)
+		return nil, false
+	}
+
+	return db.IfMatch(uint(version)), true
+}
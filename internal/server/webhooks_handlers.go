@@ -0,0 +1,361 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-tasker/internal/database"
+	"go-tasker/internal/webhook"
+	"go-tasker/schemas"
+	"go-tasker/types"
+	"go-tasker/utils"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	webhookBatchSize   = 10
+	webhookTimeout     = 10 * time.Second
+	webhookMaxAttempts = 8
+
+	// webhookRetryBase is the delay before the first retry, doubled for
+	// every further attempt
+	webhookRetryBase = 30 * time.Second
+
+	// webhookLease keeps claimed deliveries from being claimed again while
+	// a batch is being sent
+	webhookLease = 2 * webhookBatchSize * webhookTimeout
+)
+
+// GetWebhooksHandler godoc
+// @Summary Get the webhooks of a project
+// @Description Get the webhooks registered on a project
+// @Tags webhooks
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/webhooks [get]
+func (s *Server) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	webhooks, err := s.db.GetWebhooks(projectID)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Webhooks retrieved successfully", webhooks)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostWebhooksHandler godoc
+// @Summary Register a webhook
+// @Description Register a URL to be called with the project events matching its filters, such as task.created, task.* or *. The signing secret is only returned once.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param webhook body types.CreateWebhookPayload true "Create Webhook Payload"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/webhooks [post]
+func (s *Server) PostWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+
+	var createWebhookPayload types.CreateWebhookPayload
+	if err := utils.ParseAndValidateJSON(w, r, &createWebhookPayload); err != nil {
+		return
+	}
+	if !s.checkWebhookURL(w, r, createWebhookPayload.URL) {
+		return
+	}
+
+	hook, err := s.db.CreateWebhook(projectID, createWebhookPayload)
+	if err != nil {
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Webhook created successfully", hook)
+	response["data"].(map[string]interface{})["secret"] = hook.Secret
+
+	utils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// GetWebhookHandler godoc
+// @Summary Get a webhook
+// @Description Get a webhook of a project
+// @Tags webhooks
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param webhookID path string true "Webhook ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/webhooks/{webhookID} [get]
+func (s *Server) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	webhookID := r.PathValue("webhookID")
+
+	hook, err := s.db.GetWebhook(projectID, webhookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("webhook not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Webhook retrieved successfully", hook)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PutWebhookHandler godoc
+// @Summary Update a webhook
+// @Description Update the URL, event filters and active state of a webhook
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param webhookID path string true "Webhook ID"
+// @Param webhook body types.UpdateWebhookPayload true "Update Webhook Payload"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/webhooks/{webhookID} [put]
+func (s *Server) PutWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	webhookID := r.PathValue("webhookID")
+
+	var updateWebhookPayload types.UpdateWebhookPayload
+	if err := utils.ParseAndValidateJSON(w, r, &updateWebhookPayload); err != nil {
+		return
+	}
+	if !s.checkWebhookURL(w, r, updateWebhookPayload.URL) {
+		return
+	}
+
+	hook, err := s.db.UpdateWebhook(projectID, webhookID, updateWebhookPayload)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("webhook not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Webhook updated successfully", hook)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteWebhookHandler godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook. Deliveries still pending are not sent.
+// @Tags webhooks
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param webhookID path string true "Webhook ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/webhooks/{webhookID} [delete]
+func (s *Server) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	webhookID := r.PathValue("webhookID")
+
+	if err := s.db.DeleteWebhook(projectID, webhookID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("webhook not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Webhook deleted successfully", nil)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetWebhookDeliveriesHandler godoc
+// @Summary Get the deliveries of a webhook
+// @Description Get a page of the delivery log of a webhook, newest first, with the outcome of the last attempt of each delivery
+// @Tags webhooks
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param webhookID path string true "Webhook ID"
+// @Param limit query int false "Page size (1-100, default 50)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/webhooks/{webhookID}/deliveries [get]
+func (s *Server) GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	webhookID := r.PathValue("webhookID")
+
+	page, err := parsePageQuery(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.ValidateStruct(w, page); err != nil {
+		return
+	}
+
+	deliveries, nextCursor, err := s.db.GetWebhookDeliveries(projectID, webhookID, page)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("webhook not found"))
+			return
+		}
+		if errors.Is(err, database.ErrInvalidCursor) {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithPagination("Deliveries retrieved successfully", deliveries, nextCursor)
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// PostRedeliverHandler godoc
+// @Summary Redeliver an event
+// @Description Queue a new delivery of the event of an earlier delivery, to be sent right away
+// @Tags webhooks
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param webhookID path string true "Webhook ID"
+// @Param deliveryID path string true "Delivery ID"
+// @Success 201 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/projects/{projectID}/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver [post]
+func (s *Server) PostRedeliverHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("projectID")
+	webhookID := r.PathValue("webhookID")
+	deliveryID := r.PathValue("deliveryID")
+
+	delivery, err := s.db.RedeliverWebhookDelivery(projectID, webhookID, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("delivery not found"))
+			return
+		}
+		utils.WriteInternalServerError(w, err)
+		return
+	}
+
+	response := utils.PrepareJSONWithMessage("Delivery queued successfully", delivery)
+
+	utils.WriteJSON(w, http.StatusCreated, response)
+}
+
+// checkWebhookURL refuses webhook URLs pointing to the server's own network,
+// unless WEBHOOK_ALLOW_PRIVATE_NETWORKS is set. It writes the error response
+// itself and reports whether the URL can be used.
+func (s *Server) checkWebhookURL(w http.ResponseWriter, r *http.Request, url string) bool {
+	if s.webhookAllowPrivate {
+		return true
+	}
+	if err := webhook.CheckURL(r.Context(), url); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// deliverWebhooks sends the pending webhook deliveries that are due,
+// checking once per interval.
+func (s *Server) deliverWebhooks(interval time.Duration) {
+	client := webhook.NewClient(webhookTimeout, s.webhookAllowPrivate)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		deliveries, err := s.db.ClaimWebhookDeliveries(webhookBatchSize, webhookLease)
+		if err != nil {
+			log.Printf("claiming webhook deliveries: %v", err)
+			continue
+		}
+
+		s.attemptDeliveries(client, deliveries)
+	}
+}
+
+// attemptDeliveries sends a batch of deliveries and waits for all of them.
+// Each webhook gets its own goroutine, so a slow subscriber does not hold up
+// the others, while its own deliveries are sent one at a time, in order.
+func (s *Server) attemptDeliveries(client *http.Client, deliveries []schemas.WebhookDelivery) {
+	byWebhook := make(map[uint][]*schemas.WebhookDelivery)
+	for i := range deliveries {
+		webhookID := deliveries[i].WebhookID
+		byWebhook[webhookID] = append(byWebhook[webhookID], &deliveries[i])
+	}
+
+	var wg sync.WaitGroup
+	for _, queue := range byWebhook {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, delivery := range queue {
+				s.attemptDelivery(client, delivery)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// attemptDelivery sends a delivery once and records the outcome. Failed
+// deliveries are retried with exponential backoff until they run out of
+// attempts.
+func (s *Server) attemptDelivery(client *http.Client, delivery *schemas.WebhookDelivery) {
+	result, err := webhook.Send(context.Background(), client, delivery.Webhook.URL, delivery.Webhook.Secret,
+		delivery.ID, delivery.Event, delivery.Payload)
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.ResponseStatus = result.StatusCode
+	delivery.Error = ""
+
+	if err == nil && result.OK() {
+		delivery.Status = schemas.DeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	} else {
+		if err != nil {
+			delivery.Error = err.Error()
+		} else {
+			delivery.Error = fmt.Sprintf("subscriber responded with %d", result.StatusCode)
+		}
+
+		if delivery.Attempts >= webhookMaxAttempts {
+			delivery.Status = schemas.DeliveryFailed
+			delivery.NextAttemptAt = nil
+		} else {
+			next := now.Add(webhookRetryBase << (delivery.Attempts - 1))
+			delivery.NextAttemptAt = &next
+		}
+	}
+
+	if err := s.db.SaveWebhookDeliveryAttempt(delivery); err != nil {
+		log.Printf("saving webhook delivery %d: %v", delivery.ID, err)
+	}
+}
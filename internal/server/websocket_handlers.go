@@ -0,0 +1,404 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-tasker/internal/auth"
+	"go-tasker/internal/events"
+	"go-tasker/schemas"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+)
+
+// Message types of the collaboration channel. Clients send subscribe,
+// unsubscribe and request messages; the server sends the others.
+const (
+	wsSubscribe    = "subscribe"
+	wsUnsubscribe  = "unsubscribe"
+	wsRequest      = "request"
+	wsEvent        = "event"
+	wsPresence     = "presence"
+	wsResponse     = "response"
+	wsReset        = "reset"
+	wsUnsubscribed = "unsubscribed"
+	wsError        = "error"
+)
+
+// Browsers cannot set headers on a WebSocket, so they pass a stream token as
+// a "bearer.<token>" subprotocol, along with wsProtocol for the server to
+// select.
+const (
+	wsProtocol            = "tasker"
+	wsTokenProtocolPrefix = "bearer."
+)
+
+// wsClientBuffer is how many messages a client may fall behind before it is
+// disconnected.
+const wsClientBuffer = 64
+
+// wsRequestPath matches the task routes clients can call over the channel.
+var wsRequestPath = regexp.MustCompile(`^/projects/[0-9]+/lists/[0-9]+/tasks(/[a-z0-9_-]+)*$`)
+
+type wsMessage struct {
+	Type        string          `json:"type"`
+	ID          string          `json:"id"`
+	ProjectID   uint            `json:"project_id"`
+	LastEventID string          `json:"last_event_id"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	IfMatch     string          `json:"if_match"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// wsClient is a connection to the collaboration channel. Messages to it are
+// queued and written by a single goroutine.
+type wsClient struct {
+	conn *websocket.Conn
+	user *schemas.User
+
+	send      chan any
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu   sync.Mutex
+	subs map[uint]*events.Subscription
+}
+
+// queue sends a message to the client without blocking. Clients that fall
+// too far behind are disconnected.
+func (c *wsClient) queue(message any) {
+	select {
+	case c.send <- message:
+	case <-c.done:
+	default:
+		c.close()
+	}
+}
+
+func (c *wsClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func (c *wsClient) writeLoop() {
+	for {
+		select {
+		case message := <-c.send:
+			if err := websocket.JSON.Send(c.conn, message); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *wsClient) errorf(id string, message string) {
+	c.queue(map[string]any{"type": wsError, "id": id, "message": message})
+}
+
+// presence tracks which clients follow each project over the collaboration
+// channel.
+type presence struct {
+	mu       sync.Mutex
+	projects map[uint]map[*wsClient]struct{}
+}
+
+func newPresence() *presence {
+	return &presence{projects: make(map[uint]map[*wsClient]struct{})}
+}
+
+func (p *presence) join(projectID uint, client *wsClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.projects[projectID] == nil {
+		p.projects[projectID] = make(map[*wsClient]struct{})
+	}
+	p.projects[projectID][client] = struct{}{}
+	p.broadcast(projectID)
+}
+
+func (p *presence) leave(projectID uint, client *wsClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.projects[projectID], client)
+	if len(p.projects[projectID]) == 0 {
+		delete(p.projects, projectID)
+		return
+	}
+	p.broadcast(projectID)
+}
+
+// broadcast sends the users following a project to all of its clients. A
+// user connected more than once is listed once.
+func (p *presence) broadcast(projectID uint) {
+	users := []map[string]any{}
+	var seen []uint
+	for client := range p.projects[projectID] {
+		if slices.Contains(seen, client.user.ID) {
+			continue
+		}
+		seen = append(seen, client.user.ID)
+		users = append(users, map[string]any{"id": client.user.ID, "username": client.user.Username})
+	}
+	slices.SortFunc(users, func(a, b map[string]any) int {
+		return strings.Compare(a["username"].(string), b["username"].(string))
+	})
+
+	message := map[string]any{"type": wsPresence, "project_id": projectID, "users": users}
+	for client := range p.projects[projectID] {
+		client.queue(message)
+	}
+}
+
+// GetWebSocketHandler godoc
+// @Summary Open the collaboration channel
+// @Description Upgrade to a WebSocket carrying JSON messages. Send {"type": "subscribe", "project_id": n} to receive the change events of a project and the presence of the other users following it, and {"type": "request", "id": "...", "method": "POST", "path": "/projects/n/lists/n/tasks", "body": {...}} to change tasks as with the REST API. Browsers authenticate with a stream token, passed in access_token or offered as a bearer.<token> subprotocol next to tasker, and pages from origins outside ALLOWED_ORIGINS are refused. Connections are closed after WS_MAX_LIFETIME, when the client has to reconnect.
+// @Tags events
+// @Param access_token query string false "Stream token"
+// @Success 101 {string} string
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/ws [get]
+func (s *Server) GetWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	server := websocket.Server{
+		Handler: s.serveCollaboration,
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			if !s.allowedOrigin(r) {
+				return fmt.Errorf("origin not allowed")
+			}
+
+			// Only wsProtocol is ever selected, so the stream token is not
+			// echoed back
+			offered := config.Protocol
+			config.Protocol = nil
+			if slices.Contains(offered, wsProtocol) {
+				config.Protocol = []string{wsProtocol}
+			}
+			return nil
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+func (s *Server) serveCollaboration(conn *websocket.Conn) {
+	// The connection is hijacked with the server's read and write
+	// deadlines still set
+	conn.SetDeadline(time.Time{})
+
+	client := &wsClient{
+		conn: conn,
+		user: auth.UserFromContext(conn.Request().Context()),
+		send: make(chan any, wsClientBuffer),
+		done: make(chan struct{}),
+		subs: make(map[uint]*events.Subscription),
+	}
+	go client.writeLoop()
+
+	// Clients reconnect with fresh credentials once the connection expires
+	lifetime := time.AfterFunc(s.wsMaxLifetime, client.close)
+	defer lifetime.Stop()
+
+	defer func() {
+		client.mu.Lock()
+		projectIDs := make([]uint, 0, len(client.subs))
+		for projectID := range client.subs {
+			projectIDs = append(projectIDs, projectID)
+		}
+		client.mu.Unlock()
+
+		for _, projectID := range projectIDs {
+			s.unsubscribe(client, projectID)
+		}
+		client.close()
+	}()
+
+	for {
+		var message wsMessage
+		if err := websocket.JSON.Receive(conn, &message); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				client.errorf("", "messages must be JSON objects")
+				continue
+			}
+			return
+		}
+
+		switch message.Type {
+		case wsSubscribe:
+			s.subscribe(client, message)
+		case wsUnsubscribe:
+			s.unsubscribe(client, message.ProjectID)
+		case wsRequest:
+			s.dispatch(client, message)
+		default:
+			client.errorf(message.ID, "unknown message type")
+		}
+	}
+}
+
+// subscribe starts forwarding the events of a project to the client,
+// replaying those after LastEventID when it is set.
+func (s *Server) subscribe(client *wsClient, message wsMessage) {
+	_, err := s.db.GetProjectRole(strconv.FormatUint(uint64(message.ProjectID), 10), client.user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			client.errorf(message.ID, "project not found")
+			return
+		}
+		client.errorf(message.ID, "internal server error")
+		return
+	}
+
+	client.mu.Lock()
+	if _, ok := client.subs[message.ProjectID]; ok {
+		client.mu.Unlock()
+		return
+	}
+	hub := s.db.Events()
+	sub, replay, ok := hub.Subscribe(message.ProjectID, client.user.ID, message.LastEventID)
+	client.subs[message.ProjectID] = sub
+	client.mu.Unlock()
+
+	if !ok {
+		client.queue(map[string]any{"type": wsReset, "project_id": message.ProjectID})
+	}
+	for _, event := range replay {
+		client.queue(eventMessage(event))
+	}
+	s.presence.join(message.ProjectID, client)
+
+	go func() {
+		for event := range sub.C {
+			client.queue(eventMessage(event))
+		}
+
+		// The hub drops subscribers that fall behind, which can subscribe
+		// again with the ID of the last event they received, and those of
+		// users who may no longer follow the project
+		client.mu.Lock()
+		dropped := client.subs[message.ProjectID] == sub
+		if dropped {
+			delete(client.subs, message.ProjectID)
+		}
+		client.mu.Unlock()
+
+		if dropped {
+			s.presence.leave(message.ProjectID, client)
+			client.queue(map[string]any{"type": wsUnsubscribed, "project_id": message.ProjectID})
+		}
+	}()
+}
+
+func (s *Server) unsubscribe(client *wsClient, projectID uint) {
+	client.mu.Lock()
+	sub, ok := client.subs[projectID]
+	delete(client.subs, projectID)
+	client.mu.Unlock()
+
+	if ok {
+		s.db.Events().Unsubscribe(sub)
+		s.presence.leave(projectID, client)
+	}
+}
+
+// dispatch serves a request message with the REST handler of its route, as
+// the client's user, so it goes through the same access checks and
+// validation as over HTTP. The user is loaded again for every request, and
+// the connection is closed once it no longer exists.
+func (s *Server) dispatch(client *wsClient, message wsMessage) {
+	target, err := url.Parse(message.Path)
+	if err != nil || !wsRequestPath.MatchString(target.Path) {
+		client.errorf(message.ID, "path must be a task route such as /projects/1/lists/1/tasks")
+		return
+	}
+	if !slices.Contains([]string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}, message.Method) {
+		client.errorf(message.ID, "method must be POST, PUT, PATCH or DELETE")
+		return
+	}
+
+	var body io.Reader = http.NoBody
+	if len(message.Body) > 0 {
+		body = bytes.NewReader(message.Body)
+	}
+
+	user, err := s.db.GetUser(client.user.ID)
+	if err != nil {
+		client.close()
+		return
+	}
+
+	ctx := auth.WithUser(client.conn.Request().Context(), user)
+	req, err := http.NewRequestWithContext(ctx, message.Method, "/api/v1"+target.RequestURI(), body)
+	if err != nil {
+		client.errorf(message.ID, "invalid request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if message.IfMatch != "" {
+		req.Header.Set("If-Match", message.IfMatch)
+	}
+
+	response := &responseBuffer{header: make(http.Header)}
+	s.routes.ServeHTTP(response, req)
+
+	reply := map[string]any{"type": wsResponse, "id": message.ID, "status": response.status}
+	if etag := response.header.Get("ETag"); etag != "" {
+		reply["etag"] = etag
+	}
+	if json.Valid(response.body.Bytes()) {
+		reply["body"] = json.RawMessage(response.body.Bytes())
+	}
+	client.queue(reply)
+}
+
+func eventMessage(event events.Event) map[string]any {
+	return map[string]any{
+		"type":       wsEvent,
+		"id":         event.ID,
+		"project_id": event.ProjectID,
+		"event":      event.Name,
+		"data":       json.RawMessage(event.Data),
+	}
+}
+
+// responseBuffer captures the response of a handler called on behalf of a
+// collaboration channel client.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
@@ -0,0 +1,52 @@
+// Package storage keeps the content of attachments in a blob store, keyed by
+// the hex SHA-256 hash of the content.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const defaultDir = "data/attachments"
+
+var ErrNotFound = errors.New("blob not found")
+
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any blob
+	// already stored there.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+
+	// Get opens the blob stored under key. It returns ErrNotFound when
+	// there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the blob stored under key. Deleting a missing blob is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the store selected by STORAGE_DRIVER: the local filesystem
+// under STORAGE_DIR by default, or an S3-compatible bucket with "s3".
+func New() (Store, error) {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = defaultDir
+		}
+		return NewLocal(dir)
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+	}
+}
@@ -0,0 +1,216 @@
+// Package webhook signs and sends the HTTP callbacks that notify webhook
+// subscribers of changes to a project.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-tasker/schemas"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// maxResponseBody bounds how much of a subscriber's response is read before
+// the connection is reused.
+const maxResponseBody = 4 << 10
+
+// ErrForbiddenAddress is returned when a webhook URL points to, or a
+// delivery would connect to, an address of the server's own network.
+var ErrForbiddenAddress = errors.New("webhook URLs must not point to loopback, private, link-local or unspecified addresses")
+
+var (
+	entities = []string{
+		schemas.EntityProject, schemas.EntityList, schemas.EntityTask, schemas.EntityLabel,
+		schemas.EntityMember, schemas.EntityComment, schemas.EntityAttachment,
+	}
+	actions = []string{
+		schemas.ActionCreated, schemas.ActionUpdated, schemas.ActionDeleted, schemas.ActionDone,
+		schemas.ActionUndone, schemas.ActionMoved, schemas.ActionRestored, schemas.ActionPurged,
+		schemas.ActionAssigned, schemas.ActionUnassigned, schemas.ActionLabeled, schemas.ActionUnlabeled,
+		schemas.ActionBlocked, schemas.ActionUnblocked,
+	}
+)
+
+// Event returns the name of the event sent for an action on an entity, such
+// as task.done.
+func Event(entityType string, action string) string {
+	return entityType + "." + action
+}
+
+// ValidFilter reports whether filter names events a webhook can subscribe
+// to: a single event such as task.created, every event about an entity
+// such as task.*, or * for all events.
+func ValidFilter(filter string) bool {
+	if filter == "*" {
+		return true
+	}
+
+	entity, action, ok := strings.Cut(filter, ".")
+	return ok && slices.Contains(entities, entity) && (action == "*" || slices.Contains(actions, action))
+}
+
+// Matches reports whether any of the filters selects the event.
+func Matches(filters []string, event string) bool {
+	entity, _, _ := strings.Cut(event, ".")
+	for _, filter := range filters {
+		if filter == "*" || filter == event || filter == entity+".*" {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSecret returns a random signing secret for a webhook.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Sign returns the signature of a delivery: the hex HMAC-SHA256, keyed with
+// the webhook secret, of the Unix timestamp and the body joined by a dot.
+// Covering the timestamp lets subscribers reject replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Result is the outcome of a delivery attempt. The response body is not
+// kept, so a subscriber cannot use deliveries to read back what it serves.
+type Result struct {
+	StatusCode int
+}
+
+// OK reports whether the subscriber accepted the delivery.
+func (r Result) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Send POSTs a signed delivery to url. An error means the subscriber could
+// not be reached; any response it sends is returned as the result.
+func Send(ctx context.Context, client *http.Client, url string, secret string, deliveryID uint, event string, body []byte) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-tasker-webhooks")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(deliveryID), 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+	return Result{StatusCode: resp.StatusCode}, nil
+}
+
+// forbiddenPrefixes are the ranges that reach internal hosts without being
+// private addresses: carrier-grade NAT, and IPv4 addresses translated by a
+// NAT64 gateway.
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Forbidden reports whether webhooks must not be sent to addr: a loopback,
+// private, shared, link-local, multicast or unspecified address.
+func Forbidden(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckURL resolves the host of a webhook URL and returns
+// ErrForbiddenAddress when any of its addresses is forbidden.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("cannot resolve webhook host %q", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if Forbidden(addr) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// NewClient returns the client deliveries are sent with. Unless
+// allowPrivate is set it refuses to connect to forbidden addresses, which
+// also covers hosts that resolve to one only after their URL was checked.
+// Redirects are not followed: the redirect response is the result.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network string, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || Forbidden(addrPort.Addr()) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     time.Minute,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
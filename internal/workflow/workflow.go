@@ -0,0 +1,97 @@
+// Package workflow defines the statuses a project can be in and the
+// transitions allowed between them.
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-tasker/schemas"
+	"os"
+	"slices"
+	"sort"
+)
+
+// Workflow maps each status to the statuses a project in it can move to.
+// Every status a project can be in is a key, possibly with no transitions.
+// New projects start in the Initial status.
+type Workflow struct {
+	Initial     string              `json:"initial"`
+	Transitions map[string][]string `json:"transitions"`
+}
+
+// Default is the workflow used unless PROJECT_WORKFLOW names another one.
+var Default = &Workflow{
+	Initial: schemas.ProjectPlanning,
+	Transitions: map[string][]string{
+		schemas.ProjectPlanning:  {schemas.ProjectActive, schemas.ProjectOnHold, schemas.ProjectArchived},
+		schemas.ProjectActive:    {schemas.ProjectOnHold, schemas.ProjectCompleted, schemas.ProjectArchived},
+		schemas.ProjectOnHold:    {schemas.ProjectActive, schemas.ProjectArchived},
+		schemas.ProjectCompleted: {schemas.ProjectActive, schemas.ProjectArchived},
+		schemas.ProjectArchived:  {schemas.ProjectActive},
+	},
+}
+
+// Load reads a workflow from a JSON file such as
+// {"initial": "open", "transitions": {"open": ["closed"], "closed": []}}.
+// An empty path returns Default.
+func Load(path string) (*Workflow, error) {
+	if path == "" {
+		return Default, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var workflow Workflow
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("parsing workflow %s: %w", path, err)
+	}
+	if len(workflow.Transitions) == 0 {
+		return nil, fmt.Errorf("workflow %s has no statuses", path)
+	}
+	if !workflow.Has(workflow.Initial) {
+		return nil, fmt.Errorf("workflow %s: initial status %q is not one of its statuses", path, workflow.Initial)
+	}
+	for from, targets := range workflow.Transitions {
+		for _, to := range targets {
+			if !workflow.Has(to) {
+				return nil, fmt.Errorf("workflow %s: %q moves to unknown status %q", path, from, to)
+			}
+		}
+	}
+
+	return &workflow, nil
+}
+
+// Has reports whether status is part of the workflow.
+func (w *Workflow) Has(status string) bool {
+	_, ok := w.Transitions[status]
+	return ok
+}
+
+// Statuses returns every status of the workflow, sorted.
+func (w *Workflow) Statuses() []string {
+	statuses := make([]string, 0, len(w.Transitions))
+	for status := range w.Transitions {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	return statuses
+}
+
+// Next returns the statuses a project can move to from status. A project
+// whose status is not part of the workflow, such as one created before it
+// was configured, can move to any status.
+func (w *Workflow) Next(status string) []string {
+	if !w.Has(status) {
+		return w.Statuses()
+	}
+	return w.Transitions[status]
+}
+
+// Allows reports whether a project can move from one status to another.
+func (w *Workflow) Allows(from string, to string) bool {
+	return slices.Contains(w.Next(from), to)
+}
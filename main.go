@@ -5,6 +5,12 @@ import (
 	"go-tasker/internal/server"
 )
 
+// @title GoManage API
+// @version 1.0
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Use "Bearer <token>" with a token from /api/v1/auth/login or an API key
 func main() {
 	server := server.NewServer()
 
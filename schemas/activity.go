@@ -0,0 +1,51 @@
+package schemas
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entity types recorded in the activity log.
+const (
+	EntityProject    = "project"
+	EntityList       = "list"
+	EntityTask       = "task"
+	EntityLabel      = "label"
+	EntityMember     = "member"
+	EntityComment    = "comment"
+	EntityAttachment = "attachment"
+)
+
+// Actions recorded in the activity log.
+const (
+	ActionCreated    = "created"
+	ActionUpdated    = "updated"
+	ActionDeleted    = "deleted"
+	ActionDone       = "done"
+	ActionUndone     = "undone"
+	ActionMoved      = "moved"
+	ActionRestored   = "restored"
+	ActionPurged     = "purged"
+	ActionAssigned   = "assigned"
+	ActionUnassigned = "unassigned"
+	ActionLabeled    = "labeled"
+	ActionUnlabeled  = "unlabeled"
+	ActionBlocked    = "blocked"
+	ActionUnblocked  = "unblocked"
+)
+
+// Activity is an entry of the append-only audit trail. Before and After hold
+// the fields that changed as JSON objects, or null when the entity did not
+// exist before or after the action.
+type Activity struct {
+	ID         uint      `gorm:"primarykey"`
+	CreatedAt  time.Time `gorm:"index"`
+	ActorID    *uint     `gorm:"index"`
+	ProjectID  uint      `gorm:"index"`
+	TaskID     *uint     `gorm:"index"`
+	EntityType string
+	EntityID   uint
+	Action     string
+	Before     json.RawMessage
+	After      json.RawMessage
+}
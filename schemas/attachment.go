@@ -0,0 +1,26 @@
+package schemas
+
+import "time"
+
+// Attachment is a file attached to a task. Its content is kept in the blob
+// store under Hash, shared by every attachment with the same content.
+type Attachment struct {
+	ID          uint      `gorm:"primarykey"`
+	CreatedAt   time.Time `gorm:"index"`
+	TaskID      uint      `gorm:"index"`
+	Task        Task      `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UploaderID  uint      `gorm:"index"`
+	Uploader    User      `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Filename    string
+	ContentType string
+	Size        int64
+	Hash        string `gorm:"index"`
+}
+
+// Blob records content held in the blob store, keyed by its hex SHA-256
+// hash. It is removed from the store once no attachment refers to it.
+type Blob struct {
+	Hash      string `gorm:"primaryKey"`
+	Size      int64
+	CreatedAt time.Time
+}
@@ -0,0 +1,40 @@
+package schemas
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment is a Markdown message on a task. Edits keep the previous bodies as
+// revisions, and @username mentions of project members are recorded.
+type Comment struct {
+	gorm.Model
+	Body      string
+	EditedAt  *time.Time
+	TaskID    uint `gorm:"index"`
+	Task      Task `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	AuthorID  uint `gorm:"index"`
+	Author    User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Mentions  []Mention
+	Revisions []CommentRevision `json:"-"`
+}
+
+// CommentRevision is the body of a comment before one of its edits.
+type CommentRevision struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	CommentID uint      `gorm:"index"`
+	Body      string
+}
+
+// Mention records that a comment mentions a user, so they can be notified.
+type Mention struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	CommentID uint      `gorm:"uniqueIndex:idx_comment_mention"`
+	Comment   Comment   `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserID    uint      `gorm:"uniqueIndex:idx_comment_mention;index"`
+	User      User      `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	TaskID    uint      `gorm:"index"`
+}
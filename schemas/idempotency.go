@@ -0,0 +1,20 @@
+package schemas
+
+import "time"
+
+// IdempotentRequest is a POST made with an Idempotency-Key header. It keeps
+// the response so retries of the request get it back instead of repeating
+// the request.
+type IdempotentRequest struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UserID      uint   `gorm:"uniqueIndex:idx_idempotent_requests_key"`
+	User        User   `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Key         string `gorm:"uniqueIndex:idx_idempotent_requests_key"`
+	Fingerprint string
+	// Status is zero while the request is still being handled
+	Status    int
+	Header    map[string][]string `gorm:"serializer:json"`
+	Body      []byte
+	ExpiresAt time.Time `gorm:"index"`
+}
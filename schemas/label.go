@@ -0,0 +1,15 @@
+package schemas
+
+import (
+	"gorm.io/gorm"
+)
+
+const DefaultLabelColor = "#9e9e9e"
+
+type Label struct {
+	gorm.Model
+	Name      string `gorm:"uniqueIndex:idx_project_label"`
+	Color     string
+	ProjectID uint    `gorm:"uniqueIndex:idx_project_label"`
+	Project   Project `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
@@ -8,7 +8,10 @@ import (
 type List struct {
 	gorm.Model
 	Title     string
+	Position  string `gorm:"index"`
 	ProjectID uint
 	Project   Project `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	Tasks     []Task  `gorm:"constraint:OnDelete:CASCADE;"`
+	// Version is bumped by every change, for optimistic concurrency control
+	Version uint `gorm:"not null;default:1"`
 }
@@ -0,0 +1,32 @@
+package schemas
+
+import (
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// Project roles, ordered from least to most privileged. Each role can do
+// everything the roles before it can.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+var ProjectRoles = []string{RoleViewer, RoleEditor, RoleOwner}
+
+// RoleAllows reports whether role grants at least the permissions of required.
+func RoleAllows(role string, required string) bool {
+	have := slices.Index(ProjectRoles, role)
+	return have >= 0 && have >= slices.Index(ProjectRoles, required)
+}
+
+type ProjectMember struct {
+	gorm.Model
+	ProjectID uint    `gorm:"uniqueIndex:idx_project_member"`
+	Project   Project `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserID    uint    `gorm:"uniqueIndex:idx_project_member"`
+	User      User    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Role      string
+}
@@ -2,12 +2,38 @@
 package schemas
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Statuses of the default project workflow.
+const (
+	ProjectPlanning  = "planning"
+	ProjectActive    = "active"
+	ProjectOnHold    = "on_hold"
+	ProjectCompleted = "completed"
+	ProjectArchived  = "archived"
+)
+
 type Project struct {
 	gorm.Model
 	Title  string
 	Status string
 	Lists  []List `gorm:"constraint:OnDelete:CASCADE;"`
+	// Version is bumped by every change, for optimistic concurrency control
+	Version uint `gorm:"not null;default:1"`
+}
+
+// ProjectStatusChange is an entry of the status history of a project.
+// FromStatus is empty for the status the project was created with.
+type ProjectStatusChange struct {
+	ID         uint      `gorm:"primarykey"`
+	CreatedAt  time.Time `gorm:"index"`
+	ProjectID  uint      `gorm:"index"`
+	Project    Project   `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ActorID    *uint
+	FromStatus string
+	ToStatus   string
+	Comment    string
 }
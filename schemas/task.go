@@ -2,13 +2,41 @@
 package schemas
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Task priority levels, ordered from lowest to highest.
+const (
+	PriorityNone   = "none"
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+var TaskPriorities = []string{PriorityNone, PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
 type Task struct {
 	gorm.Model
-	Title  string
-	Done   bool
-	ListID uint
-	List   List `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Title    string
+	Done     bool
+	Position string `gorm:"index"`
+	Priority string `gorm:"default:none;index"`
+	StartAt  *time.Time
+	DueAt    *time.Time `gorm:"index"`
+	// Recurrence is an iCalendar RRULE evaluated from AnchorAt. Completing a
+	// recurring task creates the next instance, linked by RecurrenceFromID.
+	Recurrence       string
+	AnchorAt         *time.Time
+	RecurrenceFromID *uint `gorm:"index"`
+	ListID           uint
+	List             List    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ParentID         *uint   `gorm:"index"`
+	Assignees        []User  `gorm:"many2many:task_assignees;constraint:OnDelete:CASCADE;"`
+	Labels           []Label `gorm:"many2many:task_labels;constraint:OnDelete:CASCADE;"`
+	BlockedBy        []Task  `gorm:"many2many:task_dependencies;joinForeignKey:TaskID;joinReferences:BlockerID;constraint:OnDelete:CASCADE;"`
+	// Version is bumped by every change, for optimistic concurrency control
+	Version uint `gorm:"not null;default:1"`
 }
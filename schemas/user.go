@@ -0,0 +1,25 @@
+package schemas
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type User struct {
+	gorm.Model
+	Username     string `gorm:"uniqueIndex"`
+	Email        string `gorm:"uniqueIndex"`
+	PasswordHash string `json:"-"`
+}
+
+type APIKey struct {
+	gorm.Model
+	Name       string
+	Prefix     string
+	KeyHash    string `json:"-" gorm:"uniqueIndex"`
+	LastUsedAt *time.Time
+	ExpiresAt  *time.Time
+	UserID     uint `gorm:"index"`
+	User       User `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
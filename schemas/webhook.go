@@ -0,0 +1,48 @@
+package schemas
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Delivery states of a webhook delivery.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook subscribes a URL to the events of a project. Events holds the
+// event filters, such as task.created, task.* or *.
+type Webhook struct {
+	gorm.Model
+	ProjectID uint    `gorm:"index"`
+	Project   Project `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	URL       string
+	Events    []string `gorm:"serializer:json"`
+	Secret    string   `json:"-"`
+	Active    bool
+}
+
+// WebhookDelivery is an event sent, or waiting to be sent, to a webhook.
+// Pending deliveries are retried with exponential backoff until they
+// succeed or run out of attempts.
+type WebhookDelivery struct {
+	ID             uint      `gorm:"primarykey"`
+	CreatedAt      time.Time `gorm:"index"`
+	UpdatedAt      time.Time
+	WebhookID      uint    `gorm:"index"`
+	Webhook        Webhook `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ActivityID     *uint
+	RedeliveryOfID *uint
+	Event          string
+	Payload        json.RawMessage
+	Status         string     `gorm:"index:idx_webhook_delivery_due"`
+	NextAttemptAt  *time.Time `gorm:"index:idx_webhook_delivery_due"`
+	Attempts       int
+	ResponseStatus int
+	Error          string
+	DeliveredAt    *time.Time
+}
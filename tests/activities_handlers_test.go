@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivities(t *testing.T) {
+	t.Run("expects task changes in the task feed, newest first", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		payload := []byte(`{"title": "Write the docs", "priority": "high"}`)
+		req, _ := http.NewRequest("PUT", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID, bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		// The history of a deleted task can still be read
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/activities", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		activities := decodeResponse(t, response)["data"].([]interface{})
+		var actions []string
+		for _, activity := range activities {
+			actions = append(actions, activity.(map[string]interface{})["action"].(string))
+		}
+		assert.Equal(t, []string{"deleted", "done", "updated", "created"}, actions)
+
+		updated := activities[2].(map[string]interface{})
+		assert.Equal(t, "task", updated["entity_type"])
+		assert.Equal(t, taskID, formatID(updated["entity_id"]))
+		assert.Equal(t, currentUserID(t, authToken), formatID(updated["actor_id"]))
+		assert.Equal(t, map[string]interface{}{"title": "Write docs", "priority": "none"}, updated["before"])
+		assert.Equal(t, map[string]interface{}{"title": "Write the docs", "priority": "high"}, updated["after"])
+
+		created := activities[3].(map[string]interface{})
+		assert.Nil(t, created["before"])
+		assert.Equal(t, "Write docs", created["after"].(map[string]interface{})["title"])
+	})
+
+	t.Run("expects the project feed to cover lists, tasks and members", func(t *testing.T) {
+		clearTables()
+
+		registerAndLogin("auditor")
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "auditor", "role": "viewer"}`)
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/activities?limit=2", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		result := decodeResponse(t, response)
+		activities := result["data"].([]interface{})
+		assert.Len(t, activities, 2)
+		assert.Equal(t, "member", activities[0].(map[string]interface{})["entity_type"])
+		assert.Equal(t, "task", activities[1].(map[string]interface{})["entity_type"])
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/activities?cursor="+result["next_cursor"].(string), nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		activities = decodeResponse(t, response)["data"].([]interface{})
+		assert.Len(t, activities, 2)
+		assert.Equal(t, "list", activities[0].(map[string]interface{})["entity_type"])
+		assert.Equal(t, "project", activities[1].(map[string]interface{})["entity_type"])
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/activities?entity_type=list", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Len(t, decodeResponse(t, response)["data"], 1)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/activities?entity_type=board", nil)
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+	})
+
+	t.Run("expects failed changes to leave no activity", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		blockerID := createTask(t, projectID, listID, `{"title": "Design"}`)
+
+		payload := []byte(`{"blocker_id": ` + blockerID + `}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/dependencies", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusCreated, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/done", nil)
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/activities", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		activities := decodeResponse(t, response)["data"].([]interface{})
+		assert.Len(t, activities, 2)
+		blocked := activities[0].(map[string]interface{})
+		assert.Equal(t, "blocked", blocked["action"])
+		assert.Equal(t, blockerID, formatID(blocked["after"].(map[string]interface{})["blocker_id"]))
+	})
+}
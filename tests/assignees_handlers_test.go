@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignees(t *testing.T) {
+	assigneeToken := registerAndLogin("assignee")
+
+	t.Run("expects to assign and unassign a project member", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		taskID := createTask(t, projectID, listID, `{"title": "Task 1"}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "assignee", "role": "editor"}`)
+		assigneeID := currentUserID(t, assigneeToken)
+
+		payload := []byte(`{"user_id": ` + assigneeID + `}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/assignees", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		assignees := decodeResponse(t, response)["data"].(map[string]interface{})["assignees"].([]interface{})
+		assert.Len(t, assignees, 1)
+		assert.Equal(t, "assignee", assignees[0].(map[string]interface{})["Username"])
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/assignees/"+assigneeID, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, decodeResponse(t, response)["data"].(map[string]interface{})["assignees"])
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/assignees/"+assigneeID, nil)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+	})
+
+	t.Run("while assigning/when user is not a member/expects validation error", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		taskID := createTask(t, projectID, listID, `{"title": "Task 1"}`)
+
+		payload := []byte(`{"user_id": ` + currentUserID(t, assigneeToken) + `}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/assignees", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+		assert.Equal(t, "user is not a member of this project", decodeResponse(t, response)["error"])
+	})
+
+	t.Run("expects to get my open tasks grouped by project and list", func(t *testing.T) {
+		clearTables()
+
+		testerID := currentUserID(t, authToken)
+		assign := func(projectID, listID, taskID string) {
+			payload := []byte(`{"user_id": ` + testerID + `}`)
+			req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/assignees", bytes.NewReader(payload))
+			checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		}
+
+		alphaID := createProject(t, "Alpha")
+		todoID := createList(t, alphaID, "Todo")
+		doingID := createList(t, alphaID, "Doing")
+		assign(alphaID, todoID, createTask(t, alphaID, todoID, `{"title": "A1"}`))
+		assign(alphaID, todoID, createTask(t, alphaID, todoID, `{"title": "A2"}`))
+		assign(alphaID, doingID, createTask(t, alphaID, doingID, `{"title": "A3"}`))
+		createTask(t, alphaID, doingID, `{"title": "Unassigned"}`)
+
+		doneID := createTask(t, alphaID, doingID, `{"title": "Done"}`)
+		assign(alphaID, doingID, doneID)
+		req, _ := http.NewRequest("PATCH", "/api/v1/projects/"+alphaID+"/lists/"+doingID+"/tasks/"+doneID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		bravoID := createProject(t, "Bravo")
+		backlogID := createList(t, bravoID, "Backlog")
+		assign(bravoID, backlogID, createTask(t, bravoID, backlogID, `{"title": "B1"}`))
+
+		req, _ = http.NewRequest("GET", "/api/v1/me/tasks", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		projects := decodeResponse(t, response)["data"].([]interface{})
+		assert.Len(t, projects, 2)
+
+		alpha := projects[0].(map[string]interface{})
+		assert.Equal(t, "Alpha", alpha["project_title"])
+		lists := alpha["lists"].([]interface{})
+		assert.Len(t, lists, 2)
+		assert.Equal(t, "Todo", lists[0].(map[string]interface{})["list_title"])
+		assert.Len(t, lists[0].(map[string]interface{})["tasks"], 2)
+		assert.Equal(t, "Doing", lists[1].(map[string]interface{})["list_title"])
+		assert.Len(t, lists[1].(map[string]interface{})["tasks"], 1)
+
+		bravo := projects[1].(map[string]interface{})
+		assert.Equal(t, "Bravo", bravo["project_title"])
+	})
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pngHeader is the start of a PNG image, enough for its type to be detected.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+func uploadAttachment(t *testing.T, url string, filename string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", url, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return executeRequest(req)
+}
+
+func TestAttachments(t *testing.T) {
+	t.Run("expects an uploaded file to be downloadable with its detected type", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Fix the header"}`)
+		attachmentsURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" + taskID + "/attachments"
+
+		// The type comes from the content, not the name
+		response := uploadAttachment(t, attachmentsURL, "screenshot.txt", pngHeader)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+
+		attachment := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "screenshot.txt", attachment["filename"])
+		assert.Equal(t, "image/png", attachment["content_type"])
+		assert.Equal(t, float64(len(pngHeader)), attachment["size"])
+		assert.Equal(t, currentUserID(t, authToken), formatID(attachment["uploader_id"]))
+
+		req, _ := http.NewRequest("GET", attachmentsURL+"/"+formatID(attachment["id"]), nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, pngHeader, response.Body.Bytes())
+		assert.Equal(t, "image/png", response.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=screenshot.txt`, response.Header().Get("Content-Disposition"))
+
+		req, _ = http.NewRequest("GET", attachmentsURL, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Len(t, decodeResponse(t, response)["data"], 1)
+	})
+
+	t.Run("expects identical files to share their content until the last is deleted", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write the spec"}`)
+		otherTaskID := createTask(t, projectID, listID, `{"title": "Review the spec"}`)
+		attachmentsURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" + taskID + "/attachments"
+		otherAttachmentsURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" + otherTaskID + "/attachments"
+
+		content := []byte("# Spec\n\nThe header stays on top.\n")
+		response := uploadAttachment(t, attachmentsURL, "spec.md", content)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		first := decodeResponse(t, response)["data"].(map[string]interface{})
+		response = uploadAttachment(t, otherAttachmentsURL, "spec-copy.md", content)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		second := decodeResponse(t, response)["data"].(map[string]interface{})
+
+		hash := first["hash"].(string)
+		assert.Equal(t, hash, second["hash"])
+		blobPath := filepath.Join(os.Getenv("STORAGE_DIR"), hash[:2], hash)
+		assert.FileExists(t, blobPath)
+
+		var count int64
+		db.Table("blobs").Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		req, _ := http.NewRequest("DELETE", attachmentsURL+"/"+formatID(first["id"]), nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		assert.FileExists(t, blobPath)
+
+		req, _ = http.NewRequest("GET", attachmentsURL+"/"+formatID(first["id"]), nil)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+
+		// Purging a task from the trash releases its attachments too
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+otherTaskID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/trash/tasks/"+otherTaskID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		assert.NoFileExists(t, blobPath)
+
+		db.Table("blobs").Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("expects invalid uploads to be rejected", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Fix the header"}`)
+		attachmentsURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" + taskID + "/attachments"
+
+		response := uploadAttachment(t, attachmentsURL, "huge.bin", make([]byte, 1<<20+1))
+		checkResponseCode(t, http.StatusRequestEntityTooLarge, response.Code)
+
+		response = uploadAttachment(t, attachmentsURL, "empty.txt", nil)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+		req, _ := http.NewRequest("POST", attachmentsURL, bytes.NewReader([]byte(`{"file": "spec.md"}`)))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		response = uploadAttachment(t, "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/999/attachments", "spec.md", []byte("# Spec"))
+		checkResponseCode(t, http.StatusNotFound, response.Code)
+
+		var count int64
+		db.Table("blobs").Count(&count)
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuth(t *testing.T) {
+	t.Run("expects to reject anonymous requests", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/projects", nil)
+		response := executeAnonymousRequest(req)
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+		assert.Equal(t, "authentication required", decodeResponse(t, response)["error"])
+		assert.Contains(t, response.Header().Get("WWW-Authenticate"), "Bearer")
+	})
+
+	t.Run("expects to reject a tampered token", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/projects", nil)
+		req.Header.Set("Authorization", "Bearer "+authToken+"x")
+		checkResponseCode(t, http.StatusUnauthorized, executeAnonymousRequest(req).Code)
+	})
+
+	t.Run("expects to get the current user without the password hash", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/me", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "tester", data["username"])
+		assert.Equal(t, "tester@example.com", data["email"])
+		assert.NotContains(t, data, "password_hash")
+	})
+
+	t.Run("while registering/when username is taken/expects to return conflict", func(t *testing.T) {
+		payload := []byte(`{"username": "tester", "email": "other@example.com", "password": "password123"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/auth/register", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusConflict, executeAnonymousRequest(req).Code)
+	})
+
+	t.Run("while registering/when the same username is registered concurrently/expects one to succeed", func(t *testing.T) {
+		db.Exec("DELETE FROM users WHERE username = ?", "racer")
+
+		codes := make(chan int, 4)
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				payload := []byte(`{"username": "racer", "email": "racer` + strconv.Itoa(i) + `@example.com", "password": "password123"}`)
+				req, _ := http.NewRequest("POST", "/api/v1/auth/register", bytes.NewReader(payload))
+				codes <- executeAnonymousRequest(req).Code
+			}(i)
+		}
+		wg.Wait()
+		close(codes)
+
+		created := 0
+		for code := range codes {
+			if code == http.StatusCreated {
+				created++
+			} else {
+				assert.Equal(t, http.StatusConflict, code)
+			}
+		}
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("while registering/when password is short/expects to return validation error", func(t *testing.T) {
+		payload := []byte(`{"username": "shorty", "email": "shorty@example.com", "password": "abc"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/auth/register", bytes.NewReader(payload))
+		response := executeAnonymousRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+		assert.Equal(t, "Invalid fields: password must be at least 8 characters", decodeResponse(t, response)["error"])
+	})
+
+	t.Run("expects to reject a wrong password", func(t *testing.T) {
+		payload := []byte(`{"username": "tester", "password": "wrong-password"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusUnauthorized, executeAnonymousRequest(req).Code)
+
+		payload = []byte(`{"username": "nobody", "password": "wrong-password"}`)
+		req, _ = http.NewRequest("POST", "/api/v1/auth/login", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusUnauthorized, executeAnonymousRequest(req).Code)
+	})
+
+	t.Run("expects to authenticate with an API key until it is revoked", func(t *testing.T) {
+		payload := []byte(`{"name": "ci"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/me/api-keys", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		key := data["key"].(string)
+		assert.Equal(t, "ci", data["name"])
+		assert.NotContains(t, data, "key_hash")
+
+		req, _ = http.NewRequest("GET", "/api/v1/me", nil)
+		req.Header.Set("X-API-Key", key)
+		checkResponseCode(t, http.StatusOK, executeAnonymousRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		checkResponseCode(t, http.StatusOK, executeAnonymousRequest(req).Code)
+
+		keyID := formatID(data["id"])
+		req, _ = http.NewRequest("DELETE", "/api/v1/me/api-keys/"+keyID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/me", nil)
+		req.Header.Set("X-API-Key", key)
+		checkResponseCode(t, http.StatusUnauthorized, executeAnonymousRequest(req).Code)
+	})
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bulkResults(t *testing.T, data map[string]interface{}) map[string]string {
+	t.Helper()
+
+	statuses := make(map[string]string)
+	for _, result := range data["results"].([]interface{}) {
+		result := result.(map[string]interface{})
+		statuses[formatID(result["task_id"])] = result["status"].(string)
+	}
+	return statuses
+}
+
+func addBulkDependency(t *testing.T, projectID, listID, taskID, blockerID string) {
+	t.Helper()
+
+	payload := []byte(`{"blocker_id": ` + blockerID + `}`)
+	req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/dependencies", bytes.NewReader(payload))
+	checkResponseCode(t, http.StatusCreated, executeRequest(req).Code)
+}
+
+func TestBulkTasks(t *testing.T) {
+	t.Run("expects each task to succeed or fail on its own", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		blockedID := createTask(t, projectID, listID, `{"title": "Release"}`)
+		blockerID := createTask(t, projectID, listID, `{"title": "Design"}`)
+		addBulkDependency(t, projectID, listID, blockedID, blockerID)
+
+		url := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/bulk"
+		payload := []byte(`{"operation": "done", "task_ids": [` + taskID + `, ` + blockedID + `, 999]}`)
+		req, _ := http.NewRequest("POST", url, bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, float64(1), data["succeeded"])
+		assert.Equal(t, float64(2), data["failed"])
+		assert.Equal(t, map[string]string{taskID: "ok", blockedID: "failed", "999": "failed"}, bulkResults(t, data))
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks?done=true", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Write docs"}, titlesOf(t, response))
+
+		// Only the successful change shows up in the activity log
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/activities?action=done", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Len(t, decodeResponse(t, response)["data"], 1)
+	})
+
+	t.Run("expects an atomic operation to change nothing when a task fails", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		blockedID := createTask(t, projectID, listID, `{"title": "Release"}`)
+		blockerID := createTask(t, projectID, listID, `{"title": "Design"}`)
+		addBulkDependency(t, projectID, listID, blockedID, blockerID)
+
+		payload := []byte(`{"operation": "done", "task_ids": [` + taskID + `, ` + blockedID + `], "atomic": true}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/tasks/bulk", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusConflict, response.Code)
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, map[string]string{taskID: "rolled_back", blockedID: "failed"}, bulkResults(t, data))
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks?done=true", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, titlesOf(t, response))
+
+		payload = []byte(`{"operation": "done", "task_ids": [` + taskID + `, ` + blockedID + `], "atomic": true, "force": true}`)
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/tasks/bulk", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+	})
+
+	t.Run("expects to rename, move and delete the tasks matching a filter", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		doneListID := createList(t, projectID, "Done")
+		createTask(t, projectID, listID, `{"title": "Release v1 notes", "priority": "high"}`)
+		createTask(t, projectID, listID, `{"title": "Publish v1", "priority": "high"}`)
+		parentID := createTask(t, projectID, listID, `{"title": "Cleanup"}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+parentID+"/subtasks", `{"title": "Remove old branches"}`)
+
+		url := "/api/v1/projects/" + projectID + "/tasks/bulk"
+		payload := []byte(`{"operation": "rename", "filter": {"title": "v1"}, "pattern": "v(\\d+)", "replacement": "version $1"}`)
+		req, _ := http.NewRequest("POST", url, bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		payload = []byte(`{"operation": "move", "filter": {"priority": ["high"]}, "list_id": ` + doneListID + `}`)
+		req, _ = http.NewRequest("POST", url, bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+doneListID+"/tasks", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Release version 1 notes", "Publish version 1"}, titlesOf(t, response))
+
+		// Subtasks deleted along with their parent count as deleted
+		payload = []byte(`{"operation": "delete", "filter": {"done": false}}`)
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/bulk", bytes.NewReader(payload))
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, float64(2), data["succeeded"])
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, titlesOf(t, response))
+	})
+
+	t.Run("expects invalid operations to be rejected", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		url := "/api/v1/projects/" + projectID + "/tasks/bulk"
+
+		for _, payload := range []string{
+			`{"operation": "done"}`,
+			`{"operation": "done", "task_ids": [` + taskID + `], "filter": {"done": false}}`,
+			`{"operation": "done", "task_ids": [], "filter": {"done": false}}`,
+			`{"operation": "done", "task_ids": []}`,
+			`{"operation": "done", "task_ids": null, "filter": null}`,
+			`{"operation": "archive", "task_ids": [` + taskID + `]}`,
+			`{"operation": "rename", "task_ids": [` + taskID + `], "pattern": "("}`,
+			`{"operation": "move", "task_ids": [` + taskID + `]}`,
+			`{"operation": "move", "task_ids": [` + taskID + `], "list_id": 999}`,
+		} {
+			req, _ := http.NewRequest("POST", url, bytes.NewReader([]byte(payload)))
+			checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+		}
+
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/999/tasks/bulk", bytes.NewReader([]byte(`{"operation": "done", "task_ids": [`+taskID+`]}`)))
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+	})
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComments(t *testing.T) {
+	commenterToken := registerAndLogin("commenter")
+	registerAndLogin("bystander")
+
+	t.Run("expects mentions of project members to be recorded", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "commenter", "role": "editor"}`)
+
+		// bystander is not a member, and mentions inside code do not count
+		body := `{"body": "@commenter can you review? cc @bystander, see ` + "`@tester`" + ` and mail@commenter.dev"}`
+		commentID := createResource(t, "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/comments", body)
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/comments/"+commentID, nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		comment := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"commenter"}, comment["mentions"])
+		assert.Equal(t, "tester", comment["author"].(map[string]interface{})["username"])
+		assert.Nil(t, comment["edited_at"])
+
+		req, _ = http.NewRequest("GET", "/api/v1/me/mentions", nil)
+		req.Header.Set("Authorization", "Bearer "+commenterToken)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		mentions := decodeResponse(t, response)["data"].([]interface{})
+		assert.Len(t, mentions, 1)
+		mention := mentions[0].(map[string]interface{})
+		assert.Equal(t, taskID, formatID(mention["task_id"]))
+		assert.Equal(t, commentID, formatID(mention["comment"].(map[string]interface{})["id"]))
+	})
+
+	t.Run("expects mentions to disappear once the user leaves the project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "commenter", "role": "editor"}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/comments", `{"body": "@commenter can you review?"}`)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/members/"+currentUserID(t, commenterToken), nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/me/mentions", nil)
+		req.Header.Set("Authorization", "Bearer "+commenterToken)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, decodeResponse(t, response)["data"])
+	})
+
+	t.Run("expects only the author to edit a comment, keeping its history", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "commenter", "role": "editor"}`)
+		commentsURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" + taskID + "/comments"
+		commentID := createResource(t, commentsURL, `{"body": "First draft"}`)
+
+		payload := []byte(`{"body": "Hijacked"}`)
+		req, _ := http.NewRequest("PUT", commentsURL+"/"+commentID, bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+commenterToken)
+		checkResponseCode(t, http.StatusForbidden, executeRequest(req).Code)
+
+		payload = []byte(`{"body": "Second draft, @commenter"}`)
+		req, _ = http.NewRequest("PUT", commentsURL+"/"+commentID, bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		comment := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "Second draft, @commenter", comment["body"])
+		assert.NotNil(t, comment["edited_at"])
+		assert.Equal(t, []interface{}{"commenter"}, comment["mentions"])
+		revisions := comment["revisions"].([]interface{})
+		assert.Len(t, revisions, 1)
+		assert.Equal(t, "First draft", revisions[0].(map[string]interface{})["body"])
+
+		payload = []byte(`{"body": ""}`)
+		req, _ = http.NewRequest("PUT", commentsURL+"/"+commentID, bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+	})
+
+	t.Run("expects deleted comments to disappear from the task", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "commenter", "role": "editor"}`)
+		commentsURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" + taskID + "/comments"
+		ownCommentID := createResource(t, commentsURL, `{"body": "Looks good"}`)
+
+		payload := []byte(`{"body": "Thanks @tester"}`)
+		req, _ := http.NewRequest("POST", commentsURL, bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+commenterToken)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		otherCommentID := formatID(decodeResponse(t, response)["data"].(map[string]interface{})["id"])
+
+		// Editors cannot delete the comments of others, owners can
+		req, _ = http.NewRequest("DELETE", commentsURL+"/"+ownCommentID, nil)
+		req.Header.Set("Authorization", "Bearer "+commenterToken)
+		checkResponseCode(t, http.StatusForbidden, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("DELETE", commentsURL+"/"+otherCommentID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", commentsURL, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		comments := decodeResponse(t, response)["data"].([]interface{})
+		assert.Len(t, comments, 1)
+		assert.Equal(t, "Looks good", comments[0].(map[string]interface{})["body"])
+
+		req, _ = http.NewRequest("GET", commentsURL+"/"+otherCommentID, nil)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/me/mentions", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, decodeResponse(t, response)["data"])
+	})
+}
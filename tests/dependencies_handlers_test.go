@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskDependencies(t *testing.T) {
+	t.Run("expects a blocked task to need force to be completed", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		backlogID := createList(t, projectID, "Backlog")
+		doingID := createList(t, projectID, "Doing")
+		blockerID := createTask(t, projectID, backlogID, `{"title": "Design"}`)
+		taskID := createTask(t, projectID, doingID, `{"title": "Build"}`)
+		taskURL := "/api/v1/projects/" + projectID + "/lists/" + doingID + "/tasks/" + taskID
+
+		payload := []byte(`{"blocker_id": ` + blockerID + `}`)
+		req, _ := http.NewRequest("POST", taskURL+"/dependencies", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		assert.Equal(t, []string{"Design"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("POST", taskURL+"/dependencies", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", taskURL+"/done", nil)
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", taskURL+"/done?force=true", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, true, decodeResponse(t, response)["data"].(map[string]interface{})["done"])
+	})
+
+	t.Run("expects to complete a task once its blockers are done", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/"
+		blockerID := createTask(t, projectID, listID, `{"title": "Design"}`)
+		taskID := createTask(t, projectID, listID, `{"title": "Build"}`)
+
+		payload := []byte(`{"blocker_id": ` + blockerID + `}`)
+		req, _ := http.NewRequest("POST", tasksURL+taskID+"/dependencies", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusCreated, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", tasksURL+blockerID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", tasksURL+taskID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+	})
+
+	t.Run("expects to reject dependency cycles and other projects", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/"
+		firstID := createTask(t, projectID, listID, `{"title": "First"}`)
+		secondID := createTask(t, projectID, listID, `{"title": "Second"}`)
+		thirdID := createTask(t, projectID, listID, `{"title": "Third"}`)
+
+		otherProjectID := createProject(t, "Project 2")
+		otherID := createTask(t, otherProjectID, createList(t, otherProjectID, "Tasks"), `{"title": "Foreign"}`)
+
+		payload := []byte(`{"blocker_id": ` + firstID + `}`)
+		req, _ := http.NewRequest("POST", tasksURL+secondID+"/dependencies", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusCreated, executeRequest(req).Code)
+
+		payload = []byte(`{"blocker_id": ` + secondID + `}`)
+		req, _ = http.NewRequest("POST", tasksURL+thirdID+"/dependencies", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusCreated, executeRequest(req).Code)
+
+		payload = []byte(`{"blocker_id": ` + thirdID + `}`)
+		req, _ = http.NewRequest("POST", tasksURL+firstID+"/dependencies", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		payload = []byte(`{"blocker_id": ` + firstID + `}`)
+		req, _ = http.NewRequest("POST", tasksURL+firstID+"/dependencies", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		payload = []byte(`{"blocker_id": ` + otherID + `}`)
+		req, _ = http.NewRequest("POST", tasksURL+firstID+"/dependencies", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+	})
+
+	t.Run("expects restoring a task to drop dependencies that close a cycle", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/"
+		firstID := createTask(t, projectID, listID, `{"title": "First"}`)
+		secondID := createTask(t, projectID, listID, `{"title": "Second"}`)
+		thirdID := createTask(t, projectID, listID, `{"title": "Third"}`)
+
+		for taskID, blockerID := range map[string]string{secondID: firstID, thirdID: secondID} {
+			payload := []byte(`{"blocker_id": ` + blockerID + `}`)
+			req, _ := http.NewRequest("POST", tasksURL+taskID+"/dependencies", bytes.NewReader(payload))
+			checkResponseCode(t, http.StatusCreated, executeRequest(req).Code)
+		}
+
+		// With Second in the trash nothing links Third back to First
+		req, _ := http.NewRequest("DELETE", tasksURL+secondID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		payload := []byte(`{"blocker_id": ` + thirdID + `}`)
+		req, _ = http.NewRequest("POST", tasksURL+firstID+"/dependencies", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusCreated, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/trash/tasks/"+secondID+"/restore", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/tasks/ordered", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Len(t, titlesOf(t, response), 3)
+
+		var dependencies int64
+		db.Table("task_dependencies").Count(&dependencies)
+		assert.Equal(t, int64(2), dependencies)
+	})
+
+	t.Run("expects tasks in dependency order", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/"
+		deployID := createTask(t, projectID, listID, `{"title": "Deploy"}`)
+		testID := createTask(t, projectID, listID, `{"title": "Test"}`)
+		buildID := createTask(t, projectID, listID, `{"title": "Build"}`)
+		createTask(t, projectID, listID, `{"title": "Docs"}`)
+
+		for taskID, blockerID := range map[string]string{deployID: testID, testID: buildID} {
+			payload := []byte(`{"blocker_id": ` + blockerID + `}`)
+			req, _ := http.NewRequest("POST", tasksURL+taskID+"/dependencies", bytes.NewReader(payload))
+			checkResponseCode(t, http.StatusCreated, executeRequest(req).Code)
+		}
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/tasks/ordered", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Build", "Test", "Deploy", "Docs"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("DELETE", tasksURL+deployID+"/dependencies/"+testID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/tasks/ordered", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Deploy", "Build", "Test", "Docs"}, titlesOf(t, response))
+	})
+}
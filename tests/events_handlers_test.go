@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type streamedEvent struct {
+	id   string
+	name string
+	data string
+}
+
+// openEventStream connects to the event stream of a project and returns the
+// events read from it. The stream is closed when the test ends.
+func openEventStream(t *testing.T, url string, lastEventID string) <-chan streamedEvent {
+	t.Helper()
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer "+authToken)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	checkResponseCode(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan streamedEvent, 16)
+	go func() {
+		defer close(events)
+
+		var event streamedEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				event.id = value
+			case "event":
+				event.name = value
+			case "data":
+				event.data = value
+			case "":
+				if event.name != "" {
+					events <- event
+				}
+				event = streamedEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan streamedEvent) streamedEvent {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return streamedEvent{}
+	}
+}
+
+func TestProjectEvents(t *testing.T) {
+	server := httptest.NewServer(s.Handler)
+	defer server.Close()
+	followerToken := registerAndLogin("follower")
+
+	t.Run("expects changes to be streamed as they happen", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		otherProjectID := createProject(t, "Project 2")
+		events := openEventStream(t, server.URL+"/api/v1/projects/"+projectID+"/events", "")
+
+		listID := createList(t, projectID, "Backlog")
+		createList(t, otherProjectID, "Elsewhere")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		event := nextEvent(t, events)
+		assert.Equal(t, "list.created", event.name)
+		assert.NotEmpty(t, event.id)
+
+		event = nextEvent(t, events)
+		assert.Equal(t, "task.created", event.name)
+		var payload map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(event.data), &payload))
+		assert.Equal(t, taskID, formatID(payload["entity_id"]))
+		assert.Equal(t, "Write docs", payload["after"].(map[string]interface{})["title"])
+	})
+
+	t.Run("expects reconnecting clients to replay missed events", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		eventsURL := server.URL + "/api/v1/projects/" + projectID + "/events"
+
+		events := openEventStream(t, eventsURL, "")
+		createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		lastEventID := nextEvent(t, events).id
+
+		// Changes made while the client is away
+		createTask(t, projectID, listID, `{"title": "Design"}`)
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		events = openEventStream(t, eventsURL, lastEventID)
+		assert.Equal(t, "task.created", nextEvent(t, events).name)
+		assert.Equal(t, "list.deleted", nextEvent(t, events).name)
+
+		// Unknown IDs ask the client to reload instead
+		events = openEventStream(t, eventsURL, "stale-42")
+		assert.Equal(t, "reset", nextEvent(t, events).name)
+	})
+
+	t.Run("expects only members to follow a project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+
+		req, _ := http.NewRequest("GET", server.URL+"/api/v1/projects/"+projectID+"/events", nil)
+		req.Header.Set("Authorization", "Bearer "+registerAndLogin("listener"))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		checkResponseCode(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("expects streams to close once the user may no longer follow the project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "follower", "role": "viewer"}`)
+		eventsURL := server.URL + "/api/v1/projects/" + projectID + "/events"
+
+		req, _ := http.NewRequest("GET", eventsURL, nil)
+		req.Header.Set("Authorization", "Bearer "+followerToken)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		checkResponseCode(t, http.StatusOK, resp.StatusCode)
+		closed := make(chan struct{})
+		go func() {
+			io.Copy(io.Discard, resp.Body)
+			close(closed)
+		}()
+
+		owned := openEventStream(t, eventsURL, "")
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/members/"+currentUserID(t, followerToken), nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the stream of the former member is still open")
+		}
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		assert.Equal(t, "member.deleted", nextEvent(t, owned).name)
+		assert.Equal(t, "project.deleted", nextEvent(t, owned).name)
+		select {
+		case _, open := <-owned:
+			assert.False(t, open)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the stream of the deleted project is still open")
+		}
+	})
+
+	t.Run("expects browsers to open streams with a stream token from allowed origins", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		eventsURL := server.URL + "/api/v1/projects/" + projectID + "/events"
+
+		req, _ := http.NewRequest("POST", "/api/v1/auth/stream-token", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		token := decodeResponse(t, response)["data"].(map[string]interface{})["token"].(string)
+
+		streamStatus := func(url string, origin string) (int, http.Header) {
+			req, _ := http.NewRequest("GET", url, nil)
+			if origin != "" {
+				req.Header.Set("Origin", origin)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			return resp.StatusCode, resp.Header
+		}
+
+		code, header := streamStatus(eventsURL+"?access_token="+token, "https://app.example.com")
+		checkResponseCode(t, http.StatusOK, code)
+		assert.Equal(t, "https://app.example.com", header.Get("Access-Control-Allow-Origin"))
+
+		code, _ = streamStatus(eventsURL+"?access_token="+token, "https://evil.example.com")
+		checkResponseCode(t, http.StatusForbidden, code)
+		code, _ = streamStatus(eventsURL, "")
+		checkResponseCode(t, http.StatusUnauthorized, code)
+		code, _ = streamStatus(eventsURL+"?access_token="+authToken, "")
+		checkResponseCode(t, http.StatusUnauthorized, code)
+
+		// Stream tokens open nothing but event streams
+		code, _ = streamStatus(server.URL+"/api/v1/me?access_token="+token, "")
+		checkResponseCode(t, http.StatusUnauthorized, code)
+		req, _ = http.NewRequest("GET", "/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		checkResponseCode(t, http.StatusUnauthorized, executeRequest(req).Code)
+	})
+}
@@ -3,19 +3,37 @@ package tests
 import (
 	"go-tasker/internal/server"
 	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 )
 
 func TestMain(m *testing.M) {
+	storageDir, err := os.MkdirTemp("", "attachments-*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Setenv("STORAGE_DIR", storageDir)
+	os.Setenv("ATTACHMENT_MAX_SIZE", strconv.Itoa(1<<20))
+	os.Setenv("WEBHOOK_INTERVAL", "20ms")
+	// The webhook receivers of the tests listen on loopback
+	os.Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "true")
+	os.Setenv("ALLOWED_ORIGINS", "https://app.example.com")
+	os.Setenv("WS_MAX_LIFETIME", "2s")
+
 	s = server.NewServer()
 	db = getDB()
 
+	clearTableUsers()
+	authToken = registerAndLogin("tester")
+
 	code := m.Run()
 
 	clearTableLists()
+	os.RemoveAll(storageDir)
 
 	os.Exit(code)
 }
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	retrierToken := registerAndLogin("retrier")
+
+	postTask := func(url string, key string, payload string) *http.Response {
+		req, _ := http.NewRequest("POST", url, bytes.NewReader([]byte(payload)))
+		req.Header.Set("Idempotency-Key", key)
+		return executeRequest(req).Result()
+	}
+
+	t.Run("expects a retried request to be handled once", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		url := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks"
+
+		req, _ := http.NewRequest("POST", url, bytes.NewReader([]byte(`{"title": "Write docs"}`)))
+		req.Header.Set("Idempotency-Key", "create-1")
+		first := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, first.Code)
+		assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+		req, _ = http.NewRequest("POST", url, bytes.NewReader([]byte(`{"title": "Write docs"}`)))
+		req.Header.Set("Idempotency-Key", "create-1")
+		retry := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, first.Header().Get("ETag"), retry.Header().Get("ETag"))
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+
+		var count int64
+		db.Table("tasks").Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		// A different request cannot reuse the key
+		response := postTask(url, "create-1", `{"title": "Review docs"}`)
+		checkResponseCode(t, http.StatusUnprocessableEntity, response.StatusCode)
+
+		// Validation failures are replayed too
+		checkResponseCode(t, http.StatusBadRequest, postTask(url, "create-2", `{}`).StatusCode)
+		response = postTask(url, "create-2", `{}`)
+		checkResponseCode(t, http.StatusBadRequest, response.StatusCode)
+		assert.Equal(t, "true", response.Header.Get("Idempotent-Replayed"))
+
+		// Bodies larger than any route accepts are not read
+		response = postTask(url, "create-3", `{"title": "`+strings.Repeat("a", 2<<20)+`"}`)
+		checkResponseCode(t, http.StatusRequestEntityTooLarge, response.StatusCode)
+	})
+
+	t.Run("expects keys to belong to a user and to expire", func(t *testing.T) {
+		clearTables()
+
+		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader([]byte(`{"title": "Project 1", "status": "planning"}`)))
+		req.Header.Set("Idempotency-Key", "project")
+		checkResponseCode(t, http.StatusCreated, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("POST", "/api/v1/projects", bytes.NewReader([]byte(`{"title": "Project 1", "status": "planning"}`)))
+		req.Header.Set("Idempotency-Key", "project")
+		req.Header.Set("Authorization", "Bearer "+retrierToken)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		assert.Empty(t, response.Header().Get("Idempotent-Replayed"))
+
+		db.Table("idempotent_requests").Where("1 = 1").Update("expires_at", time.Now().UTC().Add(-time.Minute))
+
+		req, _ = http.NewRequest("POST", "/api/v1/projects", bytes.NewReader([]byte(`{"title": "Project 1", "status": "planning"}`)))
+		req.Header.Set("Idempotency-Key", "project")
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		assert.Empty(t, response.Header().Get("Idempotent-Replayed"))
+
+		var count int64
+		db.Table("projects").Count(&count)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("expects large uploads to be spooled rather than kept in memory", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		url := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" + taskID + "/attachments"
+
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("file", "notes.txt")
+		part.Write(bytes.Repeat([]byte("notes\n"), 50<<10))
+		writer.Close()
+
+		upload := func() *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("POST", url, bytes.NewReader(body.Bytes()))
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			req.Header.Set("Idempotency-Key", "upload-1")
+			return executeRequest(req)
+		}
+		first := upload()
+		checkResponseCode(t, http.StatusCreated, first.Code)
+		retry := upload()
+		checkResponseCode(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+
+		var count int64
+		db.Table("attachments").Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		spooled, _ := filepath.Glob(filepath.Join(os.TempDir(), "idempotent-request-*"))
+		assert.Empty(t, spooled)
+	})
+
+	t.Run("expects secrets not to be stored for replays", func(t *testing.T) {
+		clearTables()
+
+		req, _ := http.NewRequest("POST", "/api/v1/me/api-keys", bytes.NewReader([]byte(`{"name": "deploy"}`)))
+		req.Header.Set("Idempotency-Key", "api-key")
+		req.Header.Set("Authorization", "Bearer "+retrierToken)
+		first := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, first.Code)
+		key := decodeResponse(t, first)["data"].(map[string]interface{})["key"].(string)
+
+		req, _ = http.NewRequest("POST", "/api/v1/me/api-keys", bytes.NewReader([]byte(`{"name": "deploy"}`)))
+		req.Header.Set("Idempotency-Key", "api-key")
+		req.Header.Set("Authorization", "Bearer "+retrierToken)
+		retry := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+		assert.NotContains(t, retry.Body.String(), key)
+
+		var stored int64
+		db.Table("idempotent_requests").Where("body LIKE ?", "%"+key+"%").Count(&stored)
+		assert.Zero(t, stored)
+	})
+}
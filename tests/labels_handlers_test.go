@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabels(t *testing.T) {
+	t.Run("expects to create, update and delete labels", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+
+		payload := []byte(`{"name": "bug", "color": "#ff0000"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/labels", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "bug", data["name"])
+		assert.Equal(t, "#ff0000", data["color"])
+		labelID := formatID(data["id"])
+
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/labels", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+
+		payload = []byte(`{"name": "defect"}`)
+		req, _ = http.NewRequest("PUT", "/api/v1/projects/"+projectID+"/labels/"+labelID, bytes.NewReader(payload))
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "#9e9e9e", decodeResponse(t, response)["data"].(map[string]interface{})["color"])
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/labels/"+labelID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/labels", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, decodeResponse(t, response)["data"])
+	})
+
+	t.Run("while creating/when colour is invalid/expects validation error", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+
+		payload := []byte(`{"name": "bug", "color": "red"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/labels", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+		assert.Equal(t, "Invalid fields: color must be a hex colour such as #ff0000", decodeResponse(t, response)["error"])
+	})
+
+	t.Run("expects not to attach a label from another project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		taskID := createTask(t, projectID, listID, `{"title": "Task 1"}`)
+
+		otherID := createProject(t, "Project 2")
+		labelID := createResource(t, "/api/v1/projects/"+otherID+"/labels", `{"name": "bug"}`)
+
+		payload := []byte(`{"label_id": ` + labelID + `}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/labels", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+	})
+
+	t.Run("expects to filter tasks by labels with any and all", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		bugID := createResource(t, "/api/v1/projects/"+projectID+"/labels", `{"name": "bug"}`)
+		backendID := createResource(t, "/api/v1/projects/"+projectID+"/labels", `{"name": "backend"}`)
+
+		label := func(taskID, labelID string) {
+			payload := []byte(`{"label_id": ` + labelID + `}`)
+			req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/labels", bytes.NewReader(payload))
+			checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		}
+
+		both := createTask(t, projectID, listID, `{"title": "Both"}`)
+		label(both, bugID)
+		label(both, backendID)
+		label(createTask(t, projectID, listID, `{"title": "Bug only"}`), bugID)
+		label(createTask(t, projectID, listID, `{"title": "Backend only"}`), backendID)
+		createTask(t, projectID, listID, `{"title": "Unlabelled"}`)
+
+		url := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks?labels=bug,backend"
+		req, _ := http.NewRequest("GET", url, nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Both", "Bug only", "Backend only"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("GET", url+"&label_match=all", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Both"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+both+"/labels/"+bugID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", url+"&label_match=all", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, titlesOf(t, response))
+	})
+}
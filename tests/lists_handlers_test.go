@@ -18,7 +18,7 @@ func TestLists(t *testing.T) {
 		clearTableProjects()
 
 		// Create a project first
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 
@@ -57,7 +57,7 @@ func TestLists(t *testing.T) {
 		clearTableProjects()
 
 		// Create a project first
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 
@@ -106,7 +106,7 @@ func TestLists(t *testing.T) {
 		clearTableProjects()
 
 		// Create a project first
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 
@@ -152,7 +152,7 @@ func TestLists(t *testing.T) {
 		clearTableProjects()
 
 		// Create a project first
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 
@@ -190,7 +190,7 @@ func TestLists(t *testing.T) {
 		clearTableProjects()
 
 		// Create a project first
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 
@@ -244,7 +244,7 @@ func TestLists(t *testing.T) {
 		clearTableProjects()
 
 		// Create a project first
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 
@@ -288,7 +288,7 @@ func TestLists(t *testing.T) {
 		clearTableProjects()
 
 		// Create a project
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 		checkResponseCode(t, http.StatusCreated, response.Code)
@@ -329,3 +329,31 @@ func TestLists(t *testing.T) {
 		}
 	})
 }
+
+func TestListsPagination(t *testing.T) {
+	t.Run("expects to page through lists and filter by creation time", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		for _, title := range []string{"Todo", "Doing", "Done"} {
+			createList(t, projectID, title)
+		}
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists?limit=2", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Todo", "Doing"}, titlesOf(t, response))
+
+		cursor := decodeResponse(t, response)["next_cursor"].(string)
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists?limit=2&cursor="+cursor, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Done"}, titlesOf(t, response))
+		assert.Nil(t, decodeResponse(t, response)["next_cursor"])
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists?created_after=2999-01-01T00:00:00Z", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, titlesOf(t, response))
+	})
+}
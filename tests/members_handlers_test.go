@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMembers(t *testing.T) {
+	viewerToken := registerAndLogin("viewer")
+	strangerToken := registerAndLogin("stranger")
+
+	// asUser sets the bearer token of another user on req
+	asUser := func(req *http.Request, token string) *http.Request {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	t.Run("expects the creator to own a new project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/members", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		data := decodeResponse(t, response)["data"].([]interface{})
+		assert.Len(t, data, 1)
+		member := data[0].(map[string]interface{})
+		assert.Equal(t, "owner", member["role"])
+		assert.Equal(t, "tester", member["user"].(map[string]interface{})["Username"])
+	})
+
+	t.Run("expects a viewer to read but not modify a project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		taskID := createTask(t, projectID, listID, `{"title": "Task 1"}`)
+
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "viewer", "role": "viewer"}`)
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", nil)
+		response := executeRequest(asUser(req, viewerToken))
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Task 1"}, titlesOf(t, response))
+
+		payload := []byte(`{"title": "Renamed"}`)
+		req, _ = http.NewRequest("PUT", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID, bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusForbidden, executeRequest(asUser(req, viewerToken)).Code)
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID, nil)
+		checkResponseCode(t, http.StatusForbidden, executeRequest(asUser(req, viewerToken)).Code)
+	})
+
+	t.Run("expects an editor to modify tasks but not delete the project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		taskID := createTask(t, projectID, listID, `{"title": "Task 1"}`)
+
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "viewer", "role": "editor"}`)
+
+		req, _ := http.NewRequest("PATCH", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(asUser(req, viewerToken)).Code)
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID, nil)
+		checkResponseCode(t, http.StatusForbidden, executeRequest(asUser(req, viewerToken)).Code)
+	})
+
+	t.Run("expects non-members to neither see nor reach a project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects", nil)
+		response := executeRequest(asUser(req, strangerToken))
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, titlesOf(t, response))
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists", nil)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(asUser(req, strangerToken)).Code)
+	})
+
+	t.Run("expects to change and remove members but keep an owner", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "viewer", "role": "viewer"}`)
+
+		ownerID := currentUserID(t, authToken)
+		viewerID := currentUserID(t, viewerToken)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/members/"+ownerID, nil)
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+
+		payload := []byte(`{"role": "owner"}`)
+		req, _ = http.NewRequest("PUT", "/api/v1/projects/"+projectID+"/members/"+viewerID, bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/members/"+ownerID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/members", nil)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+	})
+
+	t.Run("while adding a member/when user is unknown/expects not found", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+
+		payload := []byte(`{"username": "nobody", "role": "viewer"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/members", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+	})
+}
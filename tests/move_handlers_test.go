@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManualOrdering(t *testing.T) {
+	t.Run("expects to reorder tasks before and after siblings", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Board")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks"
+		ids := map[string]string{}
+		for _, title := range []string{"A", "B", "C", "D"} {
+			ids[title] = createTask(t, projectID, listID, `{"title": "`+title+`"}`)
+		}
+
+		payload := []byte(`{"before_id": ` + ids["A"] + `}`)
+		req, _ := http.NewRequest("POST", tasksURL+"/"+ids["D"]+"/move", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		payload = []byte(`{"after_id": ` + ids["C"] + `}`)
+		req, _ = http.NewRequest("POST", tasksURL+"/"+ids["A"]+"/move", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", tasksURL, nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"D", "B", "C", "A"}, titlesOf(t, response))
+	})
+
+	t.Run("expects repeated moves into the same gap to keep their order", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Board")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks"
+		firstID := createTask(t, projectID, listID, `{"title": "first"}`)
+		lastID := createTask(t, projectID, listID, `{"title": "last"}`)
+
+		// Each task is moved right after "first", so they end up in reverse
+		var expected []string
+		for _, title := range []string{"t1", "t2", "t3", "t4", "t5", "t6", "t7", "t8", "t9", "t10"} {
+			taskID := createTask(t, projectID, listID, `{"title": "`+title+`"}`)
+			payload := []byte(`{"after_id": ` + firstID + `}`)
+			req, _ := http.NewRequest("POST", tasksURL+"/"+taskID+"/move", bytes.NewReader(payload))
+			checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+			expected = append([]string{title}, expected...)
+		}
+
+		payload := []byte(`{"before_id": ` + firstID + `}`)
+		req, _ := http.NewRequest("POST", tasksURL+"/"+lastID+"/move", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", tasksURL, nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, append([]string{"last", "first"}, expected...), titlesOf(t, response))
+	})
+
+	t.Run("expects positions to stay short when items keep going to the front", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Board")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks"
+		firstID := createTask(t, projectID, listID, `{"title": "first"}`)
+
+		var expected []string
+		for i := 1; i <= 80; i++ {
+			title := "t" + strconv.Itoa(i)
+			taskID := createTask(t, projectID, listID, `{"title": "`+title+`"}`)
+			payload := []byte(`{"before_id": ` + firstID + `}`)
+			req, _ := http.NewRequest("POST", tasksURL+"/"+taskID+"/move", bytes.NewReader(payload))
+			checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+			expected = append(expected, title)
+			firstID = taskID
+		}
+
+		var longest int
+		db.Table("tasks").Select("MAX(LENGTH(position))").Scan(&longest)
+		assert.LessOrEqual(t, longest, 8)
+
+		req, _ := http.NewRequest("GET", tasksURL+"?limit=100", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		titles := titlesOf(t, response)
+		assert.Len(t, titles, 81)
+		for i, title := range expected {
+			assert.Equal(t, title, titles[len(expected)-1-i])
+		}
+	})
+
+	t.Run("expects to reorder lists within a project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		todoID := createList(t, projectID, "To do")
+		createList(t, projectID, "Doing")
+		doneID := createList(t, projectID, "Done")
+
+		payload := []byte(`{"before_id": ` + todoID + `}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+doneID+"/move", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Done", "To do", "Doing"}, titlesOf(t, response))
+	})
+
+	t.Run("expects to reject invalid moves", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Board")
+		otherListID := createList(t, projectID, "Other")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks"
+		taskID := createTask(t, projectID, listID, `{"title": "A"}`)
+		siblingID := createTask(t, projectID, listID, `{"title": "B"}`)
+		otherID := createTask(t, projectID, otherListID, `{"title": "Elsewhere"}`)
+
+		payload := []byte(`{"before_id": ` + siblingID + `, "after_id": ` + siblingID + `}`)
+		req, _ := http.NewRequest("POST", tasksURL+"/"+taskID+"/move", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "Invalid fields: before_id or after_id must be set, but not both", decodeResponse(t, response)["error"])
+
+		payload = []byte(`{"after_id": ` + otherID + `}`)
+		req, _ = http.NewRequest("POST", tasksURL+"/"+taskID+"/move", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		payload = []byte(`{"after_id": ` + taskID + `}`)
+		req, _ = http.NewRequest("POST", tasksURL+"/"+taskID+"/move", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+	})
+}
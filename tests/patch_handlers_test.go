@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatch(t *testing.T) {
+	t.Run("expects a merge patch to only change the fields it names", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" +
+			createTask(t, projectID, listID, `{"title": "Write docs", "priority": "high", "due_at": "2030-01-02T15:00:00Z"}`)
+
+		req, _ := http.NewRequest("PATCH", taskURL+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", taskURL, bytes.NewReader([]byte(`{"title": "Write the docs", "due_at": null}`)))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"3"`, response.Header().Get("ETag"))
+
+		task := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "Write the docs", task["title"])
+		assert.Equal(t, "high", task["priority"])
+		assert.Equal(t, true, task["done"])
+		assert.Nil(t, task["due_at"])
+
+		req, _ = http.NewRequest("PATCH", "/api/v1/projects/"+projectID+"/lists/"+listID, bytes.NewReader([]byte(`{"title": "Todo"}`)))
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "Todo", decodeResponse(t, response)["data"].(map[string]interface{})["title"])
+
+		req, _ = http.NewRequest("PATCH", "/api/v1/projects/"+projectID, bytes.NewReader([]byte(`{"title": "Project One"}`)))
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		project := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "Project One", project["title"])
+		assert.Equal(t, "planning", project["status"])
+	})
+
+	t.Run("expects a JSON Patch to apply its operations in order", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" +
+			createTask(t, projectID, listID, `{"title": "Write docs", "priority": "low"}`)
+
+		payload := []byte(`[
+			{"op": "test", "path": "/priority", "value": "low"},
+			{"op": "replace", "path": "/priority", "value": "urgent"},
+			{"op": "copy", "from": "/title", "path": "/recurrence"},
+			{"op": "replace", "path": "/recurrence", "value": "FREQ=DAILY"},
+			{"op": "add", "path": "/due_at", "value": "2030-01-02T15:00:00Z"}
+		]`)
+		req, _ := http.NewRequest("PATCH", taskURL, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		task := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "Write docs", task["title"])
+		assert.Equal(t, "urgent", task["priority"])
+		assert.Equal(t, "FREQ=DAILY", task["recurrence"])
+
+		// A failing test operation leaves the task untouched
+		payload = []byte(`[{"op": "test", "path": "/priority", "value": "low"}, {"op": "replace", "path": "/title", "value": "Stale"}]`)
+		req, _ = http.NewRequest("PATCH", taskURL, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", taskURL, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "Write docs", decodeResponse(t, response)["data"].(map[string]interface{})["title"])
+	})
+
+	t.Run("expects invalid patches to be rejected", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" + createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		for _, payload := range []string{
+			`{"title": null}`,
+			`{"priority": "extreme"}`,
+			`{"recurrence": "FREQ=SOMETIMES"}`,
+			`{"color": "red"}`,
+			`not json`,
+		} {
+			req, _ := http.NewRequest("PATCH", taskURL, bytes.NewReader([]byte(payload)))
+			checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+		}
+
+		for _, payload := range []string{
+			`{"op": "remove", "path": "/title"}`,
+			`[{"op": "remove", "path": "/color"}]`,
+			`[{"op": "rename", "path": "/title"}]`,
+			`[{"op": "remove", "path": "/title"}]`,
+		} {
+			req, _ := http.NewRequest("PATCH", taskURL, bytes.NewReader([]byte(payload)))
+			req.Header.Set("Content-Type", "application/json-patch+json")
+			checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+		}
+
+		req, _ := http.NewRequest("PATCH", taskURL, bytes.NewReader([]byte(`title=Stale`)))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusUnsupportedMediaType, response.Code)
+		assert.Contains(t, response.Header().Get("Accept-Patch"), "application/merge-patch+json")
+
+		req, _ = http.NewRequest("PATCH", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/999", bytes.NewReader([]byte(`{"title": "Missing"}`)))
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", "/api/v1/projects/"+projectID, bytes.NewReader([]byte(`{"status": "someday"}`)))
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+		assert.Contains(t, decodeResponse(t, response)["error"], "unknown project status")
+	})
+
+	t.Run("expects If-Match to guard patches", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" + createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		req, _ := http.NewRequest("PATCH", taskURL, bytes.NewReader([]byte(`{"priority": "high"}`)))
+		req.Header.Set("If-Match", `"1"`)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"2"`, response.Header().Get("ETag"))
+
+		req, _ = http.NewRequest("PATCH", taskURL, bytes.NewReader([]byte(`{"priority": "low"}`)))
+		req.Header.Set("If-Match", `"1"`)
+		checkResponseCode(t, http.StatusPreconditionFailed, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", taskURL, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "high", decodeResponse(t, response)["data"].(map[string]interface{})["priority"])
+	})
+}
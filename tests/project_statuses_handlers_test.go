@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func transitionProject(projectID string, payload string) int {
+	req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/transitions", bytes.NewReader([]byte(payload)))
+	return executeRequest(req).Code
+}
+
+func TestProjectStatuses(t *testing.T) {
+	t.Run("expects projects to only move along the workflow", func(t *testing.T) {
+		clearTables()
+
+		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader([]byte(`{"title": "Project 1", "status": "someday"}`)))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		// Projects start in the initial status, so they cannot skip the
+		// workflow or the open tasks check
+		req, _ = http.NewRequest("POST", "/api/v1/projects", bytes.NewReader([]byte(`{"title": "Project 1", "status": "completed"}`)))
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+		initialID := createProject(t, "Project 1")
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+initialID+"/status-history", nil)
+		history := decodeResponse(t, executeRequest(req))["data"].([]interface{})
+		assert.Len(t, history, 1)
+		assert.Equal(t, "", history[0].(map[string]interface{})["from_status"])
+		assert.Equal(t, "planning", history[0].(map[string]interface{})["to_status"])
+
+		projectID := createProject(t, "Project 1")
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/transitions", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "planning", data["status"])
+		assert.Equal(t, []interface{}{"active", "on_hold", "archived"}, data["next"])
+		assert.Len(t, data["statuses"], 5)
+
+		checkResponseCode(t, http.StatusConflict, transitionProject(projectID, `{"status": "completed"}`))
+		checkResponseCode(t, http.StatusConflict, transitionProject(projectID, `{"status": "planning"}`))
+		checkResponseCode(t, http.StatusBadRequest, transitionProject(projectID, `{"status": "someday"}`))
+		checkResponseCode(t, http.StatusBadRequest, transitionProject(projectID, `{}`))
+		checkResponseCode(t, http.StatusOK, transitionProject(projectID, `{"status": "active", "comment": "Kick-off done"}`))
+
+		// Updates go through the same workflow
+		req, _ = http.NewRequest("PUT", "/api/v1/projects/"+projectID, bytes.NewReader([]byte(`{"title": "Project 1", "status": "planning"}`)))
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", "/api/v1/projects/"+projectID, bytes.NewReader([]byte(`{"status": "on_hold"}`)))
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "on_hold", decodeResponse(t, response)["data"].(map[string]interface{})["status"])
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/status-history", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		var changes []string
+		for _, change := range decodeResponse(t, response)["data"].([]interface{}) {
+			change := change.(map[string]interface{})
+			changes = append(changes, change["from_status"].(string)+" -> "+change["to_status"].(string))
+		}
+		assert.Equal(t, []string{"active -> on_hold", "planning -> active", " -> planning"}, changes)
+
+		entry := decodeResponse(t, response)["data"].([]interface{})[1].(map[string]interface{})
+		assert.Equal(t, "Kick-off done", entry["comment"])
+		assert.Equal(t, currentUserID(t, authToken), formatID(entry["actor_id"]))
+	})
+
+	t.Run("expects a project with open tasks not to be completed", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		checkResponseCode(t, http.StatusOK, transitionProject(projectID, `{"status": "active"}`))
+
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/transitions", bytes.NewReader([]byte(`{"status": "completed"}`)))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusConflict, response.Code)
+		assert.Contains(t, decodeResponse(t, response)["error"], "open tasks")
+
+		req, _ = http.NewRequest("PUT", "/api/v1/projects/"+projectID, bytes.NewReader([]byte(`{"title": "Project 1", "status": "completed"}`)))
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		checkResponseCode(t, http.StatusOK, transitionProject(projectID, `{"status": "completed"}`))
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "completed", decodeResponse(t, response)["data"].(map[string]interface{})["status"])
+	})
+}
@@ -36,7 +36,7 @@ func TestProjects(t *testing.T) {
 	t.Run("expects to get a project", func(t *testing.T) {
 		clearTableProjects()
 
-		payload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		payload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(payload))
 		response := executeRequest(req)
 
@@ -58,7 +58,7 @@ func TestProjects(t *testing.T) {
 
 			assert.Equal(t, float64(1), project["id"], "Expected id to be 1")
 			assert.Equal(t, "Project 1", project["title"], "Expected title to be 'Project 1'")
-			assert.Equal(t, "not started", project["status"], "Expected status to be 'not started'")
+			assert.Equal(t, "planning", project["status"], "Expected status to be 'planning'")
 			assert.NotNil(t, project["created_at"], "Expected 'created_at' field to be present")
 		}
 	})
@@ -66,7 +66,7 @@ func TestProjects(t *testing.T) {
 	t.Run("expects to create a project", func(t *testing.T) {
 		clearTableProjects()
 
-		payload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		payload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(payload))
 		response := executeRequest(req)
 
@@ -85,20 +85,20 @@ func TestProjects(t *testing.T) {
 			"Expected id to be 1")
 		assert.Equal(t, "Project 1", result["data"].(map[string]interface{})["title"],
 			"Expected title to be 'Project 1'")
-		assert.Equal(t, "not started", result["data"].(map[string]interface{})["status"],
-			"Expected status to be 'not started'")
+		assert.Equal(t, "planning", result["data"].(map[string]interface{})["status"],
+			"Expected status to be 'planning'")
 		assert.NotNil(t, result["data"].(map[string]interface{})["created_at"],
 			"Expected 'created_at' field to be present")
 	})
 
-	t.Run("while creating/when title or status is missing/expects to return validation error", func(t *testing.T) {
+	t.Run("while creating/when status is missing/expects to create the project in the initial status", func(t *testing.T) {
 		clearTableProjects()
 
 		payload := []byte(`{"title": "Project 1"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(payload))
 		response := executeRequest(req)
 
-		checkResponseCode(t, http.StatusBadRequest, response.Code)
+		checkResponseCode(t, http.StatusCreated, response.Code)
 
 		var result map[string]interface{}
 		err := json.Unmarshal(response.Body.Bytes(), &result)
@@ -107,16 +107,21 @@ func TestProjects(t *testing.T) {
 			return
 		}
 
-		assert.Equal(t, result["error"], "Missing required fields: status",
-			"Expected error to be 'Missing required fields: status'")
+		assert.Equal(t, "planning", result["data"].(map[string]interface{})["status"],
+			"Expected status to be 'planning'")
+	})
 
-		payload = []byte(`{"status": "not started"}`)
-		req, _ = http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(payload))
-		response = executeRequest(req)
+	t.Run("while creating/when title is missing/expects to return validation error", func(t *testing.T) {
+		clearTableProjects()
+
+		payload := []byte(`{"status": "planning"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(payload))
+		response := executeRequest(req)
 
 		checkResponseCode(t, http.StatusBadRequest, response.Code)
 
-		err = json.Unmarshal(response.Body.Bytes(), &result)
+		var result map[string]interface{}
+		err := json.Unmarshal(response.Body.Bytes(), &result)
 		if err != nil {
 			t.Errorf("Error unmarshalling response: %v", err)
 			return
@@ -129,13 +134,13 @@ func TestProjects(t *testing.T) {
 	t.Run("expects to update a project", func(t *testing.T) {
 		clearTableProjects()
 
-		payload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		payload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(payload))
 		response := executeRequest(req)
 
 		checkResponseCode(t, http.StatusCreated, response.Code)
 
-		payload = []byte(`{"title": "Project 1 Updated", "status": "in progress"}`)
+		payload = []byte(`{"title": "Project 1 Updated", "status": "active"}`)
 		req, _ = http.NewRequest("PUT", "/api/v1/projects/1", bytes.NewReader(payload))
 		response = executeRequest(req)
 
@@ -154,8 +159,8 @@ func TestProjects(t *testing.T) {
 			"Expected id to be 1")
 		assert.Equal(t, "Project 1 Updated", result["data"].(map[string]interface{})["title"],
 			"Expected title to be 'Project 1 Updated'")
-		assert.Equal(t, "in progress", result["data"].(map[string]interface{})["status"],
-			"Expected status to be 'in progress'")
+		assert.Equal(t, "active", result["data"].(map[string]interface{})["status"],
+			"Expected status to be 'active'")
 		assert.NotNil(t, result["data"].(map[string]interface{})["created_at"],
 			"Expected 'created_at' field to be present")
 	})
@@ -163,7 +168,7 @@ func TestProjects(t *testing.T) {
 	t.Run("expects to delete a project", func(t *testing.T) {
 		clearTableProjects()
 
-		payload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		payload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(payload))
 		response := executeRequest(req)
 
@@ -186,3 +191,54 @@ func TestProjects(t *testing.T) {
 			"Expected message to be 'Project deleted successfully'")
 	})
 }
+
+func TestProjectsPagination(t *testing.T) {
+	t.Run("expects to page through projects with a cursor", func(t *testing.T) {
+		clearTables()
+
+		for _, title := range []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"} {
+			createProject(t, title)
+		}
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects?limit=2&sort=-title", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Echo", "Delta"}, titlesOf(t, response))
+
+		var seen []string
+		cursor := decodeResponse(t, response)["next_cursor"]
+		for cursor != nil {
+			req, _ = http.NewRequest("GET", "/api/v1/projects?limit=2&sort=-title&cursor="+cursor.(string), nil)
+			response = executeRequest(req)
+			checkResponseCode(t, http.StatusOK, response.Code)
+
+			seen = append(seen, titlesOf(t, response)...)
+			cursor = decodeResponse(t, response)["next_cursor"]
+		}
+		assert.Equal(t, []string{"Charlie", "Bravo", "Alpha"}, seen)
+	})
+
+	t.Run("expects to filter projects by status", func(t *testing.T) {
+		clearTables()
+
+		runningID := createProject(t, "Running")
+		checkResponseCode(t, http.StatusOK, transitionProject(runningID, `{"status": "active"}`))
+		parkedID := createProject(t, "Parked")
+		checkResponseCode(t, http.StatusOK, transitionProject(parkedID, `{"status": "on_hold"}`))
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects?status=active", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Running"}, titlesOf(t, response))
+	})
+
+	t.Run("expects to reject an invalid cursor or limit", func(t *testing.T) {
+		clearTables()
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects?cursor=not-a-cursor", nil)
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects?limit=1000", nil)
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+	})
+}
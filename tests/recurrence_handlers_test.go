@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecurringTasks(t *testing.T) {
+	t.Run("expects to preview the next occurrences", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Chores")
+		taskID := createTask(t, projectID, listID,
+			`{"title": "Rotate on-call", "due_at": "2030-01-07T09:00:00Z", "recurrence": "FREQ=WEEKLY;BYDAY=MO,TH"}`)
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/occurrences?count=3", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []interface{}{"2030-01-10T09:00:00Z", "2030-01-14T09:00:00Z", "2030-01-17T09:00:00Z"},
+			decodeResponse(t, response)["data"])
+	})
+
+	t.Run("expects occurrences to stop at the end of the series", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Chores")
+		taskID := createTask(t, projectID, listID,
+			`{"title": "Payroll", "due_at": "2030-01-25T12:00:00Z", "recurrence": "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3"}`)
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/occurrences?count=10", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []interface{}{"2030-02-22T12:00:00Z", "2030-03-29T12:00:00Z"}, decodeResponse(t, response)["data"])
+	})
+
+	t.Run("expects an update without an anchor to keep the series anchor", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Chores")
+		taskID := createTask(t, projectID, listID,
+			`{"title": "Rotate on-call", "due_at": "2030-01-07T09:00:00Z", "recurrence": "FREQ=WEEKLY;BYDAY=MO,TH"}`)
+
+		payload := []byte(`{"title": "Rotate the on-call", "recurrence": "FREQ=WEEKLY;BYDAY=MO,TH"}`)
+		req, _ := http.NewRequest("PUT", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID, bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "2030-01-07T09:00:00Z", decodeResponse(t, response)["data"].(map[string]interface{})["anchor_at"])
+	})
+
+	t.Run("expects sparse rules to find occurrences years apart", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Chores")
+		taskID := createTask(t, projectID, listID,
+			`{"title": "Leap day party", "due_at": "2028-02-29T18:00:00Z", "recurrence": "FREQ=DAILY;BYMONTH=2;BYMONTHDAY=29"}`)
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/occurrences?count=2", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []interface{}{"2032-02-29T18:00:00Z", "2036-02-29T18:00:00Z"}, decodeResponse(t, response)["data"])
+	})
+
+	t.Run("expects completing a recurring task to create the next instance once", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Chores")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks"
+		taskID := createTask(t, projectID, listID,
+			`{"title": "Rotate on-call", "due_at": "2030-01-07T09:00:00Z", "recurrence": "FREQ=WEEKLY;BYDAY=MO,TH"}`)
+
+		req, _ := http.NewRequest("PATCH", tasksURL+"/"+taskID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		req, _ = http.NewRequest("PATCH", tasksURL+"/"+taskID+"/undone", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		req, _ = http.NewRequest("PATCH", tasksURL+"/"+taskID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", tasksURL+"?done=false", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		tasks := decodeResponse(t, response)["data"].([]interface{})
+		assert.Len(t, tasks, 1)
+		next := tasks[0].(map[string]interface{})
+		assert.Equal(t, "Rotate on-call", next["title"])
+		assert.Equal(t, "2030-01-10T09:00:00Z", next["due_at"])
+		assert.Equal(t, "2030-01-07T09:00:00Z", next["anchor_at"])
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH", next["recurrence"])
+	})
+
+	t.Run("expects to reject invalid rules and non-recurring previews", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Chores")
+
+		payload := []byte(`{"title": "Broken", "recurrence": "FREQ=HOURLY"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "Invalid fields: recurrence must be a valid iCalendar RRULE such as FREQ=WEEKLY;BYDAY=MO",
+			decodeResponse(t, response)["error"])
+
+		taskID := createTask(t, projectID, listID, `{"title": "Once"}`)
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/occurrences", nil)
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+	})
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSearch(t *testing.T) {
+	strangerToken := registerAndLogin("searcher")
+
+	search := func(t *testing.T, q string) []interface{} {
+		t.Helper()
+
+		req, _ := http.NewRequest("GET", "/api/v1/search?q="+url.QueryEscape(q), nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		return decodeResponse(t, response)["data"].([]interface{})
+	}
+
+	t.Run("expects to find tasks, lists and projects linked to their parents", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Deployment pipeline")
+		listID := createList(t, projectID, "Deploy checklist")
+		createTask(t, projectID, listID, `{"title": "Deploy the API service"}`)
+		createTask(t, projectID, listID, `{"title": "Write release notes"}`)
+
+		results := search(t, "deploy")
+		assert.Len(t, results, 3)
+
+		types := map[string]map[string]interface{}{}
+		for _, item := range results {
+			result := item.(map[string]interface{})
+			types[result["type"].(string)] = result
+		}
+
+		task := types["task"]
+		assert.Equal(t, "Deploy the API service", task["title"])
+		assert.Contains(t, task["snippet"], "Deploy")
+		assert.Equal(t, projectID, formatID(task["project_id"]))
+		assert.Equal(t, "Deployment pipeline", task["project_title"])
+		assert.Equal(t, listID, formatID(task["list_id"]))
+		assert.Equal(t, "Deploy checklist", task["list_title"])
+
+		assert.Equal(t, projectID, formatID(types["list"]["project_id"]))
+		assert.Nil(t, types["project"]["list_id"])
+	})
+
+	t.Run("expects the index to follow updates and deletes", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		taskID := createTask(t, projectID, listID, `{"title": "Rotate on-call"}`)
+
+		payload := []byte(`{"title": "Rotate pager duty"}`)
+		req, _ := http.NewRequest("PUT", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID, bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		assert.Empty(t, search(t, "on-call"))
+		assert.Len(t, search(t, "pager"), 1)
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		assert.Empty(t, search(t, "pager"))
+	})
+
+	t.Run("expects not to find other users' projects", func(t *testing.T) {
+		clearTables()
+
+		createProject(t, "Secret roadmap")
+
+		req, _ := http.NewRequest("GET", "/api/v1/search?q=roadmap", nil)
+		req.Header.Set("Authorization", "Bearer "+strangerToken)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, decodeResponse(t, response)["data"])
+	})
+
+	t.Run("expects to tolerate query syntax in search terms", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		createTask(t, projectID, listID, `{"title": "Fix \"quoted\" AND broken (parser)"}`)
+
+		assert.Len(t, search(t, `"quoted" AND broken (parser)`), 1)
+	})
+
+	t.Run("expects matches to be highlighted and ranked by relevance", func(t *testing.T) {
+		skipWithoutFTS(t)
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		createTask(t, projectID, listID, `{"title": "Write the release notes for the next deploy of the API"}`)
+		createTask(t, projectID, listID, `{"title": "Deploy"}`)
+		createTask(t, projectID, listID, `{"title": "Deploy and verify the deploy"}`)
+
+		results := search(t, "deploy")
+		var titles, snippets []string
+		for _, item := range results {
+			result := item.(map[string]interface{})
+			titles = append(titles, result["title"].(string))
+			snippets = append(snippets, result["snippet"].(string))
+		}
+		assert.Equal(t, []string{
+			"Deploy",
+			"Deploy and verify the deploy",
+			"Write the release notes for the next deploy of the API",
+		}, titles)
+		assert.Equal(t, "<mark>Deploy</mark>", snippets[0])
+		assert.Equal(t, "<mark>Deploy</mark> and verify the <mark>deploy</mark>", snippets[1])
+		assert.Contains(t, snippets[2], "<mark>deploy</mark>")
+	})
+
+	t.Run("expects snippets to escape HTML in titles", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		createTask(t, projectID, listID, `{"title": "<img src=x onerror=alert(1)> launch"}`)
+
+		results := search(t, "launch")
+		assert.Len(t, results, 1)
+		snippet := results[0].(map[string]interface{})["snippet"].(string)
+		assert.NotContains(t, snippet, "<img")
+		assert.Contains(t, snippet, "&lt;img src=x onerror=alert(1)&gt;")
+	})
+
+	t.Run("while searching/when q is missing/expects validation error", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/search", nil)
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+	})
+}
+
+// skipWithoutFTS skips tests of ranking and highlighting when SQLite was
+// built without FTS5 and search falls back to substring matching.
+func skipWithoutFTS(t *testing.T) {
+	t.Helper()
+
+	silent := db.Session(&gorm.Session{Logger: logger.Discard})
+	if err := silent.Exec("SELECT rowid FROM tasks_fts LIMIT 0").Error; err != nil {
+		t.Skip("SQLite was built without FTS5 (build tag sqlite_fts5)")
+	}
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go-tasker/internal/storage"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var authorizationPattern = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/([^/]+)/s3/aws4_request, SignedHeaders=([a-z0-9;-]+), Signature=([0-9a-f]{64})$`)
+
+// fakeS3 is a stand-in for an S3-compatible service that keeps objects in
+// memory and rejects requests without a valid Signature Version 4.
+type fakeS3 struct {
+	keyID  string
+	secret string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.verify(r); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		if r.ContentLength < 0 {
+			http.Error(w, "MissingContentLength", http.StatusLengthRequired)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) verify(r *http.Request) error {
+	match := authorizationPattern.FindStringSubmatch(r.Header.Get("Authorization"))
+	if match == nil {
+		return fmt.Errorf("malformed Authorization header")
+	}
+	keyID, date, region, signedHeaders, signature := match[1], match[2], match[3], match[4], match[5]
+	if keyID != f.keyID {
+		return fmt.Errorf("unknown access key")
+	}
+
+	var headers strings.Builder
+	for _, name := range strings.Split(signedHeaders, ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method, r.URL.EscapedPath(), r.URL.RawQuery, headers.String(), signedHeaders, r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" +
+		date + "/" + region + "/s3/aws4_request\n" + hex.EncodeToString(sum[:])
+
+	key := []byte("AWS4" + f.secret)
+	for _, part := range []string{date, region, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	if hex.EncodeToString(key) != signature {
+		return fmt.Errorf("SignatureDoesNotMatch")
+	}
+	return nil
+}
+
+func TestS3Storage(t *testing.T) {
+	fake := &fakeS3{keyID: "AKIDEXAMPLE", secret: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	key := strings.Repeat("ab", 32)
+
+	t.Run("expects blobs to be stored, read and deleted", func(t *testing.T) {
+		store, err := storage.NewS3(storage.S3Config{
+			Endpoint:        server.URL,
+			Region:          "eu-west-1",
+			Bucket:          "attachments",
+			AccessKeyID:     fake.keyID,
+			SecretAccessKey: fake.secret,
+		})
+		assert.NoError(t, err)
+
+		content := []byte("# Spec")
+		assert.NoError(t, store.Put(ctx, key, bytes.NewReader(content), int64(len(content))))
+		assert.Equal(t, content, fake.objects["/attachments/"+key])
+
+		blob, err := store.Get(ctx, key)
+		assert.NoError(t, err)
+		read, _ := io.ReadAll(blob)
+		blob.Close()
+		assert.Equal(t, content, read)
+
+		assert.NoError(t, store.Delete(ctx, key))
+		assert.Empty(t, fake.objects)
+
+		_, err = store.Get(ctx, key)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.NoError(t, store.Delete(ctx, key))
+	})
+
+	t.Run("expects requests signed with the wrong secret to fail", func(t *testing.T) {
+		store, err := storage.NewS3(storage.S3Config{
+			Endpoint:        server.URL,
+			Bucket:          "attachments",
+			AccessKeyID:     fake.keyID,
+			SecretAccessKey: "not the secret",
+		})
+		assert.NoError(t, err)
+
+		err = store.Put(ctx, key, strings.NewReader("# Spec"), 6)
+		assert.ErrorContains(t, err, "403")
+		assert.Empty(t, fake.objects)
+	})
+
+	t.Run("expects an incomplete configuration to be refused", func(t *testing.T) {
+		_, err := storage.NewS3(storage.S3Config{Endpoint: server.URL, Bucket: "attachments"})
+		assert.Error(t, err)
+
+		_, err = storage.NewS3(storage.S3Config{Endpoint: "localhost:9000", Bucket: "attachments", AccessKeyID: "a", SecretAccessKey: "b"})
+		assert.Error(t, err)
+	})
+}
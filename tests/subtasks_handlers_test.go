@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubtasks(t *testing.T) {
+	t.Run("expects to fetch a task with its subtree", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/"
+		parentID := createTask(t, projectID, listID, `{"title": "Release"}`)
+		childID := createResource(t, tasksURL+parentID+"/subtasks", `{"title": "Write notes"}`)
+		createResource(t, tasksURL+childID+"/subtasks", `{"title": "Proofread"}`)
+
+		req, _ := http.NewRequest("GET", tasksURL+parentID, nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "Release", data["title"])
+		children := data["subtasks"].([]interface{})
+		assert.Len(t, children, 1)
+
+		child := children[0].(map[string]interface{})
+		assert.Equal(t, "Write notes", child["title"])
+		assert.Equal(t, float64(1), child["parent_id"])
+		assert.Equal(t, "Proofread", child["subtasks"].([]interface{})[0].(map[string]interface{})["title"])
+	})
+
+	t.Run("expects to roll up completion from subtasks", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/"
+		parentID := createTask(t, projectID, listID, `{"title": "Release"}`)
+		childID := createResource(t, tasksURL+parentID+"/subtasks", `{"title": "Write notes"}`)
+
+		req, _ := http.NewRequest("PATCH", tasksURL+parentID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", tasksURL+parentID+"?rollup=true", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, false, decodeResponse(t, response)["data"].(map[string]interface{})["done"])
+
+		req, _ = http.NewRequest("PATCH", tasksURL+childID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", tasksURL+parentID+"?rollup=true", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, true, decodeResponse(t, response)["data"].(map[string]interface{})["done"])
+	})
+
+	t.Run("expects to reject cycles and cross-list parents", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		otherListID := createList(t, projectID, "Other")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/"
+		parentID := createTask(t, projectID, listID, `{"title": "Parent"}`)
+		childID := createResource(t, tasksURL+parentID+"/subtasks", `{"title": "Child"}`)
+		otherID := createTask(t, projectID, otherListID, `{"title": "Elsewhere"}`)
+
+		payload := []byte(`{"parent_id": ` + childID + `}`)
+		req, _ := http.NewRequest("PUT", tasksURL+parentID+"/parent", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		payload = []byte(`{"parent_id": ` + parentID + `}`)
+		req, _ = http.NewRequest("PUT", tasksURL+parentID+"/parent", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		payload = []byte(`{"parent_id": ` + otherID + `}`)
+		req, _ = http.NewRequest("PUT", tasksURL+childID+"/parent", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		payload = []byte(`{"title": "Stray", "parent_id": ` + otherID + `}`)
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		payload = []byte(`{"parent_id": null}`)
+		req, _ = http.NewRequest("PUT", tasksURL+childID+"/parent", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Nil(t, decodeResponse(t, response)["data"].(map[string]interface{})["parent_id"])
+	})
+
+	t.Run("expects deleting a task to delete its subtasks", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		tasksURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/"
+		parentID := createTask(t, projectID, listID, `{"title": "Parent"}`)
+		createResource(t, tasksURL+parentID+"/subtasks", `{"title": "Child"}`)
+
+		req, _ := http.NewRequest("DELETE", tasksURL+parentID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, titlesOf(t, response))
+	})
+}
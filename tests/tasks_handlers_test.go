@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,7 +16,7 @@ func TestTasks(t *testing.T) {
 		clearTables()
 
 		// Create a project
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 		checkResponseCode(t, http.StatusCreated, response.Code)
@@ -62,7 +63,7 @@ func TestTasks(t *testing.T) {
 		clearTables()
 
 		// Create project
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 		checkResponseCode(t, http.StatusCreated, response.Code)
@@ -112,7 +113,7 @@ func TestTasks(t *testing.T) {
 		clearTables()
 
 		// Create project
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 		checkResponseCode(t, http.StatusCreated, response.Code)
@@ -152,7 +153,7 @@ func TestTasks(t *testing.T) {
 		clearTables()
 
 		// Create project
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 		checkResponseCode(t, http.StatusCreated, response.Code)
@@ -214,7 +215,7 @@ func TestTasks(t *testing.T) {
 		clearTables()
 
 		// Create project
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 		checkResponseCode(t, http.StatusCreated, response.Code)
@@ -280,7 +281,7 @@ func TestTasks(t *testing.T) {
 		clearTables()
 
 		// Create project
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 		checkResponseCode(t, http.StatusCreated, response.Code)
@@ -336,7 +337,7 @@ func TestTasks(t *testing.T) {
 		clearTables()
 
 		// Create project
-		projectPayload := []byte(`{"title": "Project 1", "status": "not started"}`)
+		projectPayload := []byte(`{"title": "Project 1", "status": "planning"}`)
 		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(projectPayload))
 		response := executeRequest(req)
 		checkResponseCode(t, http.StatusCreated, response.Code)
@@ -385,4 +386,209 @@ func TestTasks(t *testing.T) {
 		response = executeRequest(req)
 		checkResponseCode(t, http.StatusNotFound, response.Code)
 	})
-}
\ No newline at end of file
+}
+
+func TestTaskSchedule(t *testing.T) {
+	t.Run("expects to create a task with start and due dates", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+
+		taskPayload := []byte(`{"title": "Task 1", "start_at": "2024-05-01T09:00:00Z", "due_at": "2024-05-03T17:00:00Z"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", bytes.NewReader(taskPayload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "2024-05-01T09:00:00Z", data["start_at"])
+		assert.Equal(t, "2024-05-03T17:00:00Z", data["due_at"])
+	})
+
+	t.Run("expects to reject a start date after the due date", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+
+		taskPayload := []byte(`{"title": "Task 1", "start_at": "2024-05-04T09:00:00Z", "due_at": "2024-05-03T17:00:00Z"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", bytes.NewReader(taskPayload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+		assert.Equal(t, "Invalid fields: due_at must not be before start_at", decodeResponse(t, response)["error"])
+	})
+
+	t.Run("expects to get overdue tasks across a project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		todoID := createList(t, projectID, "Todo")
+		doingID := createList(t, projectID, "Doing")
+
+		past := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+		future := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+
+		createTask(t, projectID, todoID, `{"title": "Late", "due_at": "`+past+`"}`)
+		createTask(t, projectID, doingID, `{"title": "Also late", "due_at": "`+past+`"}`)
+		createTask(t, projectID, todoID, `{"title": "On time", "due_at": "`+future+`"}`)
+		doneID := createTask(t, projectID, todoID, `{"title": "Late but done", "due_at": "`+past+`"}`)
+		createTask(t, projectID, todoID, `{"title": "No due date"}`)
+
+		req, _ := http.NewRequest("PATCH", "/api/v1/projects/"+projectID+"/lists/"+todoID+"/tasks/"+doneID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/tasks/overdue", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		assert.ElementsMatch(t, []string{"Late", "Also late"}, titlesOf(t, response))
+	})
+
+	t.Run("expects to get tasks due in a window", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+
+		createTask(t, projectID, listID, `{"title": "Before", "due_at": "2024-04-30T12:00:00Z"}`)
+		createTask(t, projectID, listID, `{"title": "Inside", "due_at": "2024-05-02T12:00:00Z"}`)
+		createTask(t, projectID, listID, `{"title": "After", "due_at": "2024-05-08T12:00:00Z"}`)
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/tasks/due?from=2024-05-01T00:00:00Z&to=2024-05-08T00:00:00Z", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		data := decodeResponse(t, response)["data"].([]interface{})
+		assert.Len(t, data, 1)
+		assert.Equal(t, "Inside", data[0].(map[string]interface{})["title"])
+	})
+
+	t.Run("expects to reject an invalid window", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/tasks/due?from=yesterday", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestTaskPriority(t *testing.T) {
+	t.Run("expects new tasks to default to no priority", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+
+		taskPayload := []byte(`{"title": "Task 1"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", bytes.NewReader(taskPayload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "none", data["priority"])
+	})
+
+	t.Run("expects to reject an unknown priority", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+
+		taskPayload := []byte(`{"title": "Task 1", "priority": "critical"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", bytes.NewReader(taskPayload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+		assert.Equal(t, "Invalid fields: priority must be one of: none low medium high urgent", decodeResponse(t, response)["error"])
+	})
+
+	t.Run("expects to sort and filter tasks by priority", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+
+		createTask(t, projectID, listID, `{"title": "Medium", "priority": "medium"}`)
+		createTask(t, projectID, listID, `{"title": "Urgent", "priority": "urgent"}`)
+		createTask(t, projectID, listID, `{"title": "Low", "priority": "low"}`)
+		createTask(t, projectID, listID, `{"title": "High", "priority": "high"}`)
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks?sort=-priority", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		assert.Equal(t, []string{"Urgent", "High", "Medium", "Low"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks?priority=low,high&sort=priority", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		assert.Equal(t, []string{"Low", "High"}, titlesOf(t, response))
+	})
+
+	t.Run("expects to reject an unknown sort", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks?sort=colour", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestTasksPagination(t *testing.T) {
+	t.Run("expects to page through tasks sorted by priority", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+
+		createTask(t, projectID, listID, `{"title": "A", "priority": "high"}`)
+		createTask(t, projectID, listID, `{"title": "B", "priority": "low"}`)
+		createTask(t, projectID, listID, `{"title": "C", "priority": "high"}`)
+		createTask(t, projectID, listID, `{"title": "D", "priority": "urgent"}`)
+
+		url := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks?limit=2&sort=-priority"
+		req, _ := http.NewRequest("GET", url, nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"D", "C"}, titlesOf(t, response))
+
+		cursor := decodeResponse(t, response)["next_cursor"].(string)
+		req, _ = http.NewRequest("GET", url+"&cursor="+cursor, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"A", "B"}, titlesOf(t, response))
+		assert.Nil(t, decodeResponse(t, response)["next_cursor"])
+
+		// A cursor is only valid for the sort order it was issued for
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks?sort=title&cursor="+cursor, nil)
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+	})
+
+	t.Run("expects to filter tasks by done", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+
+		createTask(t, projectID, listID, `{"title": "Open"}`)
+		doneID := createTask(t, projectID, listID, `{"title": "Closed"}`)
+
+		req, _ := http.NewRequest("PATCH", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+doneID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks?done=false", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Open"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks?done=maybe", nil)
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+	})
+}
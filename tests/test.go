@@ -1,10 +1,13 @@
 package tests
 
 import (
+	"bytes"
+	"encoding/json"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 
 	"gorm.io/driver/sqlite"
@@ -14,6 +17,9 @@ import (
 var (
 	s  *http.Server
 	db *gorm.DB
+
+	// authToken authenticates executeRequest calls as the default test user
+	authToken string
 )
 
 type Response struct {
@@ -29,8 +35,23 @@ func clearTableLists() {
 }
 
 func clearTableTasksAndLists() {
+	db.Exec("DELETE FROM idempotent_requests")
+	db.Exec("DELETE FROM webhook_deliveries")
+	db.Exec("DELETE FROM webhooks")
+	db.Exec("DELETE FROM activities")
+	db.Exec("DELETE FROM mentions")
+	db.Exec("DELETE FROM comment_revisions")
+	db.Exec("DELETE FROM comments")
+	db.Exec("DELETE FROM attachments")
+	db.Exec("DELETE FROM blobs")
+	db.Exec("DELETE FROM task_assignees")
+	db.Exec("DELETE FROM task_labels")
+	db.Exec("DELETE FROM task_dependencies")
 	db.Exec("DELETE FROM tasks")
+	db.Exec("DELETE FROM labels")
 	db.Exec("DELETE FROM lists")
+	db.Exec("DELETE FROM project_status_changes")
+	db.Exec("DELETE FROM project_members")
 	db.Exec("DELETE FROM projects")
 	db.Exec("DELETE FROM sqlite_sequence WHERE name='tasks'")    // sqlite3
 	db.Exec("DELETE FROM sqlite_sequence WHERE name='lists'")    // sqlite3
@@ -44,6 +65,8 @@ func clearTableTasksAndLists() {
 }
 
 func clearTableProjects() {
+	db.Exec("DELETE FROM project_status_changes")
+	db.Exec("DELETE FROM project_members")
 	db.Exec("DELETE FROM projects")
 	db.Exec("DELETE FROM sqlite_sequence WHERE name='projects'") // sqlite3
 	// db.Exec("ALTER SEQUENCE projects_id_seq RESTART WITH 1")  // postgres
@@ -55,6 +78,13 @@ func clearTables() {
 	clearTableProjects()
 }
 
+func clearTableUsers() {
+	db.Exec("DELETE FROM api_keys")
+	db.Exec("DELETE FROM users")
+	db.Exec("DELETE FROM sqlite_sequence WHERE name='api_keys'") // sqlite3
+	db.Exec("DELETE FROM sqlite_sequence WHERE name='users'")    // sqlite3
+}
+
 func getDB() *gorm.DB {
 	// Create DB and connect
 	db, err := gorm.Open(sqlite.Open(os.Getenv("DB_URL")), &gorm.Config{})
@@ -65,15 +95,127 @@ func getDB() *gorm.DB {
 	return db
 }
 
+// executeRequest serves req as the default test user unless the request
+// already carries credentials.
 func executeRequest(req *http.Request) *httptest.ResponseRecorder {
+	if req.Header.Get("Authorization") == "" && req.Header.Get("X-API-Key") == "" {
+		req.Header.Set("Authorization", "Bearer "+authToken)
+	}
+
+	return executeAnonymousRequest(req)
+}
+
+func executeAnonymousRequest(req *http.Request) *httptest.ResponseRecorder {
 	rr := httptest.NewRecorder()
 	s.Handler.ServeHTTP(rr, req)
 
 	return rr
 }
 
+// registerAndLogin creates a user with the given username and returns a
+// bearer token for it.
+func registerAndLogin(username string) string {
+	payload := `{"username": "` + username + `", "email": "` + username + `@example.com", "password": "password123"}`
+	req, _ := http.NewRequest("POST", "/api/v1/auth/register", bytes.NewReader([]byte(payload)))
+	if response := executeAnonymousRequest(req); response.Code != http.StatusCreated {
+		log.Fatalf("cannot register %s: %s", username, response.Body.String())
+	}
+
+	payload = `{"username": "` + username + `", "password": "password123"}`
+	req, _ = http.NewRequest("POST", "/api/v1/auth/login", bytes.NewReader([]byte(payload)))
+	response := executeAnonymousRequest(req)
+	if response.Code != http.StatusOK {
+		log.Fatalf("cannot log in as %s: %s", username, response.Body.String())
+	}
+
+	var result struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		log.Fatalf("cannot decode login response: %v", err)
+	}
+	return result.Data.Token
+}
+
 func checkResponseCode(t *testing.T, expected, actual int) {
 	if expected != actual {
 		t.Errorf("Expected response code %d. Got %d\n", expected, actual)
 	}
 }
+
+// createResource POSTs the payload to url, expects a 201 and returns the
+// created record's ID.
+func createResource(t *testing.T, url string, payload string) string {
+	t.Helper()
+
+	req, _ := http.NewRequest("POST", url, bytes.NewReader([]byte(payload)))
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Error unmarshalling response: %v", err)
+	}
+	data, ok := result["data"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected data in response. Got '%s'", response.Body.String())
+	}
+
+	return formatID(data["id"])
+}
+
+// currentUserID returns the ID of the user authenticated by token.
+func currentUserID(t *testing.T, token string) string {
+	t.Helper()
+
+	req, _ := http.NewRequest("GET", "/api/v1/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	return formatID(decodeResponse(t, response)["data"].(map[string]interface{})["id"])
+}
+
+// formatID converts a JSON decoded ID into its path form.
+func formatID(id interface{}) string {
+	return strconv.FormatFloat(id.(float64), 'f', -1, 64)
+}
+
+func createProject(t *testing.T, title string) string {
+	t.Helper()
+	return createResource(t, "/api/v1/projects", `{"title": "`+title+`", "status": "planning"}`)
+}
+
+func createList(t *testing.T, projectID string, title string) string {
+	t.Helper()
+	return createResource(t, "/api/v1/projects/"+projectID+"/lists", `{"title": "`+title+`"}`)
+}
+
+func createTask(t *testing.T, projectID string, listID string, payload string) string {
+	t.Helper()
+	return createResource(t, "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", payload)
+}
+
+// decodeResponse unmarshals a JSON response body into a generic map.
+func decodeResponse(t *testing.T, response *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Error unmarshalling response: %v", err)
+	}
+	return result
+}
+
+// titlesOf returns the title of every record in a collection response.
+func titlesOf(t *testing.T, response *httptest.ResponseRecorder) []string {
+	t.Helper()
+
+	var titles []string
+	for _, item := range decodeResponse(t, response)["data"].([]interface{}) {
+		titles = append(titles, item.(map[string]interface{})["title"].(string))
+	}
+	return titles
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTransfer(t *testing.T) {
+	outsiderToken := registerAndLogin("outsider")
+
+	t.Run("expects to move a task with its subtasks to another list", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		doingID := createList(t, projectID, "In Progress")
+		doneID := createList(t, projectID, "Done")
+		createTask(t, projectID, doneID, `{"title": "Shipped"}`)
+		taskID := createTask(t, projectID, doingID, `{"title": "Release"}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/lists/"+doingID+"/tasks/"+taskID+"/subtasks", `{"title": "Notes"}`)
+
+		payload := []byte(`{"list_id": ` + doneID + `}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+doingID+"/tasks/"+taskID+"/move", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, doneID, formatID(decodeResponse(t, response)["data"].(map[string]interface{})["list_id"]))
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+doingID+"/tasks", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Empty(t, titlesOf(t, response))
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+doneID+"/tasks", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Shipped", "Notes", "Release"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+doneID+"/tasks/"+taskID, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Len(t, decodeResponse(t, response)["data"].(map[string]interface{})["subtasks"], 1)
+	})
+
+	t.Run("expects to move a task to another project with its labels", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		taskID := createTask(t, projectID, listID, `{"title": "Fix login"}`)
+		labelID := createResource(t, "/api/v1/projects/"+projectID+"/labels", `{"name": "bug", "color": "#ff0000"}`)
+
+		payload := []byte(`{"label_id": ` + labelID + `}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/labels", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		otherID := createProject(t, "Project 2")
+		otherListID := createList(t, otherID, "Inbox")
+
+		payload = []byte(`{"project_id": ` + otherID + `, "list_id": ` + otherListID + `}`)
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/move", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+otherID+"/lists/"+otherListID+"/tasks?labels=bug", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Fix login"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+otherID+"/labels", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		label := decodeResponse(t, response)["data"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "#ff0000", label["color"])
+	})
+
+	t.Run("expects to copy a task with its subtasks", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Templates")
+		targetID := createList(t, projectID, "Sprint")
+		taskID := createTask(t, projectID, listID, `{"title": "Onboarding", "priority": "high"}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/subtasks", `{"title": "Laptop"}`)
+
+		payload := []byte(`{"list_id": ` + targetID + `}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/copy", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+
+		data := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "high", data["priority"])
+		copyID := formatID(data["id"])
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+targetID+"/tasks/"+copyID, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		subtasks := decodeResponse(t, response)["data"].(map[string]interface{})["subtasks"].([]interface{})
+		assert.Len(t, subtasks, 1)
+		assert.Equal(t, "Laptop", subtasks[0].(map[string]interface{})["title"])
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Onboarding", "Laptop"}, titlesOf(t, response))
+	})
+
+	t.Run("expects to reject a target list outside the target project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		taskID := createTask(t, projectID, listID, `{"title": "Task 1"}`)
+		otherID := createProject(t, "Project 2")
+
+		payload := []byte(`{"project_id": ` + otherID + `, "list_id": ` + listID + `}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/move", bytes.NewReader(payload))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "target list not found in the target project", decodeResponse(t, response)["error"])
+
+		payload = []byte(`{"project_id": ` + otherID + `}`)
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/copy", bytes.NewReader(payload))
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "Missing required fields: list_id", decodeResponse(t, response)["error"])
+	})
+
+	t.Run("expects not to move a task into a project the caller cannot edit", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Tasks")
+		taskID := createTask(t, projectID, listID, `{"title": "Task 1"}`)
+
+		payload := []byte(`{"title": "Private", "status": "planning"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects", bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+outsiderToken)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		privateID := formatID(decodeResponse(t, response)["data"].(map[string]interface{})["id"])
+
+		payload = []byte(`{"title": "Inbox"}`)
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+privateID+"/lists", bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+outsiderToken)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		privateListID := formatID(decodeResponse(t, response)["data"].(map[string]interface{})["id"])
+
+		payload = []byte(`{"project_id": ` + privateID + `, "list_id": ` + privateListID + `}`)
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/move", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+	})
+}
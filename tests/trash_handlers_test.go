@@ -0,0 +1,257 @@
+package tests
+
+import (
+	"bytes"
+	"go-tasker/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrash(t *testing.T) {
+	outsiderToken := registerAndLogin("binvisitor")
+
+	t.Run("expects a deleted list and its tasks to be restorable", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		earlierID := createTask(t, projectID, listID, `{"title": "Deleted earlier"}`)
+		createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+earlierID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/trash", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		trash := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Len(t, trash["lists"], 1)
+		assert.Len(t, trash["tasks"], 2)
+
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/trash/lists/"+listID+"/restore", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		// Only the tasks deleted along with the list come back
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Write docs"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/trash/tasks/"+earlierID+"/restore", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Deleted earlier", "Write docs"}, titlesOf(t, response))
+	})
+
+	t.Run("expects a task in a deleted list to need its list restored first", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/trash/tasks/"+taskID+"/restore", nil)
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+	})
+
+	t.Run("expects a deleted task to be restored with its subtasks", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Release"}`)
+		subtaskID := createResource(t, "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/subtasks", `{"title": "Notes"}`)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/trash/tasks/"+subtaskID+"/restore", nil)
+		checkResponseCode(t, http.StatusConflict, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/trash/tasks/"+taskID+"/restore", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Release", "Notes"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/trash/tasks/"+taskID+"/restore", nil)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+	})
+
+	t.Run("expects to purge items from the trash", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		blockerID := createTask(t, projectID, listID, `{"title": "Design"}`)
+
+		payload := []byte(`{"blocker_id": ` + blockerID + `}`)
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/dependencies", bytes.NewReader(payload))
+		checkResponseCode(t, http.StatusCreated, executeRequest(req).Code)
+
+		// Live tasks cannot be purged
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/trash/tasks/"+blockerID, nil)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+blockerID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/trash/tasks/"+blockerID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		var count int64
+		db.Table("tasks").Where("id = ?", blockerID).Count(&count)
+		assert.Zero(t, count)
+		db.Table("task_dependencies").Count(&count)
+		assert.Zero(t, count)
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/trash", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/trash", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		trash := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Empty(t, trash["lists"])
+		assert.Empty(t, trash["tasks"])
+	})
+
+	t.Run("expects only the owner to restore a deleted project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/trash/projects", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Project 1"}, titlesOf(t, response))
+
+		req, _ = http.NewRequest("POST", "/api/v1/trash/projects/"+projectID+"/restore", nil)
+		req.Header.Set("Authorization", "Bearer "+outsiderToken)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("POST", "/api/v1/trash/projects/"+projectID+"/restore", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []string{"Write docs"}, titlesOf(t, response))
+	})
+
+	t.Run("expects to permanently delete a deleted project", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		req, _ = http.NewRequest("DELETE", "/api/v1/trash/projects/"+projectID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		var count int64
+		db.Table("tasks").Count(&count)
+		assert.Zero(t, count)
+		db.Table("project_members").Count(&count)
+		assert.Zero(t, count)
+
+		req, _ = http.NewRequest("POST", "/api/v1/trash/projects/"+projectID+"/restore", nil)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+	})
+
+	t.Run("expects items past the retention period to be purged", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		createTask(t, projectID, listID, `{"title": "Design"}`)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		purged, err := database.New().PurgeDeleted(time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Zero(t, purged)
+
+		purged, err = database.New().PurgeDeleted(time.Now().Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		var count int64
+		db.Table("tasks").Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("expects a purged list to take its tasks and their activities", func(t *testing.T) {
+		clearTables()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		projectID := createProject(t, "Project 1")
+		createResource(t, "/api/v1/projects/"+projectID+"/webhooks", `{"url": "`+server.URL+`", "events": ["task.*"]}`)
+		listID := createList(t, projectID, "Backlog")
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		purged, err := database.New().PurgeDeleted(time.Now().Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		var count int64
+		db.Table("tasks").Count(&count)
+		assert.Zero(t, count)
+		db.Table("activities").Where("action = ?", "purged").Count(&count)
+		assert.Equal(t, int64(1), count)
+		db.Table("activities").Where("task_id = ? OR (entity_type = ? AND entity_id = ?)", taskID, "task", taskID).Count(&count)
+		assert.Zero(t, count)
+		db.Table("webhook_deliveries").Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("expects a purged project to take its lists and tasks without recording them", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		purged, err := database.New().PurgeDeleted(time.Now().Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		var count int64
+		db.Table("tasks").Count(&count)
+		assert.Zero(t, count)
+		db.Table("activities").Count(&count)
+		assert.Zero(t, count)
+	})
+}
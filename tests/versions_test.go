@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersions(t *testing.T) {
+	t.Run("expects a stale If-Match to leave the task untouched", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		taskURL := "/api/v1/projects/" + projectID + "/lists/" + listID + "/tasks/" + createTask(t, projectID, listID, `{"title": "Write docs"}`)
+
+		req, _ := http.NewRequest("GET", taskURL, nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"1"`, response.Header().Get("ETag"))
+		assert.Equal(t, float64(1), decodeResponse(t, response)["data"].(map[string]interface{})["version"])
+
+		// Two clients holding version 1: the first update wins
+		req, _ = http.NewRequest("PUT", taskURL, bytes.NewReader([]byte(`{"title": "Write the docs"}`)))
+		req.Header.Set("If-Match", `"1"`)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"2"`, response.Header().Get("ETag"))
+
+		req, _ = http.NewRequest("PUT", taskURL, bytes.NewReader([]byte(`{"title": "Review docs"}`)))
+		req.Header.Set("If-Match", `"1"`)
+		checkResponseCode(t, http.StatusPreconditionFailed, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PATCH", taskURL+"/done", nil)
+		req.Header.Set("If-Match", `"1"`)
+		checkResponseCode(t, http.StatusPreconditionFailed, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("DELETE", taskURL, nil)
+		req.Header.Set("If-Match", `W/"2"`)
+		checkResponseCode(t, http.StatusPreconditionFailed, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", taskURL, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"2"`, response.Header().Get("ETag"))
+		task := decodeResponse(t, response)["data"].(map[string]interface{})
+		assert.Equal(t, "Write the docs", task["title"])
+		assert.Equal(t, false, task["done"])
+
+		// Without If-Match the last write wins
+		req, _ = http.NewRequest("PATCH", taskURL+"/done", nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"3"`, response.Header().Get("ETag"))
+
+		req, _ = http.NewRequest("DELETE", taskURL, nil)
+		req.Header.Set("If-Match", `"3"`)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+	})
+
+	t.Run("expects projects and lists to be versioned", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+
+		req, _ := http.NewRequest("GET", "/api/v1/projects/"+projectID, nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"1"`, response.Header().Get("ETag"))
+
+		req, _ = http.NewRequest("PUT", "/api/v1/projects/"+projectID, bytes.NewReader([]byte(`{"title": "Project 2", "status": "active"}`)))
+		req.Header.Set("If-Match", `"1"`)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"2"`, response.Header().Get("ETag"))
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID, nil)
+		req.Header.Set("If-Match", `"1"`)
+		checkResponseCode(t, http.StatusPreconditionFailed, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("PUT", "/api/v1/projects/"+projectID+"/lists/"+listID, bytes.NewReader([]byte(`{"title": "Doing"}`)))
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"2"`, response.Header().Get("ETag"))
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID, nil)
+		req.Header.Set("If-Match", `"1"`)
+		checkResponseCode(t, http.StatusPreconditionFailed, executeRequest(req).Code)
+
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/lists/"+listID, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"2"`, response.Header().Get("ETag"))
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/lists/"+listID, nil)
+		req.Header.Set("If-Match", `"2"`)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+	})
+}
@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-tasker/internal/webhook"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type receivedDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver records the deliveries it receives and answers them with
+// its current status code.
+type webhookReceiver struct {
+	mu         sync.Mutex
+	status     int
+	deliveries []receivedDelivery
+}
+
+func (wr *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.deliveries = append(wr.deliveries, receivedDelivery{header: r.Header, body: body})
+	w.WriteHeader(wr.status)
+}
+
+func (wr *webhookReceiver) received() []receivedDelivery {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	return append([]receivedDelivery(nil), wr.deliveries...)
+}
+
+// reset forgets the deliveries received so far and answers the next ones
+// with status.
+func (wr *webhookReceiver) reset(status int) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.deliveries = nil
+	wr.status = status
+}
+
+func (wr *webhookReceiver) setStatus(status int) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.status = status
+}
+
+func TestWebhooks(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	t.Run("expects subscribed events to be delivered signed", func(t *testing.T) {
+		clearTables()
+		receiver.reset(http.StatusOK)
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/webhooks", bytes.NewReader([]byte(`{"url": "`+server.URL+`", "events": ["task.created", "task.done"]}`)))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		hook := decodeResponse(t, response)["data"].(map[string]interface{})
+		secret := hook["secret"].(string)
+		webhookID := formatID(hook["id"])
+		assert.Equal(t, true, hook["active"])
+
+		taskID := createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		req, _ = http.NewRequest("PUT", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID, bytes.NewReader([]byte(`{"title": "Write the docs"}`)))
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		req, _ = http.NewRequest("PATCH", "/api/v1/projects/"+projectID+"/lists/"+listID+"/tasks/"+taskID+"/done", nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+
+		assert.Eventually(t, func() bool { return len(receiver.received()) == 2 }, 5*time.Second, 20*time.Millisecond)
+
+		deliveries := receiver.received()
+		assert.Equal(t, "task.created", deliveries[0].header.Get(webhook.EventHeader))
+		assert.Equal(t, "task.done", deliveries[1].header.Get(webhook.EventHeader))
+
+		done := deliveries[1]
+		timestamp, _ := strconv.ParseInt(done.header.Get(webhook.TimestampHeader), 10, 64)
+		assert.Equal(t, webhook.Sign(secret, timestamp, done.body), done.header.Get(webhook.SignatureHeader))
+
+		var payload map[string]interface{}
+		assert.NoError(t, json.Unmarshal(done.body, &payload))
+		assert.Equal(t, "task.done", payload["event"])
+		assert.Equal(t, taskID, formatID(payload["entity_id"]))
+		assert.Equal(t, true, payload["after"].(map[string]interface{})["done"])
+
+		// The secret is only returned when the webhook is created
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/webhooks/"+webhookID, nil)
+		response = executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.NotContains(t, decodeResponse(t, response)["data"], "secret")
+
+		assert.Eventually(t, func() bool {
+			req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/webhooks/"+webhookID+"/deliveries", nil)
+			entries := decodeResponse(t, executeRequest(req))["data"].([]interface{})
+			return len(entries) == 2 && entries[0].(map[string]interface{})["status"] == "succeeded"
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("expects a slow subscriber not to hold up the others", func(t *testing.T) {
+		clearTables()
+		receiver.reset(http.StatusOK)
+
+		release := make(chan struct{})
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+		defer slow.Close()
+		defer close(release)
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		createResource(t, "/api/v1/projects/"+projectID+"/webhooks", `{"url": "`+slow.URL+`", "events": ["task.created"]}`)
+		createResource(t, "/api/v1/projects/"+projectID+"/webhooks", `{"url": "`+server.URL+`", "events": ["task.created"]}`)
+
+		createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		assert.Eventually(t, func() bool { return len(receiver.received()) == 1 }, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("expects failed deliveries to be retried and redeliverable", func(t *testing.T) {
+		clearTables()
+		receiver.reset(http.StatusServiceUnavailable)
+
+		projectID := createProject(t, "Project 1")
+		webhookID := createResource(t, "/api/v1/projects/"+projectID+"/webhooks", `{"url": "`+server.URL+`", "events": ["list.*"]}`)
+		deliveriesURL := "/api/v1/projects/" + projectID + "/webhooks/" + webhookID + "/deliveries"
+		createList(t, projectID, "Backlog")
+
+		var delivery map[string]interface{}
+		assert.Eventually(t, func() bool {
+			req, _ := http.NewRequest("GET", deliveriesURL, nil)
+			entries := decodeResponse(t, executeRequest(req))["data"].([]interface{})
+			if len(entries) == 0 {
+				return false
+			}
+			delivery = entries[0].(map[string]interface{})
+			return delivery["attempts"] == float64(1)
+		}, 5*time.Second, 20*time.Millisecond)
+
+		assert.Equal(t, "list.created", delivery["event"])
+		assert.Equal(t, "pending", delivery["status"])
+		assert.Equal(t, float64(http.StatusServiceUnavailable), delivery["response_status"])
+		nextAttempt, _ := time.Parse(time.RFC3339Nano, delivery["next_attempt_at"].(string))
+		assert.True(t, nextAttempt.After(time.Now().Add(20*time.Second)))
+
+		receiver.setStatus(http.StatusOK)
+		redeliveryID := createResource(t, deliveriesURL+"/"+formatID(delivery["id"])+"/redeliver", "")
+
+		assert.Eventually(t, func() bool {
+			req, _ := http.NewRequest("GET", deliveriesURL, nil)
+			entries := decodeResponse(t, executeRequest(req))["data"].([]interface{})
+			redelivery := entries[0].(map[string]interface{})
+			return formatID(redelivery["id"]) == redeliveryID && redelivery["status"] == "succeeded"
+		}, 5*time.Second, 20*time.Millisecond)
+
+		deliveries := receiver.received()
+		assert.Len(t, deliveries, 2)
+		assert.Equal(t, deliveries[0].body, deliveries[1].body)
+	})
+
+	t.Run("expects unknown events and inactive webhooks to be handled", func(t *testing.T) {
+		clearTables()
+		receiver.reset(http.StatusOK)
+
+		projectID := createProject(t, "Project 1")
+
+		req, _ := http.NewRequest("POST", "/api/v1/projects/"+projectID+"/webhooks", bytes.NewReader([]byte(`{"url": "`+server.URL+`", "events": ["task.finished"]}`)))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+		req, _ = http.NewRequest("POST", "/api/v1/projects/"+projectID+"/webhooks", bytes.NewReader([]byte(`{"url": "ftp://example.com", "events": ["*"]}`)))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req).Code)
+
+		webhookID := createResource(t, "/api/v1/projects/"+projectID+"/webhooks", `{"url": "`+server.URL+`", "events": ["*"], "active": false}`)
+		createList(t, projectID, "Backlog")
+
+		var count int64
+		db.Table("webhook_deliveries").Count(&count)
+		assert.Zero(t, count)
+
+		req, _ = http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/webhooks/"+webhookID, nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		req, _ = http.NewRequest("GET", "/api/v1/projects/"+projectID+"/webhooks/"+webhookID, nil)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req).Code)
+	})
+
+	t.Run("expects deliveries to stay off the server's network and not follow redirects", func(t *testing.T) {
+		for _, url := range []string{"http://127.0.0.1/hook", "http://[::1]/hook", "http://10.0.0.5/hook",
+			"http://192.168.1.1/hook", "http://169.254.169.254/latest/meta-data", "http://0.0.0.0/hook", "http://localhost/hook",
+			"http://100.64.0.1/hook", "http://[64:ff9b::a00:5]/hook"} {
+			assert.ErrorIs(t, webhook.CheckURL(context.Background(), url), webhook.ErrForbiddenAddress, url)
+		}
+
+		// A host resolving to loopback after it was checked is refused when
+		// the delivery connects
+		_, err := webhook.Send(context.Background(), webhook.NewClient(time.Second, false), server.URL, "secret", 1, "task.created", []byte(`{}`))
+		assert.ErrorIs(t, err, webhook.ErrForbiddenAddress)
+
+		target := httptest.NewServer(receiver)
+		defer target.Close()
+		redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+		defer redirect.Close()
+
+		receiver.reset(http.StatusOK)
+		result, err := webhook.Send(context.Background(), webhook.NewClient(time.Second, true), redirect.URL, "secret", 1, "task.created", []byte(`{}`))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusFound, result.StatusCode)
+		assert.False(t, result.OK())
+		assert.Empty(t, receiver.received())
+	})
+}
@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+// openWebSocket connects to the collaboration channel as the user of token.
+// The connection is closed when the test ends.
+func openWebSocket(t *testing.T, serverURL string, token string) *websocket.Conn {
+	t.Helper()
+
+	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(serverURL, "http")+"/api/v1/ws", serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Header.Set("Authorization", "Bearer "+token)
+
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// nextMessage returns the next message of the given type, skipping the
+// others.
+func nextMessage(t *testing.T, conn *websocket.Conn, messageType string) map[string]interface{} {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var message map[string]interface{}
+		if err := websocket.JSON.Receive(conn, &message); err != nil {
+			t.Fatalf("no %s message received: %v", messageType, err)
+		}
+		if message["type"] == messageType {
+			return message
+		}
+	}
+}
+
+func usernamesOf(message map[string]interface{}) []string {
+	var usernames []string
+	for _, user := range message["users"].([]interface{}) {
+		usernames = append(usernames, user.(map[string]interface{})["username"].(string))
+	}
+	return usernames
+}
+
+func TestWebSocket(t *testing.T) {
+	server := httptest.NewServer(s.Handler)
+	defer server.Close()
+
+	collaboratorToken := registerAndLogin("collaborator")
+	strangerToken := registerAndLogin("wsstranger")
+
+	t.Run("expects to require authentication", func(t *testing.T) {
+		config, _ := websocket.NewConfig("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/ws", server.URL)
+		_, err := websocket.DialConfig(config)
+		assert.Error(t, err)
+	})
+
+	t.Run("expects presence and changes to reach every subscriber", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "collaborator", "role": "editor"}`)
+
+		owner := openWebSocket(t, server.URL, authToken)
+		assert.NoError(t, websocket.JSON.Send(owner, map[string]interface{}{"type": "subscribe", "project_id": json.Number(projectID)}))
+		assert.Equal(t, []string{"tester"}, usernamesOf(nextMessage(t, owner, "presence")))
+
+		collaborator := openWebSocket(t, server.URL, collaboratorToken)
+		assert.NoError(t, websocket.JSON.Send(collaborator, map[string]interface{}{"type": "subscribe", "project_id": json.Number(projectID)}))
+		assert.Equal(t, []string{"collaborator", "tester"}, usernamesOf(nextMessage(t, owner, "presence")))
+		assert.Equal(t, []string{"collaborator", "tester"}, usernamesOf(nextMessage(t, collaborator, "presence")))
+
+		// Changes made over REST are sent too
+		createTask(t, projectID, listID, `{"title": "Write docs"}`)
+		event := nextMessage(t, collaborator, "event")
+		assert.Equal(t, "task.created", event["event"])
+		assert.Equal(t, "Write docs", event["data"].(map[string]interface{})["after"].(map[string]interface{})["title"])
+		nextMessage(t, owner, "event")
+
+		assert.NoError(t, websocket.JSON.Send(collaborator, map[string]interface{}{
+			"type":   "request",
+			"id":     "1",
+			"method": "POST",
+			"path":   "/projects/" + projectID + "/lists/" + listID + "/tasks",
+			"body":   map[string]interface{}{"title": "Review docs"},
+		}))
+		response := nextMessage(t, collaborator, "response")
+		assert.Equal(t, "1", response["id"])
+		assert.Equal(t, float64(http.StatusCreated), response["status"])
+		assert.Equal(t, "Review docs", response["body"].(map[string]interface{})["data"].(map[string]interface{})["title"])
+
+		event = nextMessage(t, owner, "event")
+		assert.Equal(t, "task.created", event["event"])
+		assert.Equal(t, currentUserID(t, collaboratorToken), formatID(event["data"].(map[string]interface{})["actor_id"]))
+
+		collaborator.Close()
+		assert.Equal(t, []string{"tester"}, usernamesOf(nextMessage(t, owner, "presence")))
+	})
+
+	t.Run("expects requests to be validated like over HTTP", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "collaborator", "role": "viewer"}`)
+
+		conn := openWebSocket(t, server.URL, authToken)
+		assert.NoError(t, websocket.JSON.Send(conn, map[string]interface{}{
+			"type":   "request",
+			"id":     "1",
+			"method": "POST",
+			"path":   "/projects/" + projectID + "/lists/" + listID + "/tasks",
+			"body":   map[string]interface{}{"priority": "urgent"},
+		}))
+		response := nextMessage(t, conn, "response")
+		assert.Equal(t, float64(http.StatusBadRequest), response["status"])
+
+		assert.NoError(t, websocket.JSON.Send(conn, map[string]interface{}{
+			"type":   "request",
+			"id":     "2",
+			"method": "DELETE",
+			"path":   "/projects/" + projectID,
+		}))
+		assert.Equal(t, "2", nextMessage(t, conn, "error")["id"])
+
+		viewer := openWebSocket(t, server.URL, collaboratorToken)
+		assert.NoError(t, websocket.JSON.Send(viewer, map[string]interface{}{
+			"type":   "request",
+			"id":     "3",
+			"method": "POST",
+			"path":   "/projects/" + projectID + "/lists/" + listID + "/tasks",
+			"body":   map[string]interface{}{"title": "Review docs"},
+		}))
+		assert.Equal(t, float64(http.StatusForbidden), nextMessage(t, viewer, "response")["status"])
+	})
+
+	t.Run("expects non-members not to subscribe", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+
+		conn := openWebSocket(t, server.URL, strangerToken)
+		assert.NoError(t, websocket.JSON.Send(conn, map[string]interface{}{"type": "subscribe", "project_id": json.Number(projectID)}))
+		assert.Equal(t, "project not found", nextMessage(t, conn, "error")["message"])
+	})
+
+	t.Run("expects removed members to be unsubscribed", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		createResource(t, "/api/v1/projects/"+projectID+"/members", `{"username": "collaborator", "role": "viewer"}`)
+
+		conn := openWebSocket(t, server.URL, collaboratorToken)
+		assert.NoError(t, websocket.JSON.Send(conn, map[string]interface{}{"type": "subscribe", "project_id": json.Number(projectID)}))
+		nextMessage(t, conn, "presence")
+
+		req, _ := http.NewRequest("DELETE", "/api/v1/projects/"+projectID+"/members/"+currentUserID(t, collaboratorToken), nil)
+		checkResponseCode(t, http.StatusOK, executeRequest(req).Code)
+		assert.Equal(t, projectID, formatID(nextMessage(t, conn, "unsubscribed")["project_id"]))
+
+		assert.NoError(t, websocket.JSON.Send(conn, map[string]interface{}{"type": "subscribe", "project_id": json.Number(projectID)}))
+		assert.Equal(t, "project not found", nextMessage(t, conn, "error")["message"])
+	})
+
+	t.Run("expects connections to close once their user is gone or they expire", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+		listID := createList(t, projectID, "Backlog")
+
+		leaver := openWebSocket(t, server.URL, registerAndLogin("wsleaver"))
+		db.Exec("DELETE FROM users WHERE username = ?", "wsleaver")
+		assert.NoError(t, websocket.JSON.Send(leaver, map[string]interface{}{
+			"type":   "request",
+			"id":     "1",
+			"method": "POST",
+			"path":   "/projects/" + projectID + "/lists/" + listID + "/tasks",
+			"body":   map[string]interface{}{"title": "Write docs"},
+		}))
+		var message map[string]interface{}
+		leaver.SetReadDeadline(time.Now().Add(5 * time.Second))
+		assert.ErrorIs(t, websocket.JSON.Receive(leaver, &message), io.EOF)
+
+		// WS_MAX_LIFETIME is two seconds in the tests
+		conn := openWebSocket(t, server.URL, authToken)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		assert.ErrorIs(t, websocket.JSON.Receive(conn, &message), io.EOF)
+	})
+
+	t.Run("expects browsers to connect with a stream token from allowed origins", func(t *testing.T) {
+		clearTables()
+
+		projectID := createProject(t, "Project 1")
+
+		req, _ := http.NewRequest("POST", "/api/v1/auth/stream-token", nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		token := decodeResponse(t, response)["data"].(map[string]interface{})["token"].(string)
+
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws"
+		dial := func(url string, origin string, protocols ...string) (*websocket.Conn, error) {
+			config, err := websocket.NewConfig(url, origin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config.Protocol = protocols
+			return websocket.DialConfig(config)
+		}
+
+		conn, err := dial(wsURL, "https://app.example.com", "tasker", "bearer."+token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		assert.Equal(t, []string{"tasker"}, conn.Config().Protocol)
+		assert.NoError(t, websocket.JSON.Send(conn, map[string]interface{}{"type": "subscribe", "project_id": json.Number(projectID)}))
+		assert.Equal(t, []string{"tester"}, usernamesOf(nextMessage(t, conn, "presence")))
+
+		conn, err = dial(wsURL+"?access_token="+token, server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+
+		_, err = dial(wsURL+"?access_token="+token, "https://evil.example.com")
+		assert.Error(t, err)
+		_, err = dial(wsURL, server.URL, "tasker", "bearer."+authToken)
+		assert.Error(t, err)
+	})
+}
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type CreateListPayload struct {
 	Title string `json:"title" validate:"required"`
 }
@@ -9,24 +11,231 @@ type UpdateListPayload struct {
 }
 
 type CreateTaskPayload struct {
-	Title string `json:"title" validate:"required"`
+	Title    string     `json:"title" validate:"required"`
+	Priority string     `json:"priority" validate:"omitempty,oneof=none low medium high urgent"`
+	StartAt  *time.Time `json:"start_at"`
+	DueAt    *time.Time `json:"due_at"`
+	// Recurrence is an iCalendar RRULE such as FREQ=WEEKLY;BYDAY=MO. The
+	// series starts at AnchorAt, which defaults to DueAt.
+	Recurrence string     `json:"recurrence" validate:"omitempty,rrule"`
+	AnchorAt   *time.Time `json:"anchor_at"`
+	ParentID   *uint      `json:"parent_id"`
 }
 
 type UpdateTaskPayload struct {
-	Title string `json:"title" validate:"required"`
-	Done  bool   `json:"done"`
+	Title    string     `json:"title" validate:"required"`
+	Done     bool       `json:"done"`
+	Priority string     `json:"priority" validate:"omitempty,oneof=none low medium high urgent"`
+	StartAt  *time.Time `json:"start_at"`
+	DueAt    *time.Time `json:"due_at"`
+	// Recurrence is an iCalendar RRULE such as FREQ=WEEKLY;BYDAY=MO. The
+	// series starts at AnchorAt, which defaults to DueAt.
+	Recurrence string     `json:"recurrence" validate:"omitempty,rrule"`
+	AnchorAt   *time.Time `json:"anchor_at"`
+}
+
+// PageQuery holds the cursor pagination parameters shared by every
+// collection endpoint. Cursor is the opaque next_cursor of a previous page.
+type PageQuery struct {
+	Limit  int    `json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `json:"cursor"`
+}
+
+// ProjectQuery holds the filters and ordering accepted when listing projects.
+type ProjectQuery struct {
+	PageQuery
+	Status        string     `json:"status"`
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+	Sort          string     `json:"sort" validate:"omitempty,oneof=id -id title -title created_at -created_at updated_at -updated_at"`
+}
+
+// ListQuery holds the filters and ordering accepted when listing lists.
+type ListQuery struct {
+	PageQuery
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+	Sort          string     `json:"sort" validate:"omitempty,oneof=position -position id -id title -title created_at -created_at updated_at -updated_at"`
+}
+
+// TaskQuery holds the filters and ordering accepted when listing tasks.
+type TaskQuery struct {
+	PageQuery
+	Priorities    []string   `json:"priority" validate:"dive,oneof=none low medium high urgent"`
+	Done          *bool      `json:"done"`
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+	Labels        []string   `json:"labels"`
+	LabelMatch    string     `json:"label_match" validate:"omitempty,oneof=any all"`
+	Sort          string     `json:"sort" validate:"omitempty,oneof=position -position id -id title -title created_at -created_at updated_at -updated_at priority -priority"`
+}
+
+// ActivityQuery holds the filters accepted by the activity feeds, which are
+// always ordered newest first.
+type ActivityQuery struct {
+	PageQuery
+	EntityType string `json:"entity_type" validate:"omitempty,oneof=project list task label member comment attachment"`
+	Action     string `json:"action"`
 }
 
 type UpdateTaskDonePayload struct {
 	Done bool `json:"done"`
+	// Force completes the task even while tasks blocking it are still open.
+	Force bool `json:"force"`
+}
+
+type CreateCommentPayload struct {
+	Body string `json:"body" validate:"required,max=10000"`
 }
 
+type UpdateCommentPayload struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+type CreateWebhookPayload struct {
+	URL    string   `json:"url" validate:"required,http_url"`
+	Events []string `json:"events" validate:"required,min=1,dive,webhook_event"`
+	// Active defaults to true.
+	Active *bool `json:"active"`
+}
+
+type UpdateWebhookPayload struct {
+	URL    string   `json:"url" validate:"required,http_url"`
+	Events []string `json:"events" validate:"required,min=1,dive,webhook_event"`
+	Active bool     `json:"active"`
+}
+
+// CreateProjectPayload creates a project. Status defaults to the initial
+// status of the workflow, the only one a project can start in.
 type CreateProjectPayload struct {
 	Title  string `json:"title" validate:"required"`
-	Status string `json:"status" validate:"required"`
+	Status string `json:"status"`
 }
 
 type UpdateProjectPayload struct {
 	Title  string `json:"title" validate:"required"`
 	Status string `json:"status" validate:"required"`
 }
+
+// ProjectTransitionPayload moves a project to another status of the
+// workflow, with an optional comment kept in its status history.
+type ProjectTransitionPayload struct {
+	Status  string `json:"status" validate:"required"`
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+type RegisterPayload struct {
+	Username string `json:"username" validate:"required,alphanum,min=3,max=32"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type LoginPayload struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type CreateAPIKeyPayload struct {
+	Name      string     `json:"name" validate:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type AddProjectMemberPayload struct {
+	Username string `json:"username" validate:"required"`
+	Role     string `json:"role" validate:"required,oneof=viewer editor owner"`
+}
+
+type UpdateProjectMemberPayload struct {
+	Role string `json:"role" validate:"required,oneof=viewer editor owner"`
+}
+
+// SetTaskParentPayload moves a task under another task of the same list,
+// or back to the top level when ParentID is null.
+type SetTaskParentPayload struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+// OccurrencesQuery selects how many upcoming occurrences of a recurring
+// task to preview.
+type OccurrencesQuery struct {
+	Count int `json:"count" validate:"min=1,max=100"`
+}
+
+// MovePayload places an item right before or right after a sibling.
+// Exactly one of BeforeID and AfterID must be set.
+type MovePayload struct {
+	BeforeID *uint `json:"before_id"`
+	AfterID  *uint `json:"after_id"`
+}
+
+// MoveTaskPayload moves a task with its subtasks. With ListID the task goes
+// to that list, of ProjectID or of its current project, at the end unless a
+// sibling of the target list is given. Without ListID it is reordered within
+// its list and exactly one of BeforeID and AfterID must be set.
+type MoveTaskPayload struct {
+	BeforeID  *uint `json:"before_id"`
+	AfterID   *uint `json:"after_id"`
+	ListID    *uint `json:"list_id"`
+	ProjectID *uint `json:"project_id"`
+}
+
+// CopyTaskPayload copies a task with its subtasks to the end of a list, of
+// ProjectID or of the task's current project. Without ListID the copy goes
+// to the task's own list.
+type CopyTaskPayload struct {
+	ListID    *uint `json:"list_id"`
+	ProjectID *uint `json:"project_id"`
+}
+
+// BulkTaskPayload applies one operation to many tasks, picked either by ID
+// or with a filter. Rename replaces every match of Pattern in the titles
+// with Replacement, which can refer to groups as $1. Move sends the tasks to
+// the end of another list of the same project. With Atomic nothing changes
+// unless the operation succeeds for every task.
+type BulkTaskPayload struct {
+	Operation   string          `json:"operation" validate:"required,oneof=done undone rename move delete"`
+	TaskIDs     []uint          `json:"task_ids" validate:"max=1000"`
+	Filter      *BulkTaskFilter `json:"filter"`
+	Pattern     string          `json:"pattern" validate:"required_if=Operation rename,omitempty,regexp"`
+	Replacement string          `json:"replacement"`
+	ListID      *uint           `json:"list_id" validate:"required_if=Operation move"`
+	Force       bool            `json:"force"`
+	Atomic      bool            `json:"atomic"`
+}
+
+// BulkTaskFilter picks the tasks of a bulk operation. Tasks must match every
+// field that is set, and carry at least one of Labels.
+type BulkTaskFilter struct {
+	Done       *bool      `json:"done"`
+	Priorities []string   `json:"priority" validate:"dive,oneof=none low medium high urgent"`
+	Labels     []string   `json:"labels"`
+	DueBefore  *time.Time `json:"due_before"`
+	Title      string     `json:"title"`
+}
+
+type AddTaskDependencyPayload struct {
+	BlockerID uint `json:"blocker_id" validate:"required"`
+}
+
+type AssignTaskPayload struct {
+	UserID uint `json:"user_id" validate:"required"`
+}
+
+type CreateLabelPayload struct {
+	Name  string `json:"name" validate:"required,max=50,excludes=0x2C"`
+	Color string `json:"color" validate:"omitempty,hexcolor"`
+}
+
+type UpdateLabelPayload struct {
+	Name  string `json:"name" validate:"required,max=50,excludes=0x2C"`
+	Color string `json:"color" validate:"omitempty,hexcolor"`
+}
+
+type AttachLabelPayload struct {
+	LabelID uint `json:"label_id" validate:"required"`
+}
+
+type SearchQuery struct {
+	Q     string `json:"q" validate:"required"`
+	Limit int    `json:"limit" validate:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,401 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// Patch is a partial update read from a PATCH request: an RFC 7396 JSON
+// Merge Patch, or an RFC 6902 JSON Patch when JSON is set.
+type Patch struct {
+	Body []byte
+	JSON bool
+}
+
+// ReadPatch reads the patch in the request body. Plain application/json
+// bodies are taken as merge patches. For any other content type it writes a
+// 415 response listing the accepted ones in Accept-Patch and returns an
+// error.
+func ReadPatch(w http.ResponseWriter, r *http.Request) (*Patch, error) {
+	if r.Body == nil {
+		err := fmt.Errorf("missing request body")
+		WriteError(w, http.StatusBadRequest, err)
+		return nil, err
+	}
+
+	var patch Patch
+	mediaType := "application/json"
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			mediaType = contentType
+		}
+	}
+	switch mediaType {
+	case MergePatchContentType, "application/json":
+	case JSONPatchContentType:
+		patch.JSON = true
+	default:
+		err := fmt.Errorf("unsupported patch content type %q", mediaType)
+		w.Header().Set("Accept-Patch", MergePatchContentType+", "+JSONPatchContentType)
+		WriteError(w, http.StatusUnsupportedMediaType, err)
+		return nil, err
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(body) {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, fmt.Errorf("invalid patch body")
+	}
+	patch.Body = body
+
+	return &patch, nil
+}
+
+// ApplyAndValidate applies the patch to the JSON form of current and
+// decodes the result into payload, which is then validated like a full
+// request body. Fields unknown to payload are rejected. On failure it
+// writes a 400 response, or a 409 response when a JSON Patch test
+// operation fails, and returns the error.
+func (p *Patch) ApplyAndValidate(w http.ResponseWriter, current any, payload any) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		WriteInternalServerError(w, err)
+		return err
+	}
+
+	if p.JSON {
+		doc, err = JSONPatch(doc, p.Body)
+	} else {
+		doc, err = MergePatch(doc, p.Body)
+	}
+	if errors.Is(err, ErrPatchTestFailed) {
+		WriteError(w, http.StatusConflict, err)
+		return err
+	}
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(payload); err != nil {
+		WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid patch: %w", err))
+		return err
+	}
+
+	return ValidateStruct(w, payload)
+}
+
+// MergePatch applies an RFC 7396 JSON Merge Patch to doc: members of patch
+// objects replace those of doc, recursively, and null members remove them.
+func MergePatch(doc []byte, patch []byte) ([]byte, error) {
+	var target, changes any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatch(target, changes))
+}
+
+func mergePatch(target any, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	merged, ok := target.(map[string]any)
+	if !ok {
+		merged = make(map[string]any)
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(merged, name)
+		} else {
+			merged[name] = mergePatch(merged[name], value)
+		}
+	}
+	return merged
+}
+
+// ErrPatchTestFailed is returned by JSONPatch when a test operation does
+// not match the document.
+var ErrPatchTestFailed = errors.New("json patch test operation failed")
+
+type jsonPatchOperation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies the operations of an RFC 6902 JSON Patch to doc in
+// order. It fails without a partial result when any operation does.
+func JSONPatch(doc []byte, patch []byte) ([]byte, error) {
+	var root any
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, err
+	}
+
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("a json patch must be an array of operations")
+	}
+
+	for i, operation := range operations {
+		var err error
+		if root, err = applyJSONPatchOperation(root, operation); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+func applyJSONPatchOperation(root any, operation jsonPatchOperation) (any, error) {
+	if operation.Path == nil {
+		return nil, fmt.Errorf("missing path")
+	}
+	path, err := parseJSONPointer(*operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	switch operation.Op {
+	case "add", "replace", "test":
+		if operation.Value == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+		if err := json.Unmarshal(*operation.Value, &value); err != nil {
+			return nil, err
+		}
+	case "move", "copy":
+		if operation.From == nil {
+			return nil, fmt.Errorf("missing from")
+		}
+		from, err := parseJSONPointer(*operation.From)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = getJSONPointer(root, from); err != nil {
+			return nil, err
+		}
+		if operation.Op == "copy" {
+			// Decode a fresh value so the copy does not share maps and
+			// slices with the original
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			value = nil
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, err
+			}
+		}
+		if operation.Op == "move" {
+			if isJSONPointerPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("cannot move a value into one of its children")
+			}
+			if root, err = removeJSONPointer(root, from); err != nil {
+				return nil, err
+			}
+		}
+	case "remove":
+	default:
+		return nil, fmt.Errorf("unknown op %q", operation.Op)
+	}
+
+	switch operation.Op {
+	case "add", "move", "copy":
+		return addJSONPointer(root, path, value)
+	case "remove":
+		return removeJSONPointer(root, path)
+	case "replace":
+		if _, err := getJSONPointer(root, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if root, err = removeJSONPointer(root, path); err != nil {
+			return nil, err
+		}
+		return addJSONPointer(root, path, value)
+	default:
+		current, err := getJSONPointer(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w at %q", ErrPatchTestFailed, *operation.Path)
+		}
+		return root, nil
+	}
+}
+
+// parseJSONPointer splits an RFC 6901 JSON Pointer into its unescaped
+// reference tokens.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func isJSONPointerPrefix(prefix []string, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func getJSONPointer(node any, path []string) (any, error) {
+	for _, token := range path {
+		switch container := node.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q does not exist", token)
+			}
+			node = value
+		case []any:
+			index, err := jsonArrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = container[index]
+		default:
+			return nil, fmt.Errorf("path %q does not exist", token)
+		}
+	}
+	return node, nil
+}
+
+// addJSONPointer returns root with value added at path, replacing an
+// existing object member or inserting into an array.
+func addJSONPointer(root any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := getJSONPointer(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		container[token] = value
+		return root, nil
+	case []any:
+		index := len(container)
+		if token != "-" {
+			if index, err = jsonArrayIndex(token, len(container)); err != nil {
+				return nil, err
+			}
+		}
+		container = append(container, nil)
+		copy(container[index+1:], container[index:])
+		container[index] = value
+		return replaceJSONPointer(root, path[:len(path)-1], container)
+	default:
+		return nil, fmt.Errorf("path %q does not exist", token)
+	}
+}
+
+func removeJSONPointer(root any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+
+	parent, err := getJSONPointer(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		if _, ok := container[token]; !ok {
+			return nil, fmt.Errorf("path %q does not exist", token)
+		}
+		delete(container, token)
+		return root, nil
+	case []any:
+		index, err := jsonArrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		container = append(container[:index], container[index+1:]...)
+		return replaceJSONPointer(root, path[:len(path)-1], container)
+	default:
+		return nil, fmt.Errorf("path %q does not exist", token)
+	}
+}
+
+// replaceJSONPointer stores an array that changed length back into its
+// parent, since growing or shrinking a slice does not update the parent.
+func replaceJSONPointer(root any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := getJSONPointer(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		container[token] = value
+	case []any:
+		index, err := jsonArrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = value
+	}
+	return root, nil
+}
+
+// jsonArrayIndex parses an array index token no greater than max.
+func jsonArrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return index, nil
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -38,19 +39,35 @@ func ParseAndValidateJSON(w http.ResponseWriter, r *http.Request, payload any) e
 	}
 
 	// Validate the payload
-	if err := Validate.Struct(payload); err != nil {
+	return ValidateStruct(w, payload)
+}
+
+// ValidateStruct validates v with Validate and, on failure, writes a 400
+// response describing the missing and invalid fields.
+func ValidateStruct(w http.ResponseWriter, v any) error {
+	if err := Validate.Struct(v); err != nil {
 		var missingFields []string
+		var invalidFields []string
 		for _, err := range err.(validator.ValidationErrors) {
-			field := err.Field()
+			field := strings.ToLower(err.Field())
 			tag := err.Tag()
 			if tag == "required" {
-				field = strings.ToLower(field)
 				missingFields = append(missingFields, field)
+			} else {
+				invalidFields = append(invalidFields, field+" "+describeValidationError(err))
 			}
 		}
-		errorMessage :=
-			fmt.Sprintf("Missing required fields: %s", strings.Join(missingFields, ", "))
-		WriteError(w, http.StatusBadRequest, fmt.Errorf(errorMessage))
+
+		var errorMessages []string
+		if len(missingFields) > 0 {
+			errorMessages = append(errorMessages,
+				fmt.Sprintf("Missing required fields: %s", strings.Join(missingFields, ", ")))
+		}
+		if len(invalidFields) > 0 {
+			errorMessages = append(errorMessages,
+				fmt.Sprintf("Invalid fields: %s", strings.Join(invalidFields, ", ")))
+		}
+		WriteError(w, http.StatusBadRequest, errors.New(strings.Join(errorMessages, "; ")))
 		return err
 	}
 
@@ -102,6 +119,26 @@ func PrepareJSONWithMessage(message string, payload interface{}) map[string]inte
 	return jsonResponse
 }
 
+// PrepareJSONWithPagination works like PrepareJSONWithMessage and adds the
+// cursor of the next page, or null when there are no more results.
+func PrepareJSONWithPagination(message string, payload interface{}, nextCursor string) map[string]interface{} {
+	response := PrepareJSONWithMessage(message, payload)
+
+	if nextCursor == "" {
+		response["next_cursor"] = nil
+	} else {
+		response["next_cursor"] = nextCursor
+	}
+
+	return response
+}
+
+// PreparePayloadMap converts a struct into the same snake_case map used for
+// the data of PrepareJSONWithMessage, for responses that nest resources.
+func PreparePayloadMap(payload interface{}) map[string]interface{} {
+	return createPayloadMap(payload)
+}
+
 func handleSlicePayload(val reflect.Value) interface{} {
 	if val.Len() == 0 {
 		return []interface{}{}
@@ -132,6 +169,11 @@ func createPayloadMap(payload interface{}) map[string]interface{} {
 	typeOfPayload := val.Type()
 
 	for i := 0; i < val.NumField(); i++ {
+		// Fields tagged json:"-" (password hashes, secrets) are never exposed
+		if typeOfPayload.Field(i).Tag.Get("json") == "-" {
+			continue
+		}
+
 		fieldName := typeOfPayload.Field(i).Name
 		snakeCaseName := toSnakeCase(fieldName)
 		fieldValue := val.Field(i).Interface()
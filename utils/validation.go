@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"go-tasker/internal/recurrence"
+	"go-tasker/internal/webhook"
+	"go-tasker/types"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report JSON field names in validation errors instead of Go field names
+	Validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	Validate.RegisterValidation("rrule", func(fl validator.FieldLevel) bool {
+		return recurrence.Valid(fl.Field().String())
+	})
+
+	Validate.RegisterValidation("webhook_event", func(fl validator.FieldLevel) bool {
+		return webhook.ValidFilter(fl.Field().String())
+	})
+
+	Validate.RegisterValidation("regexp", func(fl validator.FieldLevel) bool {
+		_, err := regexp.Compile(fl.Field().String())
+		return err == nil
+	})
+
+	Validate.RegisterStructValidation(validateCreateTaskPayload, types.CreateTaskPayload{})
+	Validate.RegisterStructValidation(validateUpdateTaskPayload, types.UpdateTaskPayload{})
+	Validate.RegisterStructValidation(validateMovePayload, types.MovePayload{})
+	Validate.RegisterStructValidation(validateMoveTaskPayload, types.MoveTaskPayload{})
+	Validate.RegisterStructValidation(validateCopyTaskPayload, types.CopyTaskPayload{})
+	Validate.RegisterStructValidation(validateBulkTaskPayload, types.BulkTaskPayload{})
+}
+
+func validateCreateTaskPayload(sl validator.StructLevel) {
+	payload := sl.Current().Interface().(types.CreateTaskPayload)
+	validateSchedule(sl, payload.StartAt, payload.DueAt)
+}
+
+func validateUpdateTaskPayload(sl validator.StructLevel) {
+	payload := sl.Current().Interface().(types.UpdateTaskPayload)
+	validateSchedule(sl, payload.StartAt, payload.DueAt)
+}
+
+// validateMovePayload requires exactly one sibling to move next to.
+func validateMovePayload(sl validator.StructLevel) {
+	payload := sl.Current().Interface().(types.MovePayload)
+	if (payload.BeforeID == nil) == (payload.AfterID == nil) {
+		sl.ReportError(payload.BeforeID, "before_id", "BeforeID", "before_or_after", "")
+	}
+}
+
+// validateMoveTaskPayload allows at most one sibling when moving to another
+// list, and requires exactly one when reordering within the same list.
+func validateMoveTaskPayload(sl validator.StructLevel) {
+	payload := sl.Current().Interface().(types.MoveTaskPayload)
+	bothSiblings := payload.BeforeID != nil && payload.AfterID != nil
+	noSibling := payload.BeforeID == nil && payload.AfterID == nil
+	if bothSiblings || (noSibling && payload.ListID == nil) {
+		sl.ReportError(payload.BeforeID, "before_id", "BeforeID", "before_or_after", "")
+	}
+	if payload.ProjectID != nil && payload.ListID == nil {
+		sl.ReportError(payload.ListID, "list_id", "ListID", "required", "")
+	}
+}
+
+func validateCopyTaskPayload(sl validator.StructLevel) {
+	payload := sl.Current().Interface().(types.CopyTaskPayload)
+	if payload.ProjectID != nil && payload.ListID == nil {
+		sl.ReportError(payload.ListID, "list_id", "ListID", "required", "")
+	}
+}
+
+// validateBulkTaskPayload requires the tasks to be picked either by ID or
+// with a filter. Sending an empty task_ids counts as picking by ID, so it is
+// neither ignored next to a filter nor accepted as a request doing nothing.
+func validateBulkTaskPayload(sl validator.StructLevel) {
+	payload := sl.Current().Interface().(types.BulkTaskPayload)
+	byID := payload.TaskIDs != nil
+	switch {
+	case byID == (payload.Filter != nil):
+		sl.ReportError(payload.TaskIDs, "task_ids", "TaskIDs", "ids_or_filter", "")
+	case byID && len(payload.TaskIDs) == 0:
+		sl.ReportError(payload.TaskIDs, "task_ids", "TaskIDs", "min", "1")
+	}
+}
+
+// validateSchedule reports an error when a task would start after it is due.
+func validateSchedule(sl validator.StructLevel, startAt, dueAt *time.Time) {
+	if startAt == nil || dueAt == nil {
+		return
+	}
+
+	if startAt.After(*dueAt) {
+		sl.ReportError(dueAt, "due_at", "DueAt", "after_start", "start_at")
+	}
+}
+
+// describeValidationError turns a validator tag into a short human readable
+// explanation used in error responses.
+func describeValidationError(err validator.FieldError) string {
+	switch err.Tag() {
+	case "after_start":
+		return "must not be before start_at"
+	case "before_or_after":
+		return "or after_id must be set, but not both"
+	case "ids_or_filter":
+		return "or filter must be set, but not both"
+	case "regexp":
+		return "must be a valid regular expression"
+	case "oneof":
+		return "must be one of: " + err.Param()
+	case "min":
+		if err.Kind() == reflect.String {
+			return "must be at least " + err.Param() + " characters"
+		}
+		return "must be at least " + err.Param()
+	case "max":
+		if err.Kind() == reflect.String {
+			return "must be at most " + err.Param() + " characters"
+		}
+		return "must be at most " + err.Param()
+	case "email":
+		return "must be a valid email address"
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "hexcolor":
+		return "must be a hex colour such as #ff0000"
+	case "rrule":
+		return "must be a valid iCalendar RRULE such as FREQ=WEEKLY;BYDAY=MO"
+	case "excludes":
+		return "must not contain '" + err.Param() + "'"
+	default:
+		return "failed on the '" + err.Tag() + "' rule"
+	}
+}